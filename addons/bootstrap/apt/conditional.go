@@ -5,6 +5,7 @@ import (
 	"strings"
 
 	"fastcat.org/go/gdev/addons/bootstrap"
+	"fastcat.org/go/gdev/progress"
 )
 
 // AddPackageIfAvailable is like [bootstrap.AddAptPackagesStep], but will only add the
@@ -18,7 +19,7 @@ func AddPackageIfAvailable(stepName, packageName string) *bootstrap.Step {
 		} else if _, ok := avail[packageName]; ok {
 			AddPackages(ctx, packageName)
 		} else {
-			fmt.Printf("Package %s is not available, skipping\n", packageName)
+			progress.Logf(ctx, "Package %s is not available, skipping", packageName)
 		}
 		return nil
 	}
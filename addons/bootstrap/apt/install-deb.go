@@ -87,7 +87,7 @@ func SimDownloadedPackage[R PackageRelease](
 		return nil
 	}
 
-	fmt.Printf("Would install package %q version %s\n", name, rel.PackageVersion())
+	progress.Logf(ctx, "Would install package %q version %s", name, rel.PackageVersion())
 	return nil
 }
 
@@ -131,7 +131,7 @@ func prepDownloadedPackage[R PackageRelease](
 		}
 	}
 	if skip {
-		fmt.Printf("Skip: package %q version %s already installed\n", name, rel.PackageVersion())
+		progress.Logf(ctx, "Skip: package %q version %s already installed", name, rel.PackageVersion())
 		return opts, rel, true, nil
 	}
 	return opts, rel, false, nil
@@ -8,6 +8,7 @@ import (
 	"path/filepath"
 
 	"fastcat.org/go/gdev/lib/sys"
+	"fastcat.org/go/gdev/progress"
 )
 
 type SourceInstaller struct {
@@ -105,32 +106,32 @@ func (i *SourceInstaller) install(
 	}
 
 	if listEq && keyEq {
-		fmt.Printf("APT source %s already installed\n", i.SourceName)
+		progress.Logf(ctx, "APT source %s already installed", i.SourceName)
 		return false, nil
 	}
 
 	if sim {
 		if listEq {
-			fmt.Printf("Would not write apt source file %s, already up to date\n", filename)
+			progress.Logf(ctx, "Would not write apt source file %s, already up to date", filename)
 		} else {
-			fmt.Printf("Would write apt source file %s\n", filename)
+			progress.Logf(ctx, "Would write apt source file %s", filename)
 		}
 		if keyEq {
-			fmt.Printf("Would not write signing key %s, already up to date\n", i.Source.SignedBy)
+			progress.Logf(ctx, "Would not write signing key %s, already up to date", i.Source.SignedBy)
 		} else {
-			fmt.Printf("Would write signing key %s\n", i.Source.SignedBy)
+			progress.Logf(ctx, "Would write signing key %s", i.Source.SignedBy)
 		}
 		return true, nil
 	}
 
 	if !listEq {
-		fmt.Printf("Writing apt source file %s\n", filename)
+		progress.Logf(ctx, "Writing apt source file %s", filename)
 		if err := sys.WriteFileAsRoot(ctx, filename, content, 0o644); err != nil {
 			return true, fmt.Errorf("failed to write source file %q: %w", filename, err)
 		}
 	}
 	if !keyEq && len(i.Source.SignedBy) > 0 {
-		fmt.Printf("Writing signing key %s\n", i.Source.SignedBy)
+		progress.Logf(ctx, "Writing signing key %s", i.Source.SignedBy)
 		if err := sys.WriteFileAsRoot(ctx, i.Source.SignedBy, bytes.NewReader(i.SigningKey), 0o644); err != nil {
 			return true, fmt.Errorf("failed to write signing key %q: %w", i.Source.SignedBy, err)
 		}
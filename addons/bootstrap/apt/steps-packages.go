@@ -8,6 +8,7 @@ import (
 
 	"fastcat.org/go/gdev/addons/bootstrap"
 	"fastcat.org/go/gdev/lib/shx"
+	"fastcat.org/go/gdev/progress"
 )
 
 // Name of the step registered by [AddAptUpdate]. Steps that modify apt sources
@@ -166,7 +167,7 @@ func DoInstall(
 	}
 	// make printing deterministic
 	slices.Sort(cna[3:])
-	fmt.Printf("Installing: %s\n", strings.Join(cna[offset:], " "))
+	progress.Logf(ctx, "Installing: %s", strings.Join(cna[offset:], " "))
 	if _, err := shx.Run(
 		ctx,
 		cna,
@@ -237,7 +238,7 @@ func simInstall(ctx *bootstrap.Context) error {
 	for pkg := range pkgSet {
 		packages = append(packages, pkg)
 	}
-	fmt.Printf("Would install: %s\n", strings.Join(packages, ", "))
+	progress.Logf(ctx, "Would install: %s", strings.Join(packages, ", "))
 	clear(pkgSet)
 	return nil
 }
@@ -261,7 +262,7 @@ func AddPackages(ctx *bootstrap.Context, names ...string) {
 		}
 	}
 	if len(added) > 0 {
-		fmt.Printf("Queued packages to install: %s\n", strings.Join(added, " "))
+		progress.Logf(ctx, "Queued packages to install: %s", strings.Join(added, " "))
 	}
 }
 
@@ -6,6 +6,8 @@ import (
 	"maps"
 	"slices"
 	"strings"
+
+	"fastcat.org/go/gdev/progress"
 )
 
 type Plan struct {
@@ -90,19 +92,16 @@ func (p *Plan) Run(ctx context.Context) error {
 	}
 
 	for _, s := range p.ordered {
-		fmt.Printf("Running %s ...\n", s.name)
+		progress.Logf(ctx, "Running %s ...", s.name)
 		if err := s.run(bc); err != nil {
 			return err
 		}
 	}
 
-	fmt.Println()
-	fmt.Println("Bootstrap completed successfully")
+	progress.Logf(ctx, "Bootstrap completed successfully")
 
 	if needsReboot(bc) {
-		fmt.Println()
-		fmt.Println("IMPORTANT: You need to reboot before you can use the newly installed/updated tools!")
-		fmt.Println()
+		progress.Logf(ctx, "IMPORTANT: You need to reboot before you can use the newly installed/updated tools!")
 	}
 
 	return nil
@@ -219,17 +218,16 @@ func (p *Plan) Sim(ctx context.Context) error {
 
 	for _, s := range p.ordered {
 		if s.sim == nil {
-			fmt.Printf("Would run %s\n", s.name)
+			progress.Logf(ctx, "Would run %s", s.name)
 			continue
 		}
-		fmt.Printf("Simulating %s ...\n", s.name)
+		progress.Logf(ctx, "Simulating %s ...", s.name)
 		if err := s.sim(bc); err != nil {
 			return err
 		}
 	}
 
-	fmt.Println()
-	fmt.Println("Bootstrap simulated successfully")
+	progress.Logf(ctx, "Bootstrap simulated successfully")
 
 	return nil
 }
@@ -1,9 +1,8 @@
 package bootstrap
 
 import (
-	"fmt"
-
 	"fastcat.org/go/gdev/internal"
+	"fastcat.org/go/gdev/progress"
 )
 
 type Step struct {
@@ -78,7 +77,7 @@ func SkipFunc(f func(*Context) (bool, error)) StepOpt {
 				return err
 			}
 			if skip {
-				fmt.Println("Skipping", s.name)
+				progress.Logf(ctx, "Skipping %s", s.name)
 				return nil
 			}
 			return origRun(ctx)
@@ -90,7 +89,7 @@ func SkipFunc(f func(*Context) (bool, error)) StepOpt {
 				return err
 			}
 			if skip {
-				fmt.Println("Skipping", s.name)
+				progress.Logf(ctx, "Skipping %s", s.name)
 				return nil
 			}
 			if origSim != nil {
@@ -4,6 +4,8 @@ import (
 	"fmt"
 	"os/user"
 	"slices"
+
+	"fastcat.org/go/gdev/progress"
 )
 
 func IsCurrentUserInGroup(groupName string) (inGroup bool, userName string, err error) {
@@ -31,11 +33,11 @@ func EnsureCurrentUserInGroup(ctx *Context, groupName string) error {
 		return err
 	}
 	if inGroup {
-		fmt.Printf("User %s is already in group %s\n", userName, groupName)
+		progress.Logf(ctx, "User %s is already in group %s", userName, groupName)
 		return nil
 	}
 
-	fmt.Printf("Adding user %s to group %s\n", userName, groupName)
+	progress.Logf(ctx, "Adding user %s to group %s", userName, groupName)
 	SetNeedsReboot(ctx)
 
 	return addUserToGroup(ctx, userName, groupName)
@@ -20,6 +20,7 @@ var addon = addons.Addon[config]{
 	},
 	Config: config{
 		strategies: make(map[string]strategy),
+		vcs:        map[string]VCS{"git": gitVCS{}},
 	},
 }
 
@@ -30,6 +31,8 @@ func init() {
 type config struct {
 	strategies    map[string]strategy
 	strategyOrder []string
+	vcs           map[string]VCS
+	cloneMissing  bool
 }
 
 type option func(*config)
@@ -68,8 +71,12 @@ func initialize() error {
 		return err
 	}
 
-	instance.AddCommandBuilders(makeCmd)
+	instance.AddCommandBuilders(makeCmd, makeWatchCmd, makeSourceCmd)
 
+	if addon.Config.cloneMissing {
+		// must come first so the source is there to build
+		stack.AddPreStartHookType[cloneBeforeStart]()
+	}
 	stack.AddPreStartHookType[buildBeforeStart]()
 
 	return nil
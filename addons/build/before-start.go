@@ -7,7 +7,9 @@ import (
 	"path/filepath"
 	"slices"
 
+	"fastcat.org/go/gdev/addons/stack"
 	"fastcat.org/go/gdev/lib/shx"
+	"fastcat.org/go/gdev/progress"
 	"fastcat.org/go/gdev/service"
 )
 
@@ -37,6 +39,9 @@ func (b *buildBeforeStart) Service(ctx context.Context, svc service.Service) err
 	if m, ok := service.ServiceMode(ctx, svc.Name()); !ok {
 		// skip building if not running the service?
 		return nil
+	} else if m == service.ModeExcluded {
+		// the user is managing this service themselves, leave it alone
+		return nil
 	} else if !src.UsesSourceInMode(m) {
 		// not gonna use the source, so don't build it
 		return nil
@@ -81,14 +86,16 @@ func (b *buildBeforeStart) AfterServices(ctx context.Context, infra, svcs []serv
 			return fmt.Errorf("no build strategy for repo %s", prettyRoot)
 		}
 		opts := Options{ /* TODO */ }
+		done := stack.TimeStartStep(ctx, stack.TimingBuild, prettyRoot)
 		// if any service needs the repo root, use BuildAll
 		if slices.Contains(subDirs, "") {
-			fmt.Printf("Building %s using %s\n", prettyRoot, sn)
+			progress.Logf(ctx, "Building %s using %s", prettyRoot, sn)
 			err = b.BuildAll(ctx, opts)
 		} else {
-			fmt.Printf("Building %s using %s with subdirs %v\n", prettyRoot, sn, subDirs)
+			progress.Logf(ctx, "Building %s using %s with subdirs %v", prettyRoot, sn, subDirs)
 			err = b.BuildDirs(ctx, subDirs, opts)
 		}
+		done()
 		if err != nil {
 			return fmt.Errorf("error building repo %s with strategy %s: %w", prettyRoot, sn, err)
 		}
@@ -3,7 +3,9 @@ package build
 import (
 	"fmt"
 	"path/filepath"
+	"slices"
 	"strings"
+	"time"
 
 	"github.com/spf13/cobra"
 
@@ -90,3 +92,61 @@ func makeCmd() *cobra.Command {
 
 	return buildCmd
 }
+
+func makeWatchCmd() *cobra.Command {
+	var opts WatchOptions
+	watchCmd := &cobra.Command{
+		Use:     "watch [service...]",
+		Aliases: []string{"dev"},
+		Short:   "Rebuild and restart local mode services when their source changes",
+		Long: "Watches the local source of the named services, or of all services in local mode, " +
+			"rebuilding and restarting them when it changes. The stack should already be started.",
+		ValidArgsFunction: func(_ *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+			var candidates []string
+			for _, svc := range stack.AllServices() {
+				if n := svc.Name(); strings.HasPrefix(n, toComplete) && !slices.Contains(args, n) {
+					if _, ok := svc.(service.ServiceWithSource); ok {
+						candidates = append(candidates, n)
+					}
+				}
+			}
+			return candidates, cobra.ShellCompDirectiveNoFileComp
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			modes := service.ConfiguredModes()
+			var svcs []service.ServiceWithSource
+			if len(args) == 0 {
+				for _, svc := range stack.AllServices() {
+					if ss, ok := svc.(service.ServiceWithSource); ok && ss.UsesSourceInMode(modes[svc.Name()]) {
+						svcs = append(svcs, ss)
+					}
+				}
+				if len(svcs) == 0 {
+					return fmt.Errorf("no services are in a mode that uses local source")
+				}
+			}
+			for _, arg := range args {
+				svc := stack.ServiceByName(arg)
+				if svc == nil {
+					return fmt.Errorf("service %q not known", arg)
+				}
+				ss, ok := svc.(service.ServiceWithSource)
+				if !ok {
+					return fmt.Errorf("service %s does not have source to build", arg)
+				} else if !ss.UsesSourceInMode(modes[arg]) {
+					return fmt.Errorf("service %s does not use its local source in %s mode", arg, modes[arg])
+				}
+				svcs = append(svcs, ss)
+			}
+			ctx, err := stack.NewContext(cmd.Context(), service.WithServiceModes(modes))
+			if err != nil {
+				return err
+			}
+			return Watch(ctx, svcs, opts)
+		},
+	}
+	watchCmd.Flags().BoolVarP(&opts.Verbose, "verbose", "v", false, "print verbose build output")
+	watchCmd.Flags().DurationVar(&opts.Debounce, "debounce", 500*time.Millisecond,
+		"how long to wait after the last change before rebuilding")
+	return watchCmd
+}
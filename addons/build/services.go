@@ -6,6 +6,7 @@ import (
 	"path/filepath"
 	"slices"
 
+	"fastcat.org/go/gdev/progress"
 	"fastcat.org/go/gdev/service"
 )
 
@@ -52,14 +53,14 @@ func Services(
 	for i, b := range builders {
 		if len(subdirs[i]) == 0 || slices.Contains(subdirs[i], ".") {
 			if opts.Verbose {
-				fmt.Printf("Building %s with %s\n", b.Root(), strategies[i])
+				progress.Logf(ctx, "Building %s with %s", b.Root(), strategies[i])
 			}
 			if err := b.BuildAll(ctx, opts); err != nil {
 				return fmt.Errorf("error building %s: %w", b.Root(), err)
 			}
 		} else {
 			if opts.Verbose {
-				fmt.Printf("Building %d dirs in %s with %s\n", len(subdirs[i]), b.Root(), strategies[i])
+				progress.Logf(ctx, "Building %d dirs in %s with %s", len(subdirs[i]), b.Root(), strategies[i])
 			}
 			if err := b.BuildDirs(ctx, subdirs[i], opts); err != nil {
 				return fmt.Errorf("error building %s: %w", b.Root(), err)
@@ -0,0 +1,136 @@
+package build
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/jedib0t/go-pretty/v6/table"
+	"github.com/spf13/cobra"
+
+	"fastcat.org/go/gdev/addons/stack"
+	"fastcat.org/go/gdev/lib/shx"
+	"fastcat.org/go/gdev/service"
+)
+
+func makeSourceCmd() *cobra.Command {
+	sourceCmd := &cobra.Command{
+		Use:   "source",
+		Short: "manage the local source checkouts for services",
+		// just a parent for other commands
+	}
+	sourceCmd.AddCommand(
+		&cobra.Command{
+			Use:               "clone [service...]",
+			Short:             "clone the source for services that have not been checked out yet",
+			Long:              "Clones the source for the named services, or for all services with a remote source",
+			ValidArgsFunction: completeSourceServices,
+			RunE: func(cmd *cobra.Command, args []string) error {
+				svcs, err := sourceServices(args)
+				if err != nil {
+					return err
+				}
+				var errs []error
+				for _, svc := range svcs {
+					if len(args) == 0 {
+						// only clone what we can when not asked for specific services
+						if vcs, repo, _ := svc.RemoteSource(cmd.Context()); vcs == "" || repo == "" {
+							continue
+						}
+					}
+					if cloned, err := CloneSource(cmd.Context(), svc); err != nil {
+						errs = append(errs, err)
+					} else if !cloned {
+						root, _, _ := svc.LocalSource(cmd.Context())
+						fmt.Printf("Source for %s already present in %s\n", svc.Name(), shx.PrettyPath(root))
+					}
+				}
+				return errors.Join(errs...)
+			},
+		},
+		&cobra.Command{
+			Use:               "status [service...]",
+			Short:             "show the branch and state of the source checkouts for services",
+			ValidArgsFunction: completeSourceServices,
+			RunE: func(cmd *cobra.Command, args []string) error {
+				svcs, err := sourceServices(args)
+				if err != nil {
+					return err
+				}
+				modes := service.ConfiguredModes()
+				tw := table.NewWriter()
+				tw.SetStyle(table.StyleColoredBlueWhiteOnBlack)
+				tw.SetOutputMirror(cmd.OutOrStdout())
+				tw.AppendHeader(table.Row{"Service", "Mode", "Path", "Status"})
+				tw.AppendSeparator()
+				for _, svc := range svcs {
+					root, subDir, err := svc.LocalSource(cmd.Context())
+					var path, state string
+					if err != nil {
+						state = err.Error()
+					} else {
+						path = shx.PrettyPath(root)
+						if subDir != "" && subDir != "." {
+							path += " (" + subDir + ")"
+						}
+						if st, err := ServiceSourceStatus(cmd.Context(), svc); err != nil {
+							state = err.Error()
+						} else {
+							state = st.String()
+						}
+					}
+					tw.AppendRow(table.Row{svc.Name(), modes[svc.Name()], path, state})
+				}
+				tw.Render()
+				return nil
+			},
+		},
+	)
+	return sourceCmd
+}
+
+// sourceServices looks up the named services, or all services with source if
+// none are named.
+func sourceServices(names []string) ([]service.ServiceWithSource, error) {
+	var svcs []service.ServiceWithSource
+	if len(names) == 0 {
+		for _, svc := range append(stack.AllInfrastructure(), stack.AllServices()...) {
+			if ss, ok := svc.(service.ServiceWithSource); ok {
+				svcs = append(svcs, ss)
+			}
+		}
+		if len(svcs) == 0 {
+			return nil, fmt.Errorf("no services have source")
+		}
+		return svcs, nil
+	}
+	for _, name := range names {
+		svc := stack.ServiceByName(name)
+		if svc == nil {
+			return nil, fmt.Errorf("service %q not known", name)
+		}
+		ss, ok := svc.(service.ServiceWithSource)
+		if !ok {
+			return nil, fmt.Errorf("service %s does not have source", name)
+		}
+		svcs = append(svcs, ss)
+	}
+	return svcs, nil
+}
+
+func completeSourceServices(
+	_ *cobra.Command,
+	args []string,
+	toComplete string,
+) ([]string, cobra.ShellCompDirective) {
+	var candidates []string
+	for _, svc := range append(stack.AllInfrastructure(), stack.AllServices()...) {
+		if n := svc.Name(); strings.HasPrefix(n, toComplete) && !slices.Contains(args, n) {
+			if _, ok := svc.(service.ServiceWithSource); ok {
+				candidates = append(candidates, n)
+			}
+		}
+	}
+	return candidates, cobra.ShellCompDirectiveNoFileComp
+}
@@ -0,0 +1,121 @@
+package build
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"fastcat.org/go/gdev/lib/shx"
+	"fastcat.org/go/gdev/progress"
+	"fastcat.org/go/gdev/service"
+)
+
+// ErrNoLocalSource is returned (wrapped) by [ServiceSourceStatus] when the
+// service's source has not been checked out.
+var ErrNoLocalSource = errors.New("local source not checked out")
+
+// remoteVCS resolves the remote source and VCS for the service.
+func remoteVCS(ctx context.Context, svc service.ServiceWithSource) (VCS, string, error) {
+	vcsName, repo, err := svc.RemoteSource(ctx)
+	if err != nil {
+		return nil, "", fmt.Errorf("can't determine remote source for service %s: %w", svc.Name(), err)
+	} else if vcsName == "" || repo == "" {
+		return nil, "", fmt.Errorf("service %s has no remote source", svc.Name())
+	}
+	vcs := LookupVCS(vcsName)
+	if vcs == nil {
+		return nil, "", fmt.Errorf("service %s uses unsupported VCS %q", svc.Name(), vcsName)
+	}
+	return vcs, repo, nil
+}
+
+// CloneSource clones the remote source for the service into its local source
+// root, if that does not exist yet. It returns whether it cloned anything.
+func CloneSource(ctx context.Context, svc service.ServiceWithSource) (bool, error) {
+	root, _, err := svc.LocalSource(ctx)
+	if err != nil {
+		return false, fmt.Errorf("can't determine local source for service %s: %w", svc.Name(), err)
+	}
+	if _, err := os.Stat(root); err == nil {
+		return false, nil
+	} else if !os.IsNotExist(err) {
+		return false, fmt.Errorf("error checking local source for service %s in %s: %w", svc.Name(), root, err)
+	}
+	vcs, repo, err := remoteVCS(ctx, svc)
+	if err != nil {
+		return false, err
+	}
+	if err := os.MkdirAll(filepath.Dir(root), 0o755); err != nil {
+		return false, err
+	}
+	progress.Logf(ctx, "Cloning %s into %s for %s", repo, shx.PrettyPath(root), svc.Name())
+	if err := vcs.Clone(ctx, repo, root); err != nil {
+		return false, fmt.Errorf("error cloning source for service %s: %w", svc.Name(), err)
+	}
+	return true, nil
+}
+
+// ServiceSourceStatus describes the state of the service's local source
+// checkout.
+func ServiceSourceStatus(ctx context.Context, svc service.ServiceWithSource) (SourceStatus, error) {
+	root, _, err := svc.LocalSource(ctx)
+	if err != nil {
+		return SourceStatus{}, fmt.Errorf("can't determine local source for service %s: %w", svc.Name(), err)
+	}
+	if _, err := os.Stat(root); err != nil {
+		if os.IsNotExist(err) {
+			return SourceStatus{}, fmt.Errorf("%w in %s", ErrNoLocalSource, shx.PrettyPath(root))
+		}
+		return SourceStatus{}, err
+	}
+	vcs, _, err := remoteVCS(ctx, svc)
+	if err != nil {
+		return SourceStatus{}, err
+	}
+	return vcs.Status(ctx, root)
+}
+
+// cloneBeforeStart is a pre-start hook that clones missing source for services
+// that will use it, so that it can be built. It is enabled with
+// [WithCloneMissingSource].
+type cloneBeforeStart struct{}
+
+func (cloneBeforeStart) Name() string {
+	return "clone-before-start"
+}
+
+func (cloneBeforeStart) LoadServices(context.Context) error {
+	return nil
+}
+
+func (cloneBeforeStart) BeforeServices(context.Context, []service.Service, []service.Service) error {
+	return nil
+}
+
+func (cloneBeforeStart) Service(ctx context.Context, svc service.Service) error {
+	src, ok := svc.(service.ServiceWithSource)
+	if !ok {
+		return nil
+	}
+	if m, ok := service.ServiceMode(ctx, svc.Name()); !ok || m == service.ModeExcluded {
+		return nil
+	} else if !src.UsesSourceInMode(m) {
+		return nil
+	}
+	_, err := CloneSource(ctx, src)
+	return err
+}
+
+func (cloneBeforeStart) AfterServices(context.Context, []service.Service, []service.Service) error {
+	return nil
+}
+
+// WithCloneMissingSource enables cloning the source for services that are
+// started in a mode that uses it, if it has not been checked out yet.
+func WithCloneMissingSource() option {
+	return func(c *config) {
+		c.cloneMissing = true
+	}
+}
@@ -0,0 +1,139 @@
+package build
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	"fastcat.org/go/gdev/lib/shx"
+)
+
+// VCS is a version control system that can check out and inspect service
+// source repositories, as named by [service.ServiceWithSource.RemoteSource].
+type VCS interface {
+	// Clone checks out repo into dir, which should not exist yet.
+	Clone(ctx context.Context, repo, dir string) error
+	// Status describes the state of the checkout in dir.
+	Status(ctx context.Context, dir string) (SourceStatus, error)
+}
+
+// SourceStatus describes the state of a local source checkout.
+type SourceStatus struct {
+	// Branch is the current branch, or empty if it is detached.
+	Branch string `json:"branch,omitempty"`
+	// Dirty is set if there are uncommitted or untracked changes.
+	Dirty bool `json:"dirty"`
+	// Upstream is the branch being tracked, if any. Ahead and Behind are only
+	// meaningful if this is set.
+	Upstream string `json:"upstream,omitempty"`
+	Ahead    int    `json:"ahead,omitempty"`
+	Behind   int    `json:"behind,omitempty"`
+}
+
+func (s SourceStatus) String() string {
+	var sb strings.Builder
+	if s.Branch == "" {
+		sb.WriteString("(detached)")
+	} else {
+		sb.WriteString(s.Branch)
+	}
+	if s.Dirty {
+		sb.WriteString(", dirty")
+	}
+	if s.Upstream == "" {
+		sb.WriteString(", no upstream")
+	} else if s.Ahead != 0 || s.Behind != 0 {
+		fmt.Fprintf(&sb, ", %d ahead %d behind %s", s.Ahead, s.Behind, s.Upstream)
+	} else {
+		fmt.Fprintf(&sb, ", up to date with %s", s.Upstream)
+	}
+	return sb.String()
+}
+
+// WithVCS registers a VCS implementation under the name services use in
+// RemoteSource. Git is registered by default. Registering a name again replaces
+// the previous implementation, which allows customizing how git is used.
+func WithVCS(name string, vcs VCS) option {
+	return func(c *config) {
+		c.vcs[name] = vcs
+	}
+}
+
+// LookupVCS gets the registered VCS with the given name, or nil if there is
+// none.
+func LookupVCS(name string) VCS {
+	return addon.Config.vcs[name]
+}
+
+type gitVCS struct{}
+
+// Clone implements VCS.
+func (gitVCS) Clone(ctx context.Context, repo, dir string) error {
+	_, err := runGit(ctx, "", "clone", "--quiet", repo, dir)
+	return err
+}
+
+// Status implements VCS.
+func (gitVCS) Status(ctx context.Context, dir string) (SourceStatus, error) {
+	out, err := runGit(ctx, dir, "status", "--porcelain=v2", "--branch")
+	if err != nil {
+		return SourceStatus{}, err
+	}
+	return parseGitStatus(out)
+}
+
+func runGit(ctx context.Context, dir string, args ...string) (string, error) {
+	cmd := append([]string{"git"}, args...)
+	if dir != "" {
+		cmd = append([]string{"git", "-C", dir}, args...)
+	}
+	res, err := shx.Run(ctx, cmd, shx.CaptureOutput(), shx.CaptureError())
+	if err != nil {
+		return "", fmt.Errorf("failed to run git %s: %w", args[0], err)
+	}
+	defer res.Close() //nolint:errcheck
+	if err := res.Err(); err != nil {
+		if out := res.Stderr(); out != nil {
+			_, _ = io.Copy(os.Stderr, out)
+		}
+		return "", fmt.Errorf("git %s failed: %w", args[0], err)
+	}
+	out, err := io.ReadAll(res.Stdout())
+	return string(out), err
+}
+
+// parseGitStatus parses the output of `git status --porcelain=v2 --branch`.
+func parseGitStatus(out string) (SourceStatus, error) {
+	var s SourceStatus
+	for line := range strings.Lines(out) {
+		line = strings.TrimSuffix(line, "\n")
+		header, ok := strings.CutPrefix(line, "# ")
+		if !ok {
+			if line != "" {
+				s.Dirty = true
+			}
+			continue
+		}
+		key, value, _ := strings.Cut(header, " ")
+		switch key {
+		case "branch.head":
+			if value != "(detached)" {
+				s.Branch = value
+			}
+		case "branch.upstream":
+			s.Upstream = value
+		case "branch.ab":
+			ahead, behind, _ := strings.Cut(value, " ")
+			var err1, err2 error
+			s.Ahead, err1 = strconv.Atoi(strings.TrimPrefix(ahead, "+"))
+			s.Behind, err2 = strconv.Atoi(strings.TrimPrefix(behind, "-"))
+			if err1 != nil || err2 != nil {
+				return s, fmt.Errorf("invalid git branch.ab status %q", value)
+			}
+		}
+	}
+	return s, nil
+}
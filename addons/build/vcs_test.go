@@ -0,0 +1,88 @@
+package build
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"fastcat.org/go/gdev/resource"
+	"fastcat.org/go/gdev/service"
+)
+
+func Test_parseGitStatus(t *testing.T) {
+	s, err := parseGitStatus("# branch.oid abc123\n" +
+		"# branch.head main\n" +
+		"# branch.upstream origin/main\n" +
+		"# branch.ab +2 -1\n" +
+		"1 .M N... 100644 100644 100644 abc abc file.go\n")
+	require.NoError(t, err)
+	assert.Equal(t, SourceStatus{Branch: "main", Dirty: true, Upstream: "origin/main", Ahead: 2, Behind: 1}, s)
+	assert.Equal(t, "main, dirty, 2 ahead 1 behind origin/main", s.String())
+
+	s, err = parseGitStatus("# branch.oid abc123\n# branch.head (detached)\n")
+	require.NoError(t, err)
+	assert.Equal(t, SourceStatus{}, s)
+	assert.Equal(t, "(detached), no upstream", s.String())
+}
+
+func TestCloneSource(t *testing.T) {
+	git := func(dir string, args ...string) {
+		cmd := exec.Command("git", append([]string{
+			"-C", dir, "-c", "user.name=test", "-c", "user.email=test@example.com",
+		}, args...)...)
+		out, err := cmd.CombinedOutput()
+		require.NoError(t, err, string(out))
+	}
+	upstream := filepath.Join(t.TempDir(), "upstream")
+	require.NoError(t, os.Mkdir(upstream, 0o755))
+	git(upstream, "init", "-q", "-b", "main")
+	require.NoError(t, os.WriteFile(filepath.Join(upstream, "README"), []byte("hi\n"), 0o644))
+	git(upstream, "add", "README")
+	git(upstream, "commit", "-q", "-m", "initial")
+
+	local := filepath.Join(t.TempDir(), "src", "svc")
+	svc := service.New("svc",
+		service.WithResources(resource.Waiter("ready", func(context.Context) (bool, error) { return true, nil })),
+		service.WithSource(local, "", "git", upstream),
+	).(service.ServiceWithSource)
+
+	_, err := ServiceSourceStatus(t.Context(), svc)
+	assert.ErrorIs(t, err, ErrNoLocalSource)
+
+	cloned, err := CloneSource(t.Context(), svc)
+	require.NoError(t, err)
+	assert.True(t, cloned)
+	assert.FileExists(t, filepath.Join(local, "README"))
+
+	cloned, err = CloneSource(t.Context(), svc)
+	require.NoError(t, err)
+	assert.False(t, cloned)
+
+	require.NoError(t, os.WriteFile(filepath.Join(local, "new"), nil, 0o644))
+	git(local, "add", "new")
+	git(local, "commit", "-q", "-m", "local change")
+	st, err := ServiceSourceStatus(t.Context(), svc)
+	require.NoError(t, err)
+	assert.Equal(t, SourceStatus{Branch: "main", Upstream: "origin/main", Ahead: 1}, st)
+
+	noRemote := service.New("other",
+		service.WithResources(resource.Waiter("ready", func(context.Context) (bool, error) { return true, nil })),
+		service.WithSource(filepath.Join(t.TempDir(), "missing"), "", "", ""),
+	).(service.ServiceWithSource)
+	_, err = CloneSource(t.Context(), noRemote)
+	assert.ErrorContains(t, err, "service other has no remote source")
+}
+
+type fakeVCS struct{ VCS }
+
+func TestWithVCS_replace(t *testing.T) {
+	c := config{vcs: map[string]VCS{"git": gitVCS{}}}
+	WithVCS("git", fakeVCS{})(&c)
+	WithVCS("hg", fakeVCS{})(&c)
+	assert.Equal(t, map[string]VCS{"git": fakeVCS{}, "hg": fakeVCS{}}, c.vcs)
+}
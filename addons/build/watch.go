@@ -0,0 +1,273 @@
+package build
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"fastcat.org/go/gdev/addons/stack"
+	"fastcat.org/go/gdev/lib/shx"
+	"fastcat.org/go/gdev/lib/sys"
+	"fastcat.org/go/gdev/progress"
+	"fastcat.org/go/gdev/service"
+)
+
+type WatchOptions struct {
+	Options
+	// Debounce is how long to wait after the last change before rebuilding.
+	Debounce time.Duration
+}
+
+// watchedRepo tracks the services being watched within one repo root.
+type watchedRepo struct {
+	root     string
+	strategy string
+	builder  Builder
+	// git is set if the root is in a git repo, in which case git's ignore rules
+	// will be applied
+	git  bool
+	svcs []watchedService
+}
+
+type watchedService struct {
+	svc service.Service
+	// subDir is the cleaned, relative, source dir of the service within the repo
+	subDir string
+}
+
+// Watch watches the local source for the given services, and when it changes,
+// rebuilds it and restarts the services' resources that implement
+// [resource.Restarter]. Build and restart failures are reported but do not stop
+// the watch. Files ignored by git are ignored.
+//
+// The context must have the service and resource contexts set up, as for
+// [stack.StartServices]. Watch runs until the context is canceled.
+func Watch(ctx context.Context, svcs []service.ServiceWithSource, opts WatchOptions) error {
+	if opts.Debounce <= 0 {
+		opts.Debounce = 500 * time.Millisecond
+	}
+	var repos []*watchedRepo
+	for _, svc := range svcs {
+		root, subDir, err := svc.LocalSource(ctx)
+		if err != nil {
+			return fmt.Errorf("error getting local source for service %s: %w", svc.Name(), err)
+		}
+		if root, err = filepath.Abs(root); err != nil {
+			return fmt.Errorf("can't get absolute path for service %s in %s: %w", svc.Name(), root, err)
+		}
+		ws := watchedService{svc, filepath.Clean(subDir)}
+		if i := slices.IndexFunc(repos, func(r *watchedRepo) bool { return r.root == root }); i >= 0 {
+			repos[i].svcs = append(repos[i].svcs, ws)
+			continue
+		}
+		sn, b, err := DetectStrategy(root)
+		if err != nil {
+			return fmt.Errorf("can't detect build strategy repo %s: %w", shx.PrettyPath(root), err)
+		} else if b == nil {
+			return fmt.Errorf("no build strategy for repo %s", shx.PrettyPath(root))
+		}
+		repos = append(repos, &watchedRepo{
+			root:     root,
+			strategy: sn,
+			builder:  b,
+			git:      isGitRepo(ctx, root),
+			svcs:     []watchedService{ws},
+		})
+	}
+	if len(repos) == 0 {
+		return fmt.Errorf("no services to watch")
+	}
+
+	w, err := sys.NewWatcher()
+	if err != nil {
+		return err
+	}
+	defer w.Close() //nolint:errcheck
+	for _, repo := range repos {
+		for _, ws := range repo.svcs {
+			if err := repo.addTree(ctx, w, filepath.Join(repo.root, ws.subDir)); err != nil {
+				return err
+			}
+			dir := shx.PrettyPath(filepath.Join(repo.root, ws.subDir))
+			progress.Logf(ctx, "Watching %s for %s", dir, ws.svc.Name())
+		}
+	}
+
+	pending := map[string]bool{}
+	debounce := time.NewTimer(opts.Debounce)
+	debounce.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return context.Cause(ctx)
+		case err, ok := <-w.Errors():
+			if !ok {
+				return fmt.Errorf("file watcher closed unexpectedly")
+			}
+			progress.Logf(ctx, "WARNING: %v", err)
+		case ev := <-w.Events():
+			if slices.Contains(strings.Split(ev.Path, string(filepath.Separator)), ".git") {
+				continue
+			}
+			if ev.IsDir && ev.Created {
+				for _, repo := range repos {
+					if repo.contains(ev.Path) {
+						if err := repo.addTree(ctx, w, ev.Path); err != nil {
+							progress.Logf(ctx, "WARNING: %v", err)
+						}
+					}
+				}
+			}
+			pending[ev.Path] = true
+			debounce.Reset(opts.Debounce)
+		case <-debounce.C:
+			paths := pending
+			pending = map[string]bool{}
+			for _, repo := range repos {
+				repo.rebuild(ctx, paths, opts.Options)
+			}
+		}
+	}
+}
+
+func (r *watchedRepo) contains(path string) bool {
+	rel, err := filepath.Rel(r.root, path)
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
+// addTree watches dir and all its subdirectories that are not ignored.
+func (r *watchedRepo) addTree(ctx context.Context, w sys.Watcher, dir string) error {
+	for level := []string{dir}; len(level) != 0; {
+		var next []string
+		for _, d := range level {
+			if err := w.Add(d); err != nil {
+				if errors.Is(err, os.ErrNotExist) {
+					continue // deleted before we got to it
+				}
+				return err
+			}
+			entries, err := os.ReadDir(d)
+			if err != nil {
+				if errors.Is(err, os.ErrNotExist) {
+					continue
+				}
+				return err
+			}
+			for _, e := range entries {
+				if e.IsDir() && e.Name() != ".git" {
+					next = append(next, filepath.Join(d, e.Name()))
+				}
+			}
+		}
+		// check ignores a level at a time so we don't descend into things like
+		// node_modules
+		ignored, err := r.ignored(ctx, next)
+		if err != nil {
+			return err
+		}
+		level = slices.DeleteFunc(next, func(d string) bool { return ignored[d] })
+	}
+	return nil
+}
+
+// rebuild builds and restarts the services affected by the changed paths.
+func (r *watchedRepo) rebuild(ctx context.Context, paths map[string]bool, opts Options) {
+	var changed []string
+	for p := range paths {
+		if r.contains(p) {
+			changed = append(changed, p)
+		}
+	}
+	ignored, err := r.ignored(ctx, changed)
+	if err != nil {
+		progress.Logf(ctx, "WARNING: %v", err)
+	}
+	changed = slices.DeleteFunc(changed, func(p string) bool { return ignored[p] })
+	var subDirs []string
+	var svcs []service.Service
+	for _, ws := range r.svcs {
+		dir := filepath.Join(r.root, ws.subDir)
+		if slices.ContainsFunc(changed, func(p string) bool {
+			return p == dir || strings.HasPrefix(p, dir+string(filepath.Separator))
+		}) {
+			svcs = append(svcs, ws.svc)
+			if !slices.Contains(subDirs, ws.subDir) {
+				subDirs = append(subDirs, ws.subDir)
+			}
+		}
+	}
+	if len(svcs) == 0 {
+		return
+	}
+
+	prettyRoot := shx.PrettyPath(r.root)
+	if slices.Contains(subDirs, ".") {
+		progress.Logf(ctx, "Rebuilding %s using %s", prettyRoot, r.strategy)
+		err = r.builder.BuildAll(ctx, opts)
+	} else {
+		progress.Logf(ctx, "Rebuilding %s using %s with subdirs %v", prettyRoot, r.strategy, subDirs)
+		err = r.builder.BuildDirs(ctx, subDirs, opts)
+	}
+	if err != nil {
+		// keep the old build running, the user can fix it and we'll try again
+		progress.Logf(ctx, "Build of %s failed: %v", prettyRoot, err)
+		return
+	}
+
+	pctx, stop := progress.StartWriter(ctx)
+	err = stack.RestartServices(pctx, svcs...)
+	stop()
+	if err != nil {
+		progress.Logf(ctx, "Restart failed: %v", err)
+	}
+}
+
+func isGitRepo(ctx context.Context, root string) bool {
+	res, err := shx.Run(ctx,
+		[]string{"git", "-C", root, "rev-parse", "--git-dir"},
+		shx.CaptureCombined(),
+	)
+	defer res.Close() //nolint:errcheck
+	return err == nil && res.Err() == nil
+}
+
+// ignored checks which of the given absolute paths are ignored by git.
+func (r *watchedRepo) ignored(ctx context.Context, paths []string) (map[string]bool, error) {
+	if !r.git || len(paths) == 0 {
+		return nil, nil
+	}
+	res, err := shx.Run(ctx,
+		[]string{"git", "-C", r.root, "check-ignore", "--stdin", "-z"},
+		shx.FeedStdin(strings.NewReader(strings.Join(paths, "\x00"))),
+		shx.CaptureOutput(),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("error checking git ignores in %s: %w", shx.PrettyPath(r.root), err)
+	}
+	defer res.Close() //nolint:errcheck
+	if err := res.Err(); err != nil {
+		// exit code 1 means nothing is ignored
+		if ee := (*exec.ExitError)(nil); errors.As(err, &ee) && ee.ExitCode() == 1 {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("error checking git ignores in %s: %w", shx.PrettyPath(r.root), err)
+	}
+	out, err := io.ReadAll(res.Stdout())
+	if err != nil {
+		return nil, err
+	}
+	ignored := map[string]bool{}
+	for p := range strings.SplitSeq(string(out), "\x00") {
+		if p != "" {
+			ignored[p] = true
+		}
+	}
+	return ignored, nil
+}
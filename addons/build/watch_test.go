@@ -0,0 +1,53 @@
+package build
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"fastcat.org/go/gdev/lib/sys"
+)
+
+type fakeWatcher struct {
+	sys.Watcher
+	added []string
+}
+
+func (w *fakeWatcher) Add(dir string) error {
+	w.added = append(w.added, dir)
+	return nil
+}
+
+func Test_watchedRepo_addTree(t *testing.T) {
+	root := t.TempDir()
+	for _, d := range []string{"a/b", "node_modules/x", ".git"} {
+		require.NoError(t, os.MkdirAll(filepath.Join(root, d), 0o755))
+	}
+	require.NoError(t, os.WriteFile(filepath.Join(root, ".gitignore"), []byte("node_modules/\n"), 0o644))
+	require.NoError(t, exec.Command("git", "init", "-q", root).Run())
+
+	r := &watchedRepo{root: root, git: isGitRepo(t.Context(), root)}
+	require.True(t, r.git)
+	w := &fakeWatcher{}
+	require.NoError(t, r.addTree(t.Context(), w, root))
+	assert.ElementsMatch(t, []string{
+		root,
+		filepath.Join(root, "a"),
+		filepath.Join(root, "a/b"),
+	}, w.added)
+
+	ignored, err := r.ignored(t.Context(), []string{
+		filepath.Join(root, "a/b/c.go"),
+		filepath.Join(root, "node_modules/x/y.js"),
+	})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]bool{filepath.Join(root, "node_modules/x/y.js"): true}, ignored)
+
+	assert.True(t, r.contains(filepath.Join(root, "a")))
+	assert.False(t, r.contains(filepath.Dir(root)))
+	assert.False(t, r.contains(root+"x/y"))
+}
@@ -6,6 +6,8 @@ import (
 	"fmt"
 
 	"golang.org/x/sync/errgroup"
+
+	"fastcat.org/go/gdev/progress"
 )
 
 type Collection struct {
@@ -20,7 +22,7 @@ func (c *Collection) Run(ctx context.Context) error {
 	if err := c.Dest.Begin(ctx); err != nil {
 		return fmt.Errorf("error beginning collector: %w", err)
 	}
-	fmt.Printf("Collecting diagnostics to %s\n", c.Dest.Destination())
+	progress.Logf(ctx, "Collecting diagnostics to %s", c.Dest.Destination())
 
 	srcCtx, stopSrc := context.WithCancel(ctx)
 	defer stopSrc()
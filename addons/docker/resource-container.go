@@ -5,6 +5,8 @@ import (
 	"fmt"
 	"maps"
 	"net/netip"
+	"slices"
+	"strings"
 
 	"github.com/containerd/errdefs"
 	"github.com/docker/go-connections/nat"
@@ -128,17 +130,204 @@ func (c *ContainerResource) ID() string {
 }
 
 // Ready implements resource.ContainerResource.
-func (c *ContainerResource) Ready(context.Context) (bool, error) {
-	// TODO
-	return true, nil
+func (c *ContainerResource) Ready(ctx context.Context) (bool, error) {
+	status, err := c.ReadyDetails(ctx)
+	return status.Ready(), err
 }
 
-// Start implements resource.ContainerResource.
+// ReadyDetails implements resource.ReadyDetailer.
+//
+// The container is ready if it is running, and if it has a health check, that
+// health check is passing.
+func (c *ContainerResource) ReadyDetails(ctx context.Context) (resource.ReadyStatus, error) {
+	cli := resource.ContextValue[client.APIClient](ctx)
+	if cli == nil {
+		err := fmt.Errorf("docker client not found in context")
+		return resource.ReadyStatusFromBool(false, err), err
+	}
+	res, err := cli.ContainerInspect(ctx, c.realName(), client.ContainerInspectOptions{})
+	if err != nil {
+		if errdefs.IsNotFound(err) {
+			return resource.ReadyStatus{
+				State:   resource.ReadyStateNotReady,
+				Message: "container does not exist",
+			}, nil
+		}
+		err = fmt.Errorf("failed to inspect container %s(%s): %w", c.Name, c.ID(), err)
+		return resource.ReadyStatusFromBool(false, err), err
+	}
+	st := res.Container.State
+	if st == nil {
+		return resource.ReadyStatus{State: resource.ReadyStateUnknown}, nil
+	}
+	status := resource.ReadyStatus{
+		State:   resource.ReadyStateNotReady,
+		Message: fmt.Sprintf("container is %s", st.Status),
+	}
+	switch st.Status {
+	case container.StateRunning:
+		status.State = resource.ReadyStateReady
+	case container.StateExited, container.StateDead:
+		status.State = resource.ReadyStateFailed
+		status.Message += fmt.Sprintf(" with code %d", st.ExitCode)
+		if st.OOMKilled {
+			status.Message += " (OOM killed)"
+		}
+	}
+	if st.Error != "" {
+		status.Conditions = append(status.Conditions, resource.ReadyCondition{
+			Name:    "error",
+			State:   resource.ReadyStateFailed,
+			Message: st.Error,
+		})
+	}
+	if h := st.Health; h != nil && h.Status != container.NoHealthcheck {
+		hc := resource.ReadyCondition{Name: "health", Message: string(h.Status)}
+		switch h.Status {
+		case container.Healthy:
+			hc.State = resource.ReadyStateReady
+		default:
+			hc.State = resource.ReadyStateNotReady
+			if h.FailingStreak > 0 {
+				hc.Message += fmt.Sprintf(", failed %d times", h.FailingStreak)
+			}
+			if len(h.Log) > 0 {
+				if out := strings.TrimSpace(h.Log[len(h.Log)-1].Output); out != "" {
+					hc.Message += ": " + out
+				}
+			}
+			if status.State == resource.ReadyStateReady {
+				status.State = resource.ReadyStateNotReady
+			}
+		}
+		status.Conditions = append(status.Conditions, hc)
+	}
+	return status, nil
+}
+
+// Start implements resource.ContainerResource. If the container already
+// exists, it is left alone if it is up to date and running, started if it is
+// up to date but stopped, and otherwise replaced.
 func (c *ContainerResource) Start(ctx context.Context) error {
 	cli := resource.ContextValue[client.APIClient](ctx)
 	if cli == nil {
 		return fmt.Errorf("docker client not found in context")
 	}
+	cc, hc, err := c.configs()
+	if err != nil {
+		return err
+	}
+	res, err := cli.ContainerInspect(ctx, c.realName(), client.ContainerInspectOptions{})
+	switch {
+	case errdefs.IsNotFound(err):
+		// create it below
+	case err != nil:
+		return fmt.Errorf("failed to inspect container %s(%s): %w", c.Name, c.ID(), err)
+	case len(containerChanges(cc, hc, res.Container)) == 0:
+		if st := res.Container.State; st != nil && st.Status == container.StateRunning {
+			return nil
+		}
+		if _, err := cli.ContainerStart(ctx, res.Container.ID, client.ContainerStartOptions{}); err != nil {
+			return fmt.Errorf("failed to start container %s(%s): %w", c.Name, c.ID(), err)
+		}
+		return nil
+	default:
+		// the name is fixed, so the old one has to go before we can replace it
+		_, err := cli.ContainerRemove(ctx, res.Container.ID, client.ContainerRemoveOptions{Force: true})
+		if err != nil && !errdefs.IsNotFound(err) {
+			return fmt.Errorf("failed to remove outdated container %s(%s): %w", c.Name, c.ID(), err)
+		}
+	}
+	cr, err := cli.ContainerCreate(
+		ctx,
+		client.ContainerCreateOptions{
+			Config:           &cc,
+			HostConfig:       &hc,
+			NetworkingConfig: nil,
+			Platform:         nil,
+			Name:             c.realName(),
+		},
+	)
+	if err != nil {
+		return fmt.Errorf("failed to create container %s: %w", c.Name, err)
+	}
+	_, err = cli.ContainerStart(ctx, cr.ID, client.ContainerStartOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to start container %s(%s): %w", c.Name, c.ID(), err)
+	}
+	return nil
+}
+
+// Plan implements resource.Planner, comparing the desired container config with
+// that of the existing container.
+func (c *ContainerResource) Plan(ctx context.Context) (resource.Plan, error) {
+	cli := resource.ContextValue[client.APIClient](ctx)
+	if cli == nil {
+		return resource.Plan{}, fmt.Errorf("docker client not found in context")
+	}
+	cc, hc, err := c.configs()
+	if err != nil {
+		return resource.Plan{}, err
+	}
+	res, err := cli.ContainerInspect(ctx, c.realName(), client.ContainerInspectOptions{})
+	if err != nil {
+		if errdefs.IsNotFound(err) {
+			return resource.Plan{Action: resource.PlanCreate}, nil
+		}
+		return resource.Plan{}, fmt.Errorf("failed to inspect container %s(%s): %w", c.Name, c.ID(), err)
+	}
+	if changes := containerChanges(cc, hc, res.Container); len(changes) != 0 {
+		return resource.Plan{Action: resource.PlanUpdate, Changes: changes}, nil
+	} else if st := res.Container.State; st == nil || st.Status != container.StateRunning {
+		return resource.Plan{Action: resource.PlanRestart}, nil
+	}
+	return resource.Plan{Action: resource.PlanNone}, nil
+}
+
+// containerChanges lists what differs between the desired configs and the
+// existing container, such that it needs to be recreated.
+func containerChanges(cc container.Config, hc container.HostConfig, res container.InspectResponse) []string {
+	var changes []string
+	if cur := res.Config; cur == nil {
+		changes = append(changes, "config")
+	} else {
+		if cur.Image != cc.Image {
+			changes = append(changes, "image")
+		}
+		// the container will have defaults from the image for these if we didn't
+		// set them
+		if cc.Cmd != nil && !slices.Equal(cur.Cmd, cc.Cmd) {
+			changes = append(changes, "cmd")
+		}
+		if cc.Entrypoint != nil && !slices.Equal(cur.Entrypoint, cc.Entrypoint) {
+			changes = append(changes, "entrypoint")
+		}
+		if slices.ContainsFunc(cc.Env, func(e string) bool { return !slices.Contains(cur.Env, e) }) {
+			changes = append(changes, "env")
+		}
+		for k, v := range cc.Labels {
+			if cur.Labels[k] != v {
+				changes = append(changes, "labels")
+				break
+			}
+		}
+		if !maps.Equal(cur.ExposedPorts, cc.ExposedPorts) {
+			changes = append(changes, "ports")
+		}
+	}
+	if cur := res.HostConfig; cur == nil || len(cur.Mounts) != len(hc.Mounts) ||
+		slices.ContainsFunc(hc.Mounts, func(m mount.Mount) bool {
+			return !slices.ContainsFunc(cur.Mounts, func(cm mount.Mount) bool {
+				return cm.Type == m.Type && cm.Source == m.Source && cm.Target == m.Target
+			})
+		}) {
+		changes = append(changes, "mounts")
+	}
+	return changes
+}
+
+// configs builds the docker configs to create the container.
+func (c *ContainerResource) configs() (container.Config, container.HostConfig, error) {
 	cc := container.Config{
 		Image:  c.Image,
 		Labels: containers.DefaultLabels(),
@@ -164,13 +353,13 @@ func (c *ContainerResource) Start(ctx context.Context) error {
 	if len(c.Ports) > 0 {
 		exposed, bindings, err := nat.ParsePortSpecs(c.Ports)
 		if err != nil {
-			return fmt.Errorf("failed to parse port specs %v: %w", c.Ports, err)
+			return cc, hc, fmt.Errorf("failed to parse port specs %v: %w", c.Ports, err)
 		}
 		// now we have to parse them _again_
 		ep2 := make(network.PortSet, len(exposed))
 		for p := range exposed {
 			if pp, err := network.ParsePort(string(p)); err != nil {
-				return fmt.Errorf("failed to parse exposed port %q: %w", p, err)
+				return cc, hc, fmt.Errorf("failed to parse exposed port %q: %w", p, err)
 			} else {
 				ep2[pp] = struct{}{}
 			}
@@ -178,7 +367,7 @@ func (c *ContainerResource) Start(ctx context.Context) error {
 		b2 := make(network.PortMap, len(bindings))
 		for p, bs := range bindings {
 			if pp, err := network.ParsePort(string(p)); err != nil {
-				return fmt.Errorf("failed to parse port binding port %q: %w", p, err)
+				return cc, hc, fmt.Errorf("failed to parse port binding port %q: %w", p, err)
 			} else {
 				b2pp := make([]network.PortBinding, 0, len(bs))
 				for _, b := range bs {
@@ -187,7 +376,7 @@ func (c *ContainerResource) Start(ctx context.Context) error {
 						continue
 					}
 					if hip, err := netip.ParseAddr(b.HostIP); err != nil {
-						return fmt.Errorf("failed to parse port binding host IP %q: %w", b.HostIP, err)
+						return cc, hc, fmt.Errorf("failed to parse port binding host IP %q: %w", b.HostIP, err)
 					} else {
 						b2pp = append(b2pp, network.PortBinding{
 							HostIP:   hip,
@@ -203,27 +392,10 @@ func (c *ContainerResource) Start(ctx context.Context) error {
 	}
 	for _, fn := range c.hostConfigFn {
 		if err := fn(&hc); err != nil {
-			return fmt.Errorf("custom HostConfig failed: %w", err)
+			return cc, hc, fmt.Errorf("custom HostConfig failed: %w", err)
 		}
 	}
-	cr, err := cli.ContainerCreate(
-		ctx,
-		client.ContainerCreateOptions{
-			Config:           &cc,
-			HostConfig:       &hc,
-			NetworkingConfig: nil,
-			Platform:         nil,
-			Name:             c.realName(),
-		},
-	)
-	if err != nil {
-		return fmt.Errorf("failed to create container %s: %w", c.Name, err)
-	}
-	_, err = cli.ContainerStart(ctx, cr.ID, client.ContainerStartOptions{})
-	if err != nil {
-		return fmt.Errorf("failed to start container %s(%s): %w", c.Name, c.ID(), err)
-	}
-	return nil
+	return cc, hc, nil
 }
 
 // Stop implements resource.ContainerResource.
@@ -252,6 +424,22 @@ func (c *ContainerResource) Stop(ctx context.Context) error {
 	return nil
 }
 
+// Stopped implements resource.StoppedChecker. The container is stopped once it
+// has been removed.
+func (c *ContainerResource) Stopped(ctx context.Context) (bool, error) {
+	cli := resource.ContextValue[client.APIClient](ctx)
+	if cli == nil {
+		return false, fmt.Errorf("docker client not found in context")
+	}
+	if _, err := cli.ContainerInspect(ctx, c.realName(), client.ContainerInspectOptions{}); err != nil {
+		if errdefs.IsNotFound(err) {
+			return true, nil
+		}
+		return false, fmt.Errorf("failed to inspect container %s(%s): %w", c.Name, c.ID(), err)
+	}
+	return false, nil
+}
+
 func (c *ContainerResource) realName() string {
 	return instance.AppName() + "-" + c.Name
 }
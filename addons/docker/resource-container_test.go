@@ -0,0 +1,121 @@
+package docker
+
+import (
+	"context"
+	"testing"
+
+	"github.com/containerd/errdefs"
+	"github.com/moby/moby/api/types/container"
+	"github.com/moby/moby/client"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"fastcat.org/go/gdev/resource"
+)
+
+// fakeDocker records the container operations, with at most one existing
+// container.
+type fakeDocker struct {
+	client.APIClient
+	existing *container.InspectResponse
+	calls    []string
+}
+
+func (f *fakeDocker) ContainerInspect(
+	_ context.Context,
+	id string,
+	_ client.ContainerInspectOptions,
+) (client.ContainerInspectResult, error) {
+	f.calls = append(f.calls, "inspect "+id)
+	if f.existing == nil {
+		return client.ContainerInspectResult{}, errdefs.ErrNotFound
+	}
+	return client.ContainerInspectResult{Container: *f.existing}, nil
+}
+
+func (f *fakeDocker) ContainerCreate(
+	_ context.Context,
+	opts client.ContainerCreateOptions,
+) (client.ContainerCreateResult, error) {
+	f.calls = append(f.calls, "create "+opts.Name)
+	return client.ContainerCreateResult{ID: "new"}, nil
+}
+
+func (f *fakeDocker) ContainerStart(
+	_ context.Context,
+	id string,
+	_ client.ContainerStartOptions,
+) (client.ContainerStartResult, error) {
+	f.calls = append(f.calls, "start "+id)
+	return client.ContainerStartResult{}, nil
+}
+
+func (f *fakeDocker) ContainerRemove(
+	_ context.Context,
+	id string,
+	_ client.ContainerRemoveOptions,
+) (client.ContainerRemoveResult, error) {
+	f.calls = append(f.calls, "remove "+id)
+	return client.ContainerRemoveResult{}, nil
+}
+
+func TestContainerResource_existing(t *testing.T) {
+	c := &ContainerResource{Name: "db", Image: "postgres:17", Env: map[string]string{"A": "1"}}
+	cc, hc, err := c.configs()
+	require.NoError(t, err)
+	existing := func(image string, status container.ContainerState) *container.InspectResponse {
+		cc := cc
+		cc.Image = image
+		return &container.InspectResponse{
+			ID:         "old",
+			Config:     &cc,
+			HostConfig: &hc,
+			State:      &container.State{Status: status},
+		}
+	}
+
+	for _, tt := range []struct {
+		name     string
+		existing *container.InspectResponse
+		plan     resource.Plan
+		calls    []string
+	}{
+		{
+			"missing",
+			nil,
+			resource.Plan{Action: resource.PlanCreate},
+			[]string{"create test-db", "start new"},
+		},
+		{
+			"up to date",
+			existing("postgres:17", container.StateRunning),
+			resource.Plan{Action: resource.PlanNone},
+			nil,
+		},
+		{
+			"stopped",
+			existing("postgres:17", container.StateExited),
+			resource.Plan{Action: resource.PlanRestart},
+			[]string{"start old"},
+		},
+		{
+			"changed",
+			existing("postgres:16", container.StateRunning),
+			resource.Plan{Action: resource.PlanUpdate, Changes: []string{"image"}},
+			[]string{"remove old", "create test-db", "start new"},
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			fake := &fakeDocker{existing: tt.existing}
+			ctx := resource.NewEmptyContext(t.Context(), resource.WithValue[client.APIClient](fake))
+			plan, err := c.Plan(ctx)
+			require.NoError(t, err)
+			assert.Equal(t, tt.plan, plan)
+
+			fake.calls = nil
+			require.NoError(t, c.Start(ctx))
+			// the start always inspects first
+			assert.Equal(t, append([]string{"inspect test-db"}, tt.calls...), fake.calls)
+		})
+	}
+}
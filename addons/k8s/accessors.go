@@ -2,6 +2,7 @@ package k8s
 
 import (
 	"context"
+	"fmt"
 	"time"
 
 	apiAppsV1 "k8s.io/api/apps/v1"
@@ -59,6 +60,33 @@ type accessor[
 	resourceMeta func(r *Resource) (*apiMetaV1.TypeMeta, *apiMetaV1.ObjectMeta)
 	podTemplate  func(a Apply) *applyCoreV1.PodSpecApplyConfiguration
 	ready        func(ctx context.Context, c Interface, r *Resource) (bool, error)
+	// details optionally provides more information on why the resource is or is
+	// not ready, to augment ready.
+	details func(ctx context.Context, c Interface, r *Resource) (message string, conditions []resource.ReadyCondition, err error)
+}
+
+// readyStatus combines ready and details to provide a structured ready status.
+func (acc accessor[Client, Resource, Apply]) readyStatus(
+	ctx context.Context,
+	c Interface,
+	r *Resource,
+) (resource.ReadyStatus, error) {
+	ready, err := acc.ready(ctx, c, r)
+	status := resource.ReadyStatusFromBool(ready, err)
+	if err != nil || acc.details == nil {
+		return status, err
+	}
+	var detailsErr error
+	status.Message, status.Conditions, detailsErr = acc.details(ctx, c, r)
+	if detailsErr != nil {
+		// don't fail the ready check just because we can't get the details
+		status.Conditions = append(status.Conditions, resource.ReadyCondition{
+			Name:    "details",
+			State:   resource.ReadyStateUnknown,
+			Message: detailsErr.Error(),
+		})
+	}
+	return status, nil
 }
 
 func applyToAPITypeMeta(tm applyMetaV1.TypeMetaApplyConfiguration) apiMetaV1.TypeMeta {
@@ -114,6 +142,13 @@ var accStatefulSet = accessor[
 			s.Replicas == *r.Spec.Replicas
 		return ready, nil
 	},
+	details: func(ctx context.Context, c Interface, r *apiAppsV1.StatefulSet) (string, []resource.ReadyCondition, error) {
+		s := r.Status
+		msg := fmt.Sprintf("%d/%d replicas ready, %d updated",
+			s.ReadyReplicas, internal.ValueOrZero(r.Spec.Replicas), s.UpdatedReplicas)
+		conds, err := podConditions(ctx, c, r.Namespace, r.Spec.Selector)
+		return msg, conds, err
+	},
 }
 
 var accDeployment = accessor[
@@ -161,6 +196,13 @@ var accDeployment = accessor[
 			s.ReadyReplicas > 0
 		return ready, nil
 	},
+	details: func(ctx context.Context, c Interface, r *apiAppsV1.Deployment) (string, []resource.ReadyCondition, error) {
+		s := r.Status
+		msg := fmt.Sprintf("%d/%d replicas ready, %d updated",
+			s.ReadyReplicas, internal.ValueOrZero(r.Spec.Replicas), s.UpdatedReplicas)
+		conds, err := podConditions(ctx, c, r.Namespace, r.Spec.Selector)
+		return msg, conds, err
+	},
 }
 
 var accService = accessor[
@@ -216,6 +258,18 @@ var accService = accessor[
 		}
 		return false, nil
 	},
+	details: func(ctx context.Context, c Interface, svc *apiCoreV1.Service) (string, []resource.ReadyCondition, error) {
+		if len(svc.Spec.Selector) == 0 {
+			return "", nil, nil
+		}
+		conds, err := podConditions(ctx, c, svc.Namespace, &apiMetaV1.LabelSelector{
+			MatchLabels: svc.Spec.Selector,
+		})
+		if err == nil && len(conds) == 0 {
+			return "no pods match the service selector", nil, nil
+		}
+		return "", conds, err
+	},
 }
 
 var accEPSlice = accessor[
@@ -496,6 +550,17 @@ var accBatchJob = accessor[
 		}
 		return false, nil
 	},
+	details: func(ctx context.Context, c Interface, r *apiBatchV1.Job) (string, []resource.ReadyCondition, error) {
+		s := r.Status
+		msg := fmt.Sprintf("%d active, %d succeeded, %d failed", s.Active, s.Succeeded, s.Failed)
+		for _, jc := range s.Conditions {
+			if jc.Type == apiBatchV1.JobFailed && jc.Status == apiCoreV1.ConditionTrue {
+				msg = fmt.Sprintf("job failed: %s: %s", jc.Reason, jc.Message)
+			}
+		}
+		conds, err := podConditions(ctx, c, r.Namespace, r.Spec.Selector)
+		return msg, conds, err
+	},
 }
 
 var accPod = accessor[
@@ -539,6 +604,9 @@ var accPod = accessor[
 		// TODO: check all the container statuses too?
 		return false, nil
 	},
+	details: func(_ context.Context, _ Interface, r *apiCoreV1.Pod) (string, []resource.ReadyCondition, error) {
+		return string(r.Status.Phase), containerConditions(r), nil
+	},
 }
 
 var accSecret = accessor[
@@ -632,16 +700,23 @@ func listOpts(context.Context) apiMetaV1.ListOptions {
 	}
 }
 
-func applyOpts(context.Context) apiMetaV1.ApplyOptions {
-	return apiMetaV1.ApplyOptions{
+func applyOpts(ctx context.Context) apiMetaV1.ApplyOptions {
+	opts := apiMetaV1.ApplyOptions{
 		Force:        true,
 		FieldManager: instance.AppName(),
-		// TODO: dry run
 	}
+	if resource.DryRun(ctx) {
+		opts.DryRun = []string{apiMetaV1.DryRunAll}
+	}
+	return opts
 }
 
-func deleteOpts(context.Context) apiMetaV1.DeleteOptions {
-	return apiMetaV1.DeleteOptions{
+func deleteOpts(ctx context.Context) apiMetaV1.DeleteOptions {
+	opts := apiMetaV1.DeleteOptions{
 		PropagationPolicy: new(apiMetaV1.DeletePropagationBackground),
 	}
+	if resource.DryRun(ctx) {
+		opts.DryRun = []string{apiMetaV1.DryRunAll}
+	}
+	return opts
 }
@@ -0,0 +1,83 @@
+package k8s
+
+import (
+	"encoding/json"
+	"fmt"
+	"maps"
+	"reflect"
+	"slices"
+)
+
+// objectChanges lists the paths of the fields that differ between the live
+// object and what it would be after applying, e.g. from a dry-run apply. Status
+// and bookkeeping metadata that changes on every write are ignored.
+func objectChanges(live, next any) ([]string, error) {
+	l, err := planMap(live)
+	if err != nil {
+		return nil, err
+	}
+	n, err := planMap(next)
+	if err != nil {
+		return nil, err
+	}
+	var changes []string
+	diffValues("", l, n, &changes)
+	return changes, nil
+}
+
+// planMap converts an object to its JSON form, without the fields objectChanges
+// ignores.
+func planMap(obj any) (map[string]any, error) {
+	data, err := json.Marshal(obj)
+	if err != nil {
+		return nil, err
+	}
+	var m map[string]any
+	if err := json.Unmarshal(data, &m); err != nil {
+		return nil, err
+	}
+	delete(m, "status")
+	if md, ok := m["metadata"].(map[string]any); ok {
+		for _, k := range []string{"resourceVersion", "managedFields", "generation"} {
+			delete(md, k)
+		}
+	}
+	return m, nil
+}
+
+func diffValues(path string, a, b any, changes *[]string) {
+	switch av := a.(type) {
+	case map[string]any:
+		bv, ok := b.(map[string]any)
+		if !ok {
+			break
+		}
+		keys := slices.Collect(maps.Keys(av))
+		for k := range bv {
+			if _, ok := av[k]; !ok {
+				keys = append(keys, k)
+			}
+		}
+		slices.Sort(keys)
+		for _, k := range keys {
+			p := k
+			if path != "" {
+				p = path + "." + k
+			}
+			diffValues(p, av[k], bv[k], changes)
+		}
+		return
+	case []any:
+		bv, ok := b.([]any)
+		if !ok || len(av) != len(bv) {
+			break
+		}
+		for i := range av {
+			diffValues(fmt.Sprintf("%s[%d]", path, i), av[i], bv[i], changes)
+		}
+		return
+	}
+	if !reflect.DeepEqual(a, b) {
+		*changes = append(*changes, path)
+	}
+}
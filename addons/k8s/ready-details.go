@@ -0,0 +1,140 @@
+package k8s
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	apiCoreV1 "k8s.io/api/core/v1"
+	apiMetaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"fastcat.org/go/gdev/resource"
+)
+
+// failedWaitingReasons are container waiting reasons that indicate the
+// container will not start without intervention (or at least not soon).
+var failedWaitingReasons = []string{
+	"CrashLoopBackOff",
+	"ImagePullBackOff",
+	"ErrImagePull",
+	"ErrImageNeverPull",
+	"InvalidImageName",
+	"CreateContainerConfigError",
+	"CreateContainerError",
+	"RunContainerError",
+}
+
+// podConditions lists the pods matching the selector and describes the
+// readiness of each.
+func podConditions(
+	ctx context.Context,
+	c Interface,
+	namespace string,
+	selector *apiMetaV1.LabelSelector,
+) ([]resource.ReadyCondition, error) {
+	if selector == nil {
+		return nil, nil
+	}
+	ls, err := apiMetaV1.LabelSelectorAsSelector(selector)
+	if err != nil {
+		return nil, err
+	}
+	lo := listOpts(ctx)
+	lo.LabelSelector = ls.String()
+	pods, err := accPod.list(ctx, accPod.getClient(c, Namespace(namespace)), lo)
+	if err != nil {
+		return nil, err
+	}
+	ret := make([]resource.ReadyCondition, 0, len(pods))
+	for i := range pods {
+		ret = append(ret, podCondition(&pods[i]))
+	}
+	return ret, nil
+}
+
+// podCondition summarizes a pod's readiness, including why its containers are
+// not ready, into a single condition.
+func podCondition(pod *apiCoreV1.Pod) resource.ReadyCondition {
+	rc := resource.ReadyCondition{
+		Name:  "pod/" + pod.Name,
+		State: resource.ReadyStateNotReady,
+	}
+	msgs := []string{string(pod.Status.Phase)}
+	switch pod.Status.Phase {
+	case apiCoreV1.PodFailed:
+		rc.State = resource.ReadyStateFailed
+	case apiCoreV1.PodSucceeded:
+		rc.State = resource.ReadyStateReady
+	}
+	for _, pc := range pod.Status.Conditions {
+		switch {
+		case pc.Type == apiCoreV1.PodReady && pc.Status == apiCoreV1.ConditionTrue:
+			rc.State = resource.ReadyStateReady
+		case pc.Type == apiCoreV1.PodScheduled && pc.Status == apiCoreV1.ConditionFalse:
+			msgs = append(msgs, fmt.Sprintf("not scheduled: %s: %s", pc.Reason, pc.Message))
+		}
+	}
+	for _, cc := range containerConditions(pod) {
+		if cc.State == resource.ReadyStateReady {
+			continue
+		}
+		if cc.State == resource.ReadyStateFailed {
+			rc.State = resource.ReadyStateFailed
+		}
+		msgs = append(msgs, cc.Name+": "+cc.Message)
+	}
+	rc.Message = strings.Join(msgs, "; ")
+	return rc
+}
+
+// containerConditions describes the state of each of the pod's containers,
+// including init containers.
+func containerConditions(pod *apiCoreV1.Pod) []resource.ReadyCondition {
+	statuses := slices.Concat(pod.Status.InitContainerStatuses, pod.Status.ContainerStatuses)
+	ret := make([]resource.ReadyCondition, 0, len(statuses))
+	for _, cs := range statuses {
+		rc := resource.ReadyCondition{
+			Name:  "container/" + cs.Name,
+			State: resource.ReadyStateNotReady,
+		}
+		var msgs []string
+		switch st := cs.State; {
+		case st.Waiting != nil:
+			msgs = append(msgs, "waiting")
+			if st.Waiting.Reason != "" {
+				msgs = append(msgs, st.Waiting.Reason)
+				if slices.Contains(failedWaitingReasons, st.Waiting.Reason) {
+					rc.State = resource.ReadyStateFailed
+				}
+			}
+			if st.Waiting.Message != "" {
+				msgs = append(msgs, st.Waiting.Message)
+			}
+		case st.Running != nil:
+			msgs = append(msgs, "running")
+			if cs.Ready {
+				rc.State = resource.ReadyStateReady
+			}
+		case st.Terminated != nil:
+			msgs = append(msgs, fmt.Sprintf("terminated with code %d", st.Terminated.ExitCode))
+			if st.Terminated.Reason != "" {
+				msgs = append(msgs, st.Terminated.Reason)
+			}
+			if st.Terminated.ExitCode == 0 {
+				rc.State = resource.ReadyStateReady
+			} else {
+				rc.State = resource.ReadyStateFailed
+			}
+		}
+		if cs.RestartCount > 0 {
+			msgs = append(msgs, fmt.Sprintf("restarted %d times", cs.RestartCount))
+			if lt := cs.LastTerminationState.Terminated; lt != nil {
+				msgs = append(msgs, fmt.Sprintf("last exit code %d", lt.ExitCode))
+			}
+		}
+		rc.Message = strings.Join(msgs, ", ")
+		ret = append(ret, rc)
+	}
+	return ret
+}
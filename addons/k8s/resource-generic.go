@@ -5,6 +5,7 @@ import (
 	"fmt"
 
 	apiErrors "k8s.io/apimachinery/pkg/api/errors"
+	apiMetaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 
 	"fastcat.org/go/gdev/internal"
 	"fastcat.org/go/gdev/resource"
@@ -58,6 +59,33 @@ func (r *appliable[Client, Resource, Apply]) Start(ctx context.Context) error {
 	return nil
 }
 
+// Plan implements resource.Planner, using a server side dry-run apply to see
+// which fields would change.
+func (r *appliable[Client, Resource, Apply]) Plan(ctx context.Context) (resource.Plan, error) {
+	sc := r.client(ctx)
+	m, o := r.acc.applyMeta(r.apply)
+	cur, err := sc.Get(ctx, *o.Name, getOpts(ctx))
+	if err != nil {
+		if apiErrors.IsNotFound(err) {
+			return resource.Plan{Action: resource.PlanCreate}, nil
+		}
+		return resource.Plan{}, fmt.Errorf("failed to get %s %s: %w", *m.Kind, *o.Name, err)
+	}
+	opts := applyOpts(ctx)
+	opts.DryRun = []string{apiMetaV1.DryRunAll}
+	next, err := sc.Apply(ctx, r.apply, opts)
+	if err != nil {
+		return resource.Plan{}, fmt.Errorf("failed to dry-run apply %s %s: %w", *m.Kind, *o.Name, err)
+	}
+	changes, err := objectChanges(cur, next)
+	if err != nil {
+		return resource.Plan{}, fmt.Errorf("failed to compare %s %s: %w", *m.Kind, *o.Name, err)
+	} else if len(changes) == 0 {
+		return resource.Plan{Action: resource.PlanNone}, nil
+	}
+	return resource.Plan{Action: resource.PlanUpdate, Changes: changes}, nil
+}
+
 // Stop implements resource.Resource.
 func (r *appliable[Client, Resource, Apply]) Stop(ctx context.Context) error {
 	sc := r.client(ctx)
@@ -68,6 +96,19 @@ func (r *appliable[Client, Resource, Apply]) Stop(ctx context.Context) error {
 	return nil
 }
 
+// Stopped implements resource.StoppedChecker. The object is stopped once it
+// has been fully deleted.
+func (r *appliable[Client, Resource, Apply]) Stopped(ctx context.Context) (bool, error) {
+	if _, err := r.client(ctx).Get(ctx, r.K8SName(), getOpts(ctx)); err != nil {
+		if apiErrors.IsNotFound(err) {
+			return true, nil
+		}
+		m, o := r.acc.applyMeta(r.apply)
+		return false, fmt.Errorf("failed to get %s %s: %w", *m.Kind, *o.Name, err)
+	}
+	return false, nil
+}
+
 // Ready implements resource.Resource.
 func (r *appliable[Client, Resource, Apply]) Ready(ctx context.Context) (bool, error) {
 	obj, err := r.client(ctx).Get(ctx, r.K8SName(), getOpts(ctx))
@@ -77,6 +118,15 @@ func (r *appliable[Client, Resource, Apply]) Ready(ctx context.Context) (bool, e
 	return r.acc.ready(ctx, resource.ContextValue[Interface](ctx), obj)
 }
 
+// ReadyDetails implements resource.ReadyDetailer.
+func (r *appliable[Client, Resource, Apply]) ReadyDetails(ctx context.Context) (resource.ReadyStatus, error) {
+	obj, err := r.client(ctx).Get(ctx, r.K8SName(), getOpts(ctx))
+	if err != nil {
+		return resource.ReadyStatusFromBool(false, err), err
+	}
+	return r.acc.readyStatus(ctx, resource.ContextValue[Interface](ctx), obj)
+}
+
 // K8SKind implements ContainerResource.
 func (r *appliable[Client, Resource, Apply]) K8SKind() string {
 	m, _ := r.acc.applyMeta(r.apply)
@@ -2,9 +2,12 @@ package k8s
 
 import (
 	"context"
+	"fmt"
 
 	applyAppsV1 "k8s.io/client-go/applyconfigurations/apps/v1"
 	applyBatchV1 "k8s.io/client-go/applyconfigurations/batch/v1"
+
+	"fastcat.org/go/gdev/resource"
 )
 
 // podder generalizes the pattern of a k8s resource that schedules pods
@@ -46,6 +49,22 @@ func (p *podder[Client, Resource, Apply]) ContainerImages(ctx context.Context) (
 	return ret, nil
 }
 
+// Stopped implements resource.StoppedChecker. Deleting the owner leaves its pods
+// to be terminated in the background, so this also waits for them to be gone.
+func (p *podder[Client, Resource, Apply]) Stopped(ctx context.Context) (bool, error) {
+	if stopped, err := p.appliable.Stopped(ctx); err != nil || !stopped {
+		return stopped, err
+	}
+	lo := listOpts(ctx)
+	lo.LabelSelector = AppLabel() + "=" + p.K8SName()
+	c := accPod.getClient(resource.ContextValue[Interface](ctx), resource.ContextValue[Namespace](ctx))
+	pods, err := accPod.list(ctx, c, lo)
+	if err != nil {
+		return false, fmt.Errorf("failed to list pods for %s %s: %w", p.K8SKind(), p.K8SName(), err)
+	}
+	return len(pods) == 0, nil
+}
+
 func StatefulSet(apply *applyAppsV1.StatefulSetApplyConfiguration) ContainerResource {
 	l := AppLabels(*apply.Name)
 	apply.
@@ -14,8 +14,8 @@ import (
 //
 // Kubernetes providers such as k3s can include this in their startup resource
 // so that other services that want to talk to k8s don't hit errors from
-// starting too early.
-func APIReadyWaiter() resource.Resource {
+// starting too early. Options customize the polling, see [resource.Waiter].
+func APIReadyWaiter(opts ...resource.WaitOption) resource.Resource {
 	return resource.Waiter("k8s-api-ready", func(ctx context.Context) (bool, error) {
 		client := resource.ContextValue[Interface](ctx)
 		if err := client.Health().Ready(ctx); err != nil {
@@ -35,7 +35,7 @@ func APIReadyWaiter() resource.Resource {
 			return false, err
 		}
 		return true, nil
-	})
+	}, opts...)
 }
 
 // NodeReadyWaiter creates a Resource that will block waiting for at least one
@@ -43,7 +43,8 @@ func APIReadyWaiter() resource.Resource {
 //
 // Kubernetes providers such as k3s can include this in their startup so that
 // other resources don't try to start when k8s has nowhere to run things.
-func NodeReadyWaiter() resource.Resource {
+// Options customize the polling, see [resource.Waiter].
+func NodeReadyWaiter(opts ...resource.WaitOption) resource.Resource {
 	return resource.Waiter("k8s-node-ready", func(ctx context.Context) (bool, error) {
 		client := resource.ContextValue[Interface](ctx)
 		l, err := accNode.list(ctx, client.CoreV1().Nodes(), listOpts(ctx))
@@ -58,35 +59,45 @@ func NodeReadyWaiter() resource.Resource {
 			}
 		}
 		return len(l) > 0, nil
-	})
+	}, opts...)
 }
 
 // DeploymentReadyWaiter creates a Resource that will block waiting for the
 // named Deployment to be ready. It will error out if the deployment does not
 // exist.
-func DeploymentReadyWaiter(name string) resource.Resource {
-	return accReadyWaiter(accDeployment, name)
+func DeploymentReadyWaiter(name string, opts ...resource.WaitOption) resource.Resource {
+	return accReadyWaiter(accDeployment, name, opts...)
 }
 
 // StatefulsetReadyWaiter creates a Resource that will block waiting for the
 // named StatefulSet to be ready. It will error out if the StatefulSet does not
 // exist.
-func StatefulsetReadyWaiter(name string) resource.Resource {
-	return accReadyWaiter(accStatefulSet, name)
+func StatefulsetReadyWaiter(name string, opts ...resource.WaitOption) resource.Resource {
+	return accReadyWaiter(accStatefulSet, name, opts...)
 }
 
 // ServiceReadyWaiter creates a Resource that will block waiting for the
 // named Service to be ready. It will error out if the Service does not
 // exist. Ready for a service means at least one healthy endpoint.
-func ServiceReadyWaiter(name string) resource.Resource {
-	return accReadyWaiter(accService, name)
+func ServiceReadyWaiter(name string, opts ...resource.WaitOption) resource.Resource {
+	return accReadyWaiter(accService, name, opts...)
 }
 
 func accReadyWaiter[
 	Client client[Resource, Apply],
 	Resource any,
 	Apply apply[Apply],
-](acc accessor[Client, Resource, Apply], name string) resource.Resource {
+](acc accessor[Client, Resource, Apply], name string, opts ...resource.WaitOption) resource.Resource {
+	details := func(ctx context.Context) (resource.ReadyStatus, error) {
+		kc := resource.ContextValue[Interface](ctx)
+		namespace := resource.ContextValue[Namespace](ctx)
+		c := acc.getClient(kc, namespace)
+		r, err := c.Get(ctx, name, getOpts(ctx))
+		if err != nil {
+			return resource.ReadyStatusFromBool(false, err), err
+		}
+		return acc.readyStatus(ctx, kc, r)
+	}
 	return resource.Waiter(acc.typ.Kind+"/"+name, func(ctx context.Context) (bool, error) {
 		kc := resource.ContextValue[Interface](ctx)
 		namespace := resource.ContextValue[Namespace](ctx)
@@ -96,5 +107,5 @@ func accReadyWaiter[
 			return false, err
 		}
 		return acc.ready(ctx, kc, r)
-	})
+	}, opts...).WithReadyDetails(details)
 }
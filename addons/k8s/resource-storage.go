@@ -24,6 +24,12 @@ func (r *pvc) Stop(ctx context.Context) error {
 	return nil
 }
 
+// Stopped implements resource.StoppedChecker. As Stop leaves the PVC in place,
+// it is always considered stopped.
+func (r *pvc) Stopped(context.Context) (bool, error) {
+	return true, nil
+}
+
 func PersistentVolumeClaim(apply *applyCoreV1.PersistentVolumeClaimApplyConfiguration) Resource {
 	l := containers.DefaultLabels()
 	apply.
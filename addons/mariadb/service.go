@@ -51,6 +51,7 @@ func Service(
 	return service.New(
 		cfg.name,
 		service.WithResources(resources...),
+		service.WithEnv(cfg.env()),
 	)
 }
 
@@ -375,6 +376,17 @@ func (c svcConfig) Credentials() map[string]string {
 	}
 }
 
+// env is the environment published by the service, which adds the connection
+// address to the credentials if it is reachable from the host.
+func (c svcConfig) env() map[string]string {
+	env := c.Credentials()
+	if c.nodePort > 0 {
+		env["MYSQL_HOST"] = "localhost"
+		env["MYSQL_TCP_PORT"] = strconv.Itoa(c.nodePort)
+	}
+	return env
+}
+
 func (c svcConfig) selector() map[string]string {
 	return map[string]string{
 		"app": c.name,
@@ -9,7 +9,18 @@ type API interface {
 	PutChild(ctx context.Context, child Child) (*ChildWithStatus, error)
 	StartChild(ctx context.Context, name string) (*ChildWithStatus, error)
 	StopChild(ctx context.Context, name string) (*ChildWithStatus, error)
+	// SignalChild sends a signal, by name (e.g. "SIGHUP") or number, to the
+	// running exec of a child, e.g. to make it reload its config.
+	SignalChild(ctx context.Context, name, signal string) (*ChildWithStatus, error)
 	DeleteChild(ctx context.Context, name string) (*ChildWithStatus, error)
 	Terminate(ctx context.Context) error
-	// TODO: ChildLogs
+	// Detach shuts down the daemon but leaves the children running, for a new
+	// daemon to re-adopt from its saved state, e.g. to upgrade it.
+	Detach(ctx context.Context) error
+	// ChildLogs calls fn for each line of output captured from the child, see
+	// [LogsOptions]. An error returned from fn stops the stream and is returned.
+	ChildLogs(ctx context.Context, name string, opts LogsOptions, fn func(LogLine) error) error
+	// Events calls fn for each event published by the daemon, in order, until ctx
+	// is done. An error returned from fn stops the stream and is returned.
+	Events(ctx context.Context, opts EventsOptions, fn func(Event) error) error
 }
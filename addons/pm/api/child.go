@@ -19,6 +19,83 @@ type Child struct {
 	// should be started and then waited upon, or where special interventions are
 	// required to restart the child.
 	NoRestart bool `json:"noRestart,omitempty"`
+	// RestartPolicy customizes the backoff between automatic restarts. If nil,
+	// the defaults described on [RestartPolicy] are used.
+	RestartPolicy *RestartPolicy `json:"restartPolicy,omitempty"`
+	// Limits constrains the resources of every exec that doesn't have its own
+	// limits.
+	Limits *Limits `json:"limits,omitempty"`
+	// StopGraceSeconds is how long to wait for an exec to stop, including
+	// running its [Exec.Stop] command, before killing it, default 5s.
+	StopGraceSeconds float64 `json:"stopGraceSeconds,omitzero" validate:"gte=0"`
+	// DependsOn lists other children that must reach a condition before this
+	// one starts. Until then, starting it puts it in the [ChildWaiting] state.
+	// If a dependency stops meeting its condition, e.g. because it restarted,
+	// this child is stopped and waits for it again. The dependencies need not
+	// exist yet, but they must not form a cycle.
+	DependsOn []Dependency `json:"dependsOn,omitempty" validate:"dive"`
+}
+
+// Dependency is an entry in [Child.DependsOn].
+//
+//nolint:lll // validation tags can't be wrapped
+type Dependency struct {
+	Child string `json:"child" validate:"required"`
+	// Condition is what to wait for, default [DependencyStarted].
+	Condition DependencyCondition `json:"condition,omitzero" validate:"omitempty,oneof=started healthy completed"`
+}
+
+type DependencyCondition string
+
+const (
+	// DependencyStarted waits for the dependency to be running, or done if it is
+	// OneShot.
+	DependencyStarted DependencyCondition = "started"
+	// DependencyHealthy waits for the dependency to be running and healthy, if it
+	// has a health check, or done if it is OneShot.
+	DependencyHealthy DependencyCondition = "healthy"
+	// DependencyCompleted waits for a OneShot dependency to be done.
+	DependencyCompleted DependencyCondition = "completed"
+)
+
+// Limits constrains the resources an exec may use, so a runaway service can't
+// take down the whole machine. Everything but Nice is applied via the exec's
+// isolation group. Zero values mean no limit.
+type Limits struct {
+	// MemoryMaxBytes is the memory limit, beyond which the exec is OOM killed.
+	MemoryMaxBytes int64 `json:"memoryMaxBytes,omitzero" validate:"gte=0"`
+	// CPUWeight is the relative share of CPU time, 1-10000, default 100.
+	CPUWeight int `json:"cpuWeight,omitzero" validate:"omitempty,gte=1,lte=10000"`
+	// CPUQuota is the maximum CPU time as a fraction of one CPU, e.g. 1.5 for
+	// one and a half CPUs.
+	CPUQuota float64 `json:"cpuQuota,omitzero" validate:"gte=0"`
+	// PidsMax is the maximum number of processes and threads.
+	PidsMax int `json:"pidsMax,omitzero" validate:"gte=0"`
+	// IOWeight is the relative share of IO bandwidth, 1-10000, default 100.
+	IOWeight int `json:"ioWeight,omitzero" validate:"omitempty,gte=1,lte=10000"`
+	// Nice is the scheduling priority of the exec's process, from -20 (highest)
+	// to 19 (lowest). Negative values usually require privileges.
+	Nice int `json:"nice,omitzero" validate:"gte=-20,lte=19"`
+}
+
+// RestartPolicy controls how a child is restarted after it fails. Zero values
+// use the defaults.
+type RestartPolicy struct {
+	// InitialDelaySeconds is the delay before the first restart, default 1s.
+	InitialDelaySeconds float64 `json:"initialDelaySeconds,omitzero" validate:"gte=0"`
+	// MaxDelaySeconds caps the delay between restarts, default 60s.
+	MaxDelaySeconds float64 `json:"maxDelaySeconds,omitzero" validate:"gte=0"`
+	// Multiplier scales the delay after each consecutive failure, default 2.
+	Multiplier float64 `json:"multiplier,omitzero" validate:"omitempty,gte=1"`
+	// MaxRestarts is how many restarts are allowed within WindowSeconds before
+	// the child is considered to be crash looping and automatic restarts stop,
+	// default 5. Negative values allow unlimited restarts.
+	MaxRestarts int `json:"maxRestarts,omitzero"`
+	// WindowSeconds is the period over which MaxRestarts applies, default 300s.
+	WindowSeconds float64 `json:"windowSeconds,omitzero" validate:"gte=0"`
+	// ResetAfterHealthySeconds is how long the child must stay running and
+	// healthy for the backoff to be reset, default 30s.
+	ResetAfterHealthySeconds float64 `json:"resetAfterHealthySeconds,omitzero" validate:"gte=0"`
 }
 
 const (
@@ -34,6 +111,15 @@ type Exec struct {
 	Cwd     string            `json:"cwd,omitzero"`
 	Env     map[string]string `json:"env"`
 	Logfile string            `json:"logfile,omitzero"`
+	// Limits overrides [Child.Limits] for this exec.
+	Limits *Limits `json:"limits,omitempty"`
+	// StopSignal is the signal sent to stop the exec, by name (e.g. "SIGINT" or
+	// "INT") or number, default SIGTERM.
+	StopSignal string `json:"stopSignal,omitzero" validate:"omitempty,signal"`
+	// Stop is an optional command to run to stop the exec gracefully, e.g.
+	// `pg_ctl stop`, before sending StopSignal. It defaults to the Cwd and Env of
+	// the exec being stopped. Its own Stop and StopSignal are ignored.
+	Stop *Exec `json:"stop,omitempty"`
 }
 
 type ExecState string
@@ -64,6 +150,21 @@ type ChildStatus struct {
 	Init   []ExecStatus `json:"init"`
 	Main   ExecStatus   `json:"main"`
 	Health HealthStatus `json:"health"`
+	// Restarts counts the automatic restarts since the child was last started
+	// explicitly.
+	Restarts int `json:"restarts"`
+	// LastExit describes why the most recent exec exited.
+	LastExit string `json:"lastExit,omitzero"`
+	// Usage is the current resource usage of the running exec, if known. It is
+	// only filled in when fetching a single child.
+	Usage *ResourceUsage `json:"usage,omitempty"`
+}
+
+// ResourceUsage is the resource usage of an exec's isolation group.
+type ResourceUsage struct {
+	MemoryBytes uint64  `json:"memoryBytes"`
+	CPUSeconds  float64 `json:"cpuSeconds"`
+	Pids        uint64  `json:"pids"`
 }
 
 type ChildState string
@@ -76,6 +177,12 @@ const (
 	ChildStopping    ChildState = "stopping"
 	ChildDone        ChildState = "done"
 	ChildError       ChildState = "error"
+	// ChildWaiting is a child that has been started, but is waiting for its
+	// [Child.DependsOn] to be met.
+	ChildWaiting ChildState = "waiting"
+	// ChildCrashLooping means the child failed too many times too quickly, see
+	// [RestartPolicy], and will not be restarted until it is started explicitly.
+	ChildCrashLooping ChildState = "crash-looping"
 )
 
 type ChildWithStatus struct {
@@ -89,18 +196,51 @@ type ChildSummary struct {
 	State       ChildState        `json:"state"`
 	Pid         int               `json:"pid,omitzero"`
 	Healthy     *bool             `json:"healthy,omitzero"`
+	Restarts    int               `json:"restarts"`
+	LastExit    string            `json:"lastExit,omitzero"`
 }
 
+// HealthCheck configures how the main exec of a child is checked for health.
+// Exactly one of the check types must be set.
+//
+//nolint:lll // validation tags can't be wrapped
 type HealthCheck struct {
-	Http           *HttpHealthCheck `json:"http,omitempty" validate:"required"`
+	Http           *HttpHealthCheck `json:"http,omitempty" validate:"required_without_all=Tcp Exec Grpc,excluded_with=Tcp Exec Grpc"`
+	Tcp            *TcpHealthCheck  `json:"tcp,omitempty" validate:"required_without_all=Http Exec Grpc,excluded_with=Http Exec Grpc"`
+	Exec           *ExecHealthCheck `json:"exec,omitempty" validate:"required_without_all=Http Tcp Grpc,excluded_with=Http Tcp Grpc"`
+	Grpc           *GrpcHealthCheck `json:"grpc,omitempty" validate:"required_without_all=Http Tcp Exec,excluded_with=Http Tcp Exec"`
 	TimeoutSeconds int              `json:"timeout" validate:"required,gt=0"`
 }
 
-// FUTURE: add more health check types, validate via `required_without_all=<others>,excluded_with=<others>`
-
 type HttpHealthCheck struct {
 	Scheme   string `json:"scheme,omitzero" validate:"oneof=http https"`
 	Insecure bool   `json:"insecure,omitzero"`
 	Port     int    `json:"port" validate:"required,gt=0,lte=65535"`
 	Path     string `json:"path" validate:"required"`
 }
+
+// TcpHealthCheck is healthy if a TCP connection to the port on localhost
+// succeeds.
+type TcpHealthCheck struct {
+	Port int `json:"port" validate:"required,gt=0,lte=65535"`
+}
+
+// ExecHealthCheck is healthy if the command exits with code zero. It runs with
+// the same working directory and environment as the child's main exec, plus
+// any extra Env, in the same isolation group.
+type ExecHealthCheck struct {
+	Cmd  string            `json:"cmd" validate:"required"`
+	Args []string          `json:"args"`
+	Env  map[string]string `json:"env,omitempty"`
+}
+
+// GrpcHealthCheck is healthy if the standard grpc.health.v1 Check call on
+// localhost reports the service as serving.
+type GrpcHealthCheck struct {
+	Port int `json:"port" validate:"required,gt=0,lte=65535"`
+	// Service is the service name to check, empty for the overall server health.
+	Service string `json:"service,omitzero"`
+	TLS     bool   `json:"tls,omitzero"`
+	// Insecure skips verifying the server certificate when TLS is set.
+	Insecure bool `json:"insecure,omitzero"`
+}
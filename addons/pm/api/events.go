@@ -0,0 +1,46 @@
+package api
+
+import "time"
+
+type EventType string
+
+const (
+	EventChildAdded   EventType = "child-added"
+	EventChildRemoved EventType = "child-removed"
+	// EventState is a transition of the child's state, see [Event.PrevState].
+	EventState EventType = "state"
+	// EventExecExited is sent when an init or main exec exits, see
+	// [Event.Exec] and [Event.ExitCode].
+	EventExecExited EventType = "exec-exited"
+	// EventHealth is sent when the result of the child's health check flips,
+	// see [Event.Healthy].
+	EventHealth EventType = "health"
+	// EventRestart is sent when the child is automatically restarted, see
+	// [Event.Restarts].
+	EventRestart EventType = "restart"
+)
+
+// Event is a change to a child in the daemon.
+type Event struct {
+	// Seq orders the events, it increases by one for each event the daemon
+	// publishes.
+	Seq   uint64    `json:"seq"`
+	Time  time.Time `json:"time"`
+	Type  EventType `json:"type"`
+	Child string    `json:"child"`
+	// State is the child's state after the event.
+	State     ChildState `json:"state,omitzero"`
+	PrevState ChildState `json:"prevState,omitzero"`
+	Exec      string     `json:"exec,omitzero"`
+	ExitCode  *int       `json:"exitCode,omitempty"`
+	Healthy   *bool      `json:"healthy,omitempty"`
+	Restarts  int        `json:"restarts,omitzero"`
+	// Message describes the event for humans.
+	Message string `json:"message,omitzero"`
+}
+
+// EventsOptions controls what [API.Events] streams.
+type EventsOptions struct {
+	// Children limits the events to the named children, if not empty.
+	Children []string
+}
@@ -8,5 +8,29 @@ const (
 	PathOneChild       = PathChild + "/{" + PathChildParamName + "}"
 	PathStartChild     = PathOneChild + "/start"
 	PathStopChild      = PathOneChild + "/stop"
+	PathSignalChild    = PathOneChild + "/signal"
+	PathChildLogs      = PathOneChild + "/logs"
 	PathTerminate      = "/terminate"
+	PathDetach         = "/detach"
+	PathEvents         = "/events"
+)
+
+// Query parameters for [PathChildLogs], see [LogsOptions].
+const (
+	QueryLogsFollow = "follow"
+	QueryLogsSince  = "since"
+	QueryLogsTail   = "tail"
+	QueryLogsInit   = "init"
+)
+
+// Query parameters for [PathEvents], see [EventsOptions].
+const (
+	// QueryEventsChild may be repeated to watch several children.
+	QueryEventsChild = "child"
+)
+
+// Query parameters for [PathSignalChild].
+const (
+	// QuerySignal is the signal to send, by name or number.
+	QuerySignal = "signal"
 )
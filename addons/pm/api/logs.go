@@ -0,0 +1,38 @@
+package api
+
+import "time"
+
+const (
+	// ExecMain is the [LogLine.Exec] for the main exec of a child. Init execs are
+	// named "init-N".
+	ExecMain = "main"
+	// ExecStopSuffix is appended to the [LogLine.Exec] of an exec for the output
+	// of its [Exec.Stop] command.
+	ExecStopSuffix = "-stop"
+
+	StreamStdout = "stdout"
+	StreamStderr = "stderr"
+)
+
+// LogLine is one line of output captured from a child exec.
+type LogLine struct {
+	Time time.Time `json:"time"`
+	Exec string    `json:"exec"`
+	// Stream is empty if the exec has a Logfile, which combines the streams.
+	Stream string `json:"stream,omitzero"`
+	Text   string `json:"text"`
+}
+
+// LogsOptions control what [API.ChildLogs] returns.
+type LogsOptions struct {
+	// Follow keeps streaming new lines after the buffered ones, until the
+	// context is canceled or the child is deleted.
+	Follow bool
+	// Since skips buffered lines from before this time, if set.
+	Since time.Time
+	// Tail limits the buffered lines to the last N, if positive.
+	Tail int
+	// Init includes output from the init execs, not just main and its stop
+	// command.
+	Init bool
+}
@@ -14,3 +14,9 @@ func ListenAddr() net.Addr {
 		Name: fmt.Sprintf("/run/user/%d/%s-pm", os.Getuid(), instance.AppName()),
 	}
 }
+
+// StateDir is where the daemon keeps the state it needs to re-adopt its children
+// after a restart.
+func StateDir() string {
+	return fmt.Sprintf("/run/user/%d/%s-pm.d", os.Getuid(), instance.AppName())
+}
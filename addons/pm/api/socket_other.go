@@ -11,3 +11,7 @@ import (
 func ListenAddr() net.Addr {
 	panic(fmt.Errorf("not supported on %s", runtime.GOOS))
 }
+
+func StateDir() string {
+	panic(fmt.Errorf("not supported on %s", runtime.GOOS))
+}
@@ -4,10 +4,12 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"io"
 	"net/http"
 	"net/url"
 	"path"
+	"strconv"
 	"strings"
 	"time"
 
@@ -92,6 +94,64 @@ func (h *HTTP) StopChild(ctx context.Context, name string) (*api.ChildWithStatus
 	return internal.JSONBody[*api.ChildWithStatus](ctx, r.Body, "", true)
 }
 
+// SignalChild implements api.API.
+func (h *HTTP) SignalChild(ctx context.Context, name, signal string) (*api.ChildWithStatus, error) {
+	u := withPathValue(api.PathSignalChild, api.PathChildParamName, name) +
+		"?" + url.Values{api.QuerySignal: {signal}}.Encode()
+	r, err := h.do(ctx, http.MethodPost, u, nil)
+	if err != nil {
+		return nil, err
+	}
+	return internal.JSONBody[*api.ChildWithStatus](ctx, r.Body, "", true)
+}
+
+// ChildLogs implements api.API.
+func (h *HTTP) ChildLogs(
+	ctx context.Context,
+	name string,
+	opts api.LogsOptions,
+	fn func(api.LogLine) error,
+) error {
+	q := url.Values{}
+	if opts.Follow {
+		q.Set(api.QueryLogsFollow, "true")
+	}
+	if opts.Init {
+		q.Set(api.QueryLogsInit, "true")
+	}
+	if !opts.Since.IsZero() {
+		q.Set(api.QueryLogsSince, opts.Since.Format(time.RFC3339Nano))
+	}
+	if opts.Tail > 0 {
+		q.Set(api.QueryLogsTail, strconv.Itoa(opts.Tail))
+	}
+	p := withPathValue(api.PathChildLogs, api.PathChildParamName, name)
+	if len(q) != 0 {
+		p += "?" + q.Encode()
+	}
+	r, err := h.do(ctx, http.MethodGet, p, nil)
+	if err != nil {
+		return err
+	}
+	defer r.Body.Close() //nolint:errcheck
+	d := json.NewDecoder(r.Body)
+	for {
+		var l api.LogLine
+		if err := d.Decode(&l); errors.Is(err, io.EOF) {
+			return nil
+		} else if err != nil {
+			if ctx.Err() != nil {
+				// following was canceled
+				return nil
+			}
+			return err
+		}
+		if err := fn(l); err != nil {
+			return err
+		}
+	}
+}
+
 // Summary implements api.API.
 func (h *HTTP) Summary(ctx context.Context) ([]api.ChildSummary, error) {
 	r, err := h.do(ctx, http.MethodGet, api.PathSummary, nil)
@@ -107,6 +167,41 @@ func (h *HTTP) Terminate(ctx context.Context) error {
 	return err
 }
 
+// Events implements api.API.
+func (h *HTTP) Events(ctx context.Context, opts api.EventsOptions, fn func(api.Event) error) error {
+	p := api.PathEvents
+	if len(opts.Children) != 0 {
+		p += "?" + url.Values{api.QueryEventsChild: opts.Children}.Encode()
+	}
+	r, err := h.do(ctx, http.MethodGet, p, nil)
+	if err != nil {
+		return err
+	}
+	defer r.Body.Close() //nolint:errcheck
+	d := json.NewDecoder(r.Body)
+	for {
+		var e api.Event
+		if err := d.Decode(&e); err != nil {
+			if ctx.Err() != nil {
+				// watching was canceled
+				return nil
+			} else if errors.Is(err, io.EOF) {
+				return io.ErrUnexpectedEOF
+			}
+			return err
+		}
+		if err := fn(e); err != nil {
+			return err
+		}
+	}
+}
+
+// Detach implements api.API.
+func (h *HTTP) Detach(ctx context.Context) error {
+	_, err := h.do(ctx, http.MethodPost, api.PathDetach, nil)
+	return err
+}
+
 func (h *HTTP) do(
 	ctx context.Context,
 	method string,
@@ -145,7 +240,8 @@ func (h *HTTP) url(p string) string {
 			Path:   "/",
 		}
 	}
-	u = u.ResolveReference(&url.URL{Path: "/./" + p})
+	p, query, _ := strings.Cut(p, "?")
+	u = u.ResolveReference(&url.URL{Path: "/./" + p, RawQuery: query})
 	u.Path = path.Clean(u.Path)
 	return u.String()
 }
@@ -32,12 +32,16 @@ func pmCmd() *cobra.Command {
 			"With one or more args, shows details of those services",
 		RunE: PMStatus,
 	})
-	pm.AddCommand(&cobra.Command{
+	terminate := &cobra.Command{
 		Use:   "terminate",
 		Short: "terminate pm daemon and any children",
-		Args:  cobra.NoArgs,
-		RunE:  PMTerminate,
-	})
+		Long: "Terminates the pm daemon and any children. With --keep-children, the children are " +
+			"left running and the next pm daemon will re-adopt them, e.g. to upgrade pm.",
+		Args: cobra.NoArgs,
+		RunE: PMTerminate,
+	}
+	terminate.Flags().Bool("keep-children", false, "leave children running for the next daemon to re-adopt")
+	pm.AddCommand(terminate)
 
 	pm.AddCommand(pmAdd())
 
@@ -101,6 +105,28 @@ func pmCmd() *cobra.Command {
 		},
 	})
 
+	pm.AddCommand(&cobra.Command{
+		Use:   "signal <signal> <name...>",
+		Short: "sends a signal to one or more pm service(s)",
+		Long: "Sends a signal, by name (e.g. SIGHUP or HUP) or number, to the running process " +
+			"group of each service, e.g. to make it reload its config.",
+		Args: cobra.MinimumNArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			c := client.NewHTTP()
+			for _, name := range args[1:] {
+				if stat, err := c.SignalChild(cmd.Context(), name, args[0]); err != nil {
+					return fmt.Errorf("failed to signal %s: %w", name, err)
+				} else {
+					PrettyChildStatus(stat, os.Stdout)
+				}
+			}
+			return nil
+		},
+	})
+
+	pm.AddCommand(pmLogs())
+	pm.AddCommand(pmWatch())
+
 	pm.AddCommand(&cobra.Command{
 		Use:     "remove <name...>",
 		Aliases: []string{"rm"},
@@ -148,7 +174,7 @@ func PMStatusTable(ctx context.Context, client api.API) error {
 	tw := table.NewWriter()
 	tw.SetStyle(table.StyleColoredBlueWhiteOnBlack)
 	tw.SetOutputMirror(os.Stdout)
-	tw.AppendHeader(table.Row{"Name", "State", "Pid", "Healthy"})
+	tw.AppendHeader(table.Row{"Name", "State", "Pid", "Healthy", "Restarts", "Last Exit"})
 	tw.AppendSeparator()
 	cmpByName := func(a, b api.ChildSummary) int {
 		return strings.Compare(a.Name, b.Name)
@@ -158,7 +184,7 @@ func PMStatusTable(ctx context.Context, client api.API) error {
 		if c.Healthy != nil {
 			h = healthEmoji(*c.Healthy)
 		}
-		tw.AppendRow(table.Row{c.Name, c.State, c.Pid, h})
+		tw.AppendRow(table.Row{c.Name, c.State, c.Pid, h, c.Restarts, c.LastExit})
 	}
 	tw.Render()
 	return nil
@@ -180,13 +206,21 @@ func pmAutoStart(cmd *cobra.Command, _ []string) error {
 }
 
 func PMTerminate(cmd *cobra.Command, _ []string) error {
+	ctx := cmd.Context()
 	c := client.NewHTTP()
-	if err := c.Ping(cmd.Context()); err != nil {
+	if err := c.Ping(ctx); err != nil {
 		// TODO: check the specific error better
 		fmt.Println("pm not running")
 		return nil
 	}
-	if err := c.Terminate(cmd.Context()); err != nil {
+	// the flag may not be defined if this is used in another command
+	if keep, _ := cmd.Flags().GetBool("keep-children"); keep {
+		if err := c.Detach(ctx); err != nil {
+			return fmt.Errorf("failed to detach pm daemon: %w", err)
+		}
+		return nil
+	}
+	if err := c.Terminate(ctx); err != nil {
 		return fmt.Errorf("failed to terminate pm daemon: %w", err)
 	}
 	return nil
@@ -202,10 +236,37 @@ func PrettyChildStatus(s *api.ChildWithStatus, out io.Writer) {
 		l.AppendItem("Healthy: " + healthEmoji(s.Status.Health.Healthy))
 		// TODO: do somethin with LastHealthy/LastUnhealthy
 	}
+	if s.Status.Restarts != 0 {
+		l.AppendItem(fmt.Sprintf("Restarts: %d", s.Status.Restarts))
+	}
+	if s.Status.LastExit != "" {
+		l.AppendItem("Last exit: " + s.Status.LastExit)
+	}
+	if u := s.Status.Usage; u != nil {
+		l.AppendItem(fmt.Sprintf("Usage: %.1f MiB memory, %.1fs CPU, %d pids",
+			float64(u.MemoryBytes)/(1<<20), u.CPUSeconds, u.Pids))
+	}
+	if s.Limits != nil {
+		l.AppendItem("Limits: " + prettyLimits(s.Limits))
+	}
+	if len(s.DependsOn) != 0 {
+		deps := make([]string, 0, len(s.DependsOn))
+		for _, d := range s.DependsOn {
+			cond := d.Condition
+			if cond == "" {
+				cond = api.DependencyStarted
+			}
+			deps = append(deps, fmt.Sprintf("%s (%s)", d.Child, cond))
+		}
+		l.AppendItem("Depends on: " + strings.Join(deps, ", "))
+	}
 	renderExec := func(e api.Exec, s api.ExecStatus) {
 		l.AppendItem(strings.Join(append([]string{e.Cmd}, e.Args...), " "))
 		// TODO: Cwd, Env
 		l.Indent()
+		if e.Limits != nil {
+			l.AppendItem("Limits: " + prettyLimits(e.Limits))
+		}
 		switch s.State {
 		case api.ExecNotStarted:
 			if s.StartErr != "" {
@@ -239,6 +300,32 @@ func PrettyChildStatus(s *api.ChildWithStatus, out io.Writer) {
 	l.Render()
 }
 
+func prettyLimits(limits *api.Limits) string {
+	var parts []string
+	if limits.MemoryMaxBytes != 0 {
+		parts = append(parts, fmt.Sprintf("memory %.1f MiB", float64(limits.MemoryMaxBytes)/(1<<20)))
+	}
+	if limits.CPUWeight != 0 {
+		parts = append(parts, fmt.Sprintf("cpu weight %d", limits.CPUWeight))
+	}
+	if limits.CPUQuota != 0 {
+		parts = append(parts, fmt.Sprintf("cpu quota %g", limits.CPUQuota))
+	}
+	if limits.PidsMax != 0 {
+		parts = append(parts, fmt.Sprintf("pids %d", limits.PidsMax))
+	}
+	if limits.IOWeight != 0 {
+		parts = append(parts, fmt.Sprintf("io weight %d", limits.IOWeight))
+	}
+	if limits.Nice != 0 {
+		parts = append(parts, fmt.Sprintf("nice %d", limits.Nice))
+	}
+	if len(parts) == 0 {
+		return "none"
+	}
+	return strings.Join(parts, ", ")
+}
+
 func healthEmoji(value bool) string {
 	if value {
 		return "👍"
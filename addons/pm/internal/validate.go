@@ -1,5 +1,43 @@
 package internal
 
-import "github.com/go-playground/validator/v10"
+import (
+	"github.com/go-playground/validator/v10"
 
-var v = validator.New(validator.WithRequiredStructEnabled())
+	"fastcat.org/go/gdev/addons/pm/api"
+)
+
+var v = newValidator()
+
+const (
+	// minMemoryMax is the smallest memory limit we accept, anything less is
+	// almost certainly a mistake (e.g. MiB given instead of bytes) and would get
+	// the exec OOM killed immediately.
+	minMemoryMax = 1 << 20
+	// minCPUQuota is the smallest CPU quota we accept, the kernel won't accept a
+	// quota less than 1ms per 100ms period.
+	minCPUQuota = 0.01
+)
+
+func newValidator() *validator.Validate {
+	v := validator.New(validator.WithRequiredStructEnabled())
+	v.RegisterStructValidation(validateLimits, api.Limits{})
+	if err := v.RegisterValidation("signal", validateSignal); err != nil {
+		panic(err)
+	}
+	return v
+}
+
+func validateLimits(sl validator.StructLevel) {
+	l := sl.Current().Interface().(api.Limits)
+	if l.MemoryMaxBytes != 0 && l.MemoryMaxBytes < minMemoryMax {
+		sl.ReportError(l.MemoryMaxBytes, "MemoryMaxBytes", "memoryMaxBytes", "min", "1048576")
+	}
+	if l.CPUQuota != 0 && l.CPUQuota < minCPUQuota {
+		sl.ReportError(l.CPUQuota, "CPUQuota", "cpuQuota", "min", "0.01")
+	}
+}
+
+func validateSignal(fl validator.FieldLevel) bool {
+	_, err := ParseSignal(fl.Field().String())
+	return err == nil
+}
@@ -0,0 +1,115 @@
+package pm
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/spf13/cobra"
+	"golang.org/x/sync/errgroup"
+
+	"fastcat.org/go/gdev/addons/pm/api"
+	"fastcat.org/go/gdev/addons/pm/client"
+)
+
+func pmLogs() *cobra.Command {
+	var opts api.LogsOptions
+	since := ""
+	c := &cobra.Command{
+		Use:   "logs <name...>",
+		Short: "show the output of one or more pm service(s)",
+		Long: "Shows the output captured by the pm daemon for the named services, " +
+			"interleaved with the service names as prefixes",
+		Args: cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if since != "" {
+				var err error
+				if opts.Since, err = parseSince(since, time.Now()); err != nil {
+					return err
+				}
+			}
+			return PMLogs(cmd.Context(), client.NewHTTP(), opts, cmd.OutOrStdout(), args...)
+		},
+	}
+	f := c.Flags()
+	f.BoolVarP(&opts.Follow, "follow", "f", opts.Follow,
+		"keep streaming new output until interrupted")
+	f.StringVar(&since, "since", since,
+		"only show output since this time (RFC3339) or for this long (e.g. 10m)")
+	f.IntVarP(&opts.Tail, "tail", "n", opts.Tail,
+		"only show the last N lines of buffered output from each service")
+	f.BoolVar(&opts.Init, "init", opts.Init,
+		"include output from init commands")
+	return c
+}
+
+// parseSince accepts either a timestamp or a duration before now.
+func parseSince(since string, now time.Time) (time.Time, error) {
+	if d, err := time.ParseDuration(since); err == nil {
+		return now.Add(-d), nil
+	}
+	t, err := time.Parse(time.RFC3339, since)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid --since %q, expected a duration or RFC3339 time", since)
+	}
+	return t, nil
+}
+
+// PMLogs writes the logs for the named children to out, with each line
+// prefixed by the child name. Without opts.Follow, the lines are merged in time
+// order. With it, lines are written as they arrive.
+func PMLogs(ctx context.Context, c api.API, opts api.LogsOptions, out io.Writer, names ...string) error {
+	width := 0
+	for _, name := range names {
+		width = max(width, len(name))
+	}
+	if opts.Init {
+		// room for the exec suffix
+		width += len("/init-0")
+	}
+	prefix := func(name string, l api.LogLine) string {
+		if l.Exec != api.ExecMain {
+			name += "/" + l.Exec
+		}
+		return fmt.Sprintf("%-*s | ", width, name)
+	}
+
+	type namedLine struct {
+		name string
+		api.LogLine
+	}
+	var mu sync.Mutex
+	var lines []namedLine
+	eg, ctx := errgroup.WithContext(ctx)
+	for _, name := range names {
+		eg.Go(func() error {
+			err := c.ChildLogs(ctx, name, opts, func(l api.LogLine) error {
+				mu.Lock()
+				defer mu.Unlock()
+				if !opts.Follow {
+					lines = append(lines, namedLine{name, l})
+					return nil
+				}
+				_, err := fmt.Fprintln(out, prefix(name, l)+l.Text)
+				return err
+			})
+			if err != nil {
+				return fmt.Errorf("failed to get logs for %s: %w", name, err)
+			}
+			return nil
+		})
+	}
+	if err := eg.Wait(); err != nil {
+		return err
+	}
+	slices.SortStableFunc(lines, func(a, b namedLine) int { return a.Time.Compare(b.Time) })
+	for _, l := range lines {
+		if _, err := fmt.Fprintln(out, prefix(l.name, l.LogLine)+l.Text); err != nil {
+			return err
+		}
+	}
+	return nil
+}
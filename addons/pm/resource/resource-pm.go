@@ -2,13 +2,19 @@ package resource
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"maps"
 	"reflect"
+	"slices"
+	"sync"
 	"time"
 
 	"fastcat.org/go/gdev/addons/pm/api"
 	"fastcat.org/go/gdev/lib/httpx"
+	"fastcat.org/go/gdev/progress"
 	"fastcat.org/go/gdev/resource"
+	"fastcat.org/go/gdev/service"
 )
 
 type PM struct {
@@ -16,8 +22,26 @@ type PM struct {
 	Config        func(context.Context) (*api.Child, error)
 	LimitRestarts bool
 	WaitOnStart   bool
+	// WaitOptions customize the waiting when WaitOnStart is set. By default it
+	// re-checks whenever the daemon reports a change to the child, polling
+	// every second as a fallback, with no timeout.
+	WaitOptions []resource.WaitOption
+	// ProvidedEnv merges the environment published by the services the child's
+	// service depends on (see service.ProvidedEnv) into the child's environment,
+	// without overriding any variables the child sets itself.
+	ProvidedEnv bool
+
+	// track how many times we've seen the child enter an error state since it
+	// was started, to detect crash loops
+	mu         sync.Mutex
+	lastState  api.ChildState
+	errorCount int
 }
 
+// crashLoopErrors is how many times a child may be seen entering an error
+// state before we consider it to be crash looping.
+const crashLoopErrors = 3
+
 func PMStatic(config api.Child) *PM {
 	return &PM{
 		Name:   config.Name,
@@ -40,11 +64,51 @@ func PMDynamic(name string, config func(context.Context) (*api.Child, error)) *P
 	}
 }
 
-func (p *PM) WithWaitOnStart() *PM {
+func (p *PM) WithWaitOnStart(opts ...resource.WaitOption) *PM {
 	p.WaitOnStart = true
+	p.WaitOptions = append(p.WaitOptions, opts...)
+	return p
+}
+
+// WithProvidedEnv enables merging the environment published by the service's
+// dependencies into the child's environment.
+func (p *PM) WithProvidedEnv() *PM {
+	p.ProvidedEnv = true
 	return p
 }
 
+// config gets the child config, with the provided environment merged in if
+// enabled.
+func (p *PM) config(ctx context.Context) (*api.Child, error) {
+	child, err := p.Config(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get child config: %w", err)
+	}
+	if !p.ProvidedEnv {
+		return child, nil
+	}
+	env, err := service.ProvidedEnv(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get provided environment for child %s: %w", child.Name, err)
+	} else if len(env) == 0 {
+		return child, nil
+	}
+	// don't modify the config's copy
+	merged := *child
+	merged.Init = slices.Clone(child.Init)
+	for i := range merged.Init {
+		merged.Init[i].Env = withProvidedEnv(merged.Init[i].Env, env)
+	}
+	merged.Main.Env = withProvidedEnv(merged.Main.Env, env)
+	return &merged, nil
+}
+
+func withProvidedEnv(own, provided map[string]string) map[string]string {
+	ret := maps.Clone(provided)
+	maps.Copy(ret, own)
+	return ret
+}
+
 // ID implements Resource.
 func (p *PM) ID() string {
 	return "pm/" + p.Name
@@ -53,14 +117,15 @@ func (p *PM) ID() string {
 // Start implements Resource.
 func (p *PM) Start(ctx context.Context) error {
 	client := resource.ContextValue[api.API](ctx)
-	child, err := p.Config(ctx)
+	child, err := p.config(ctx)
 	if err != nil {
-		return fmt.Errorf("failed to get child config: %w", err)
+		return err
 	}
 	cur, err := client.Child(ctx, child.Name)
 	if err != nil && !httpx.IsNotFound(err) {
 		return fmt.Errorf("failed checking child %s status: %w", child.Name, err)
 	}
+	p.resetErrors()
 	// decide if we should stop & delete the child before recreating it
 	update, start := true, true
 	clear := cur != nil
@@ -93,34 +158,113 @@ func (p *PM) Start(ctx context.Context) error {
 	// TODO: logging or something
 
 	if p.WaitOnStart {
-		ticker := time.NewTicker(100 * time.Millisecond)
-		defer ticker.Stop()
+		watchCtx, cancel := context.WithCancel(ctx)
+		defer cancel()
+		policy := resource.NewWaitPolicy(
+			time.Second,
+			append([]resource.WaitOption{resource.WaitWake(p.WatchReady(watchCtx))}, p.WaitOptions...)...,
+		)
+		if policy.LastError == nil {
+			policy.LastError = func(context.Context) string { return childReadyStatus(cur).String() }
+		}
+		progress.Logf(ctx, "Waiting for child %s to be ready...", child.Name)
 		first := true
-		for {
-			if first {
-				fmt.Printf("Waiting for child %s to be ready...\n", child.Name)
-				first = false
+		if err := policy.Poll(ctx, func(ctx context.Context) (bool, error) {
+			if !first {
+				if cur, err = client.Child(ctx, child.Name); err != nil {
+					return false, fmt.Errorf("failed checking child %s status: %w", child.Name, err)
+				}
 			}
-			if ready, err := p.isReady(child, cur); err != nil {
-				return fmt.Errorf("child %s failed: %w", child.Name, err)
-			} else if ready {
-				break
+			first = false
+			if err := p.checkCrashLoop(cur); err != nil {
+				return false, err
+			} else if ready, err := p.isReady(child, cur); err != nil {
+				return false, fmt.Errorf("child %s failed: %w", child.Name, err)
+			} else {
+				return ready, nil
 			}
-			select {
-			case <-ctx.Done():
-				return context.Cause(ctx)
-			case <-ticker.C:
-				cur, err = client.Child(ctx, child.Name)
-				if err != nil {
-					return fmt.Errorf("failed checking child %s status: %w", child.Name, err)
-				}
+		}); err != nil {
+			if errors.Is(err, resource.ErrWaitTimeout) {
+				return fmt.Errorf("child %s: %w", child.Name, err)
 			}
+			return err
 		}
 	}
 
 	return nil
 }
 
+// WatchReady implements resource.ReadyWatcher using the daemon's event stream.
+func (p *PM) WatchReady(ctx context.Context) <-chan struct{} {
+	client := resource.ContextValue[api.API](ctx)
+	ch := make(chan struct{}, 1)
+	go func() {
+		// if this fails, e.g. with an older daemon, closing the channel tells the
+		// waiter to fall back on polling
+		defer close(ch)
+		_ = client.Events(ctx, api.EventsOptions{Children: []string{p.Name}}, func(api.Event) error {
+			select {
+			case ch <- struct{}{}:
+			default:
+				// a notification is already pending
+			}
+			return nil
+		})
+	}()
+	return ch
+}
+
+// Plan implements resource.Planner, comparing the desired child definition with
+// the one the daemon currently has.
+func (p *PM) Plan(ctx context.Context) (resource.Plan, error) {
+	client := resource.ContextValue[api.API](ctx)
+	child, err := p.config(ctx)
+	if err != nil {
+		return resource.Plan{}, err
+	}
+	cur, err := client.Child(ctx, child.Name)
+	if err != nil {
+		if httpx.IsNotFound(err) {
+			return resource.Plan{Action: resource.PlanCreate}, nil
+		}
+		return resource.Plan{}, fmt.Errorf("failed checking child %s status: %w", child.Name, err)
+	}
+	if changes := childChanges(child, &cur.Child); len(changes) != 0 {
+		return resource.Plan{Action: resource.PlanUpdate, Changes: changes}, nil
+	} else if p.LimitRestarts && cur.Status.State == api.ChildRunning {
+		return resource.Plan{Action: resource.PlanNone}, nil
+	}
+	return resource.Plan{Action: resource.PlanRestart}, nil
+}
+
+// childChanges lists the fields that differ between two child definitions.
+func childChanges(want, have *api.Child) []string {
+	var changes []string
+	check := func(name string, w, h any) {
+		if !reflect.DeepEqual(w, h) {
+			changes = append(changes, name)
+		}
+	}
+	check("annotations", want.Annotations, have.Annotations)
+	check("init", want.Init, have.Init)
+	check("main.cmd", want.Main.Cmd, have.Main.Cmd)
+	check("main.args", want.Main.Args, have.Main.Args)
+	check("main.cwd", want.Main.Cwd, have.Main.Cwd)
+	check("main.env", want.Main.Env, have.Main.Env)
+	check("main.logfile", want.Main.Logfile, have.Main.Logfile)
+	check("main.limits", want.Main.Limits, have.Main.Limits)
+	check("main.stopSignal", want.Main.StopSignal, have.Main.StopSignal)
+	check("main.stop", want.Main.Stop, have.Main.Stop)
+	check("limits", want.Limits, have.Limits)
+	check("healthCheck", want.HealthCheck, have.HealthCheck)
+	check("oneShot", want.OneShot, have.OneShot)
+	check("noRestart", want.NoRestart, have.NoRestart)
+	check("restartPolicy", want.RestartPolicy, have.RestartPolicy)
+	check("stopGraceSeconds", want.StopGraceSeconds, have.StopGraceSeconds)
+	check("dependsOn", want.DependsOn, have.DependsOn)
+	return changes
+}
+
 // Stop implements Resource.
 func (p *PM) Stop(ctx context.Context) error {
 	client := resource.ContextValue[api.API](ctx)
@@ -144,7 +288,8 @@ func (p *PM) Stop(ctx context.Context) error {
 		case api.ChildStopped, api.ChildDone:
 			cur, err = client.DeleteChild(ctx, child.Name)
 			// check cur/err again at the top
-		case api.ChildError, api.ChildInitError, api.ChildInitRunning, api.ChildRunning:
+		case api.ChildError, api.ChildInitError, api.ChildInitRunning, api.ChildRunning, api.ChildCrashLooping,
+			api.ChildWaiting:
 			cur, err = client.StopChild(ctx, child.Name)
 		case api.ChildStopping:
 			// wait
@@ -161,18 +306,157 @@ func (p *PM) Stop(ctx context.Context) error {
 	}
 }
 
+// Stopped implements resource.StoppedChecker. The child is stopped once it has
+// been removed from the daemon.
+func (p *PM) Stopped(ctx context.Context) (bool, error) {
+	client := resource.ContextValue[api.API](ctx)
+	child, err := p.Config(ctx)
+	if err != nil {
+		return false, fmt.Errorf("failed to get child config: %w", err)
+	}
+	if _, err := client.Child(ctx, child.Name); err != nil {
+		if httpx.IsNotFound(err) {
+			return true, nil
+		}
+		return false, fmt.Errorf("failed checking child %s status: %w", child.Name, err)
+	}
+	return false, nil
+}
+
+// Restart implements resource.Restarter. Unlike Start, this always restarts
+// the child, even if LimitRestarts is set.
+func (p *PM) Restart(ctx context.Context) error {
+	if err := p.Stop(ctx); err != nil {
+		return err
+	}
+	return p.Start(ctx)
+}
+
 // Ready implements Resource
 func (p *PM) Ready(ctx context.Context) (bool, error) {
+	status, err := p.ReadyDetails(ctx)
+	return status.Ready(), err
+}
+
+// ReadyDetails implements resource.ReadyDetailer
+func (p *PM) ReadyDetails(ctx context.Context) (resource.ReadyStatus, error) {
 	client := resource.ContextValue[api.API](ctx)
 	child, err := p.Config(ctx)
 	if err != nil {
-		return false, fmt.Errorf("failed to get child config: %w", err)
+		err = fmt.Errorf("failed to get child config: %w", err)
+		return resource.ReadyStatusFromBool(false, err), err
 	}
 	cur, err := client.Child(ctx, child.Name)
 	if err != nil {
-		return false, fmt.Errorf("failed checking child %s status: %w", child.Name, err)
+		err = fmt.Errorf("failed checking child %s status: %w", child.Name, err)
+		return resource.ReadyStatusFromBool(false, err), err
+	}
+	status := childReadyStatus(cur)
+	if err := p.checkCrashLoop(cur); err != nil {
+		status.State, status.Message = resource.ReadyStateFailed, err.Error()
+		return status, err
+	}
+	ready, err := p.isReady(child, cur)
+	if err != nil {
+		status.State, status.Message = resource.ReadyStateFailed, err.Error()
+	} else if ready {
+		status.State = resource.ReadyStateReady
+	}
+	return status, err
+}
+
+// childReadyStatus describes the state of the child and its execs. The overall
+// state is left as not-ready, to be filled in by the caller based on isReady.
+func childReadyStatus(cur *api.ChildWithStatus) resource.ReadyStatus {
+	status := resource.ReadyStatus{
+		State:   resource.ReadyStateNotReady,
+		Message: fmt.Sprintf("child is %s", cur.Status.State),
+	}
+	for i, es := range cur.Status.Init {
+		status.Conditions = append(status.Conditions, execCondition(fmt.Sprintf("init[%d]", i), es, true))
+	}
+	status.Conditions = append(status.Conditions, execCondition("main", cur.Status.Main, cur.OneShot))
+	if cur.HealthCheck != nil && !cur.OneShot {
+		hc := resource.ReadyCondition{Name: "health"}
+		h := cur.Status.Health
+		switch {
+		case h.Healthy:
+			hc.State = resource.ReadyStateReady
+			if h.LastHealthy != nil {
+				hc.Message = fmt.Sprintf("healthy at %s", h.LastHealthy.Format(time.TimeOnly))
+			}
+		case h.LastUnhealthy != nil:
+			hc.State = resource.ReadyStateNotReady
+			hc.Message = fmt.Sprintf("unhealthy at %s", h.LastUnhealthy.Format(time.TimeOnly))
+		default:
+			hc.State = resource.ReadyStateNotReady
+			hc.Message = "not checked yet"
+		}
+		status.Conditions = append(status.Conditions, hc)
+	}
+	return status
+}
+
+func execCondition(name string, es api.ExecStatus, exitOK bool) resource.ReadyCondition {
+	c := resource.ReadyCondition{Name: name}
+	switch {
+	case es.StartErr != "":
+		c.State, c.Message = resource.ReadyStateFailed, "failed to start: "+es.StartErr
+	case es.State == api.ExecRunning:
+		c.State, c.Message = resource.ReadyStateReady, fmt.Sprintf("running (pid %d)", es.Pid)
+		if exitOK {
+			// need it to complete
+			c.State = resource.ReadyStateNotReady
+		}
+	case es.State == api.ExecEnded && es.ExitCode == 0 && exitOK:
+		c.State, c.Message = resource.ReadyStateReady, "completed"
+	case es.State == api.ExecEnded:
+		c.State, c.Message = resource.ReadyStateFailed, fmt.Sprintf("exited with code %d", es.ExitCode)
+	default:
+		c.State, c.Message = resource.ReadyStateNotReady, string(es.State)
 	}
-	return p.isReady(child, cur)
+	return c
+}
+
+func (p *PM) resetErrors() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.lastState, p.errorCount = "", 0
+}
+
+// checkCrashLoop records the child's current state, and returns an error if it
+// has repeatedly been seen entering an error state.
+func (p *PM) checkCrashLoop(cur *api.ChildWithStatus) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	state := cur.Status.State
+	if state == api.ChildCrashLooping {
+		return fmt.Errorf("child %s is crash looping after %d restarts: %s",
+			cur.Name, cur.Status.Restarts, cur.Status.LastExit,
+		)
+	}
+	if state != p.lastState && (state == api.ChildError || state == api.ChildInitError) {
+		p.errorCount++
+	}
+	p.lastState = state
+	if p.errorCount >= crashLoopErrors {
+		return fmt.Errorf("child %s is crash looping: entered an error state %d times, last exit code %d",
+			cur.Name, p.errorCount, lastExitCode(cur.Status),
+		)
+	}
+	return nil
+}
+
+func lastExitCode(status api.ChildStatus) int {
+	if status.Main.State != api.ExecNotStarted {
+		return status.Main.ExitCode
+	}
+	for i := len(status.Init) - 1; i >= 0; i-- {
+		if status.Init[i].State != api.ExecNotStarted {
+			return status.Init[i].ExitCode
+		}
+	}
+	return 0
 }
 
 func (p *PM) isReady(_ *api.Child, cur *api.ChildWithStatus) (bool, error) {
@@ -218,3 +502,17 @@ func (p *PM) isReady(_ *api.Child, cur *api.ChildWithStatus) (bool, error) {
 
 	return cur.Status.Health.Healthy, nil
 }
+
+// DebugLaunch builds a debug launch for a service run by a pm child, using the
+// args, env, and cwd from the exec that normally runs it. Program replaces the
+// exec's Cmd, as the debugger needs the source package or script instead of
+// the built binary.
+func DebugLaunch(debugger service.Debugger, program string, exec api.Exec) service.DebugLaunch {
+	return service.DebugLaunch{
+		Debugger: debugger,
+		Program:  program,
+		Args:     slices.Clone(exec.Args),
+		Env:      maps.Clone(exec.Env),
+		Cwd:      exec.Cwd,
+	}
+}
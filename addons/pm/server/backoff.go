@@ -0,0 +1,85 @@
+package server
+
+import (
+	"time"
+
+	"fastcat.org/go/gdev/addons/pm/api"
+)
+
+// restartBackoff tracks the delays between automatic restarts of a child, and
+// whether it is crash looping, according to its [api.RestartPolicy].
+type restartBackoff struct {
+	initial, max   time.Duration
+	multiplier     float64
+	maxRestarts    int
+	window         time.Duration
+	resetAfter     time.Duration
+	next           time.Duration
+	recentRestarts []time.Time
+}
+
+// newRestartBackoff resolves the policy, filling in defaults. The default
+// initial delay is given separately so tests can speed things up.
+func newRestartBackoff(policy *api.RestartPolicy, defaultInitial time.Duration) *restartBackoff {
+	var p api.RestartPolicy
+	if policy != nil {
+		p = *policy
+	}
+	seconds := func(value float64, def time.Duration) time.Duration {
+		if value <= 0 {
+			return def
+		}
+		return time.Duration(value * float64(time.Second))
+	}
+	b := &restartBackoff{
+		initial:     seconds(p.InitialDelaySeconds, defaultInitial),
+		max:         seconds(p.MaxDelaySeconds, time.Minute),
+		multiplier:  p.Multiplier,
+		maxRestarts: p.MaxRestarts,
+		window:      seconds(p.WindowSeconds, 5*time.Minute),
+		resetAfter:  seconds(p.ResetAfterHealthySeconds, 30*time.Second),
+	}
+	if b.multiplier < 1 {
+		b.multiplier = 2
+	}
+	if b.maxRestarts == 0 {
+		b.maxRestarts = 5
+	}
+	b.max = max(b.max, b.initial)
+	b.reset()
+	return b
+}
+
+// reset clears the backoff, as if the child had never failed.
+func (b *restartBackoff) reset() {
+	b.next = b.initial
+	b.recentRestarts = b.recentRestarts[:0]
+}
+
+// failed records a failure at now, returning the delay before the next
+// restart, or false if the child is crash looping and should not be restarted.
+func (b *restartBackoff) failed(now time.Time) (time.Duration, bool) {
+	cutoff := now.Add(-b.window)
+	recent := b.recentRestarts[:0]
+	for _, t := range b.recentRestarts {
+		if t.After(cutoff) {
+			recent = append(recent, t)
+		}
+	}
+	b.recentRestarts = recent
+	if b.maxRestarts > 0 && len(b.recentRestarts) >= b.maxRestarts {
+		return 0, false
+	}
+	b.recentRestarts = append(b.recentRestarts, now)
+	delay := b.next
+	b.next = min(time.Duration(float64(b.next)*b.multiplier), b.max)
+	return delay, true
+}
+
+// healthyFor checks if the child has been healthy for long enough to reset the
+// backoff, and does so if it has.
+func (b *restartBackoff) healthyFor(d time.Duration) {
+	if d >= b.resetAfter {
+		b.reset()
+	}
+}
@@ -0,0 +1,49 @@
+package server
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"fastcat.org/go/gdev/addons/pm/api"
+)
+
+func TestRestartBackoff(t *testing.T) {
+	b := newRestartBackoff(&api.RestartPolicy{
+		MaxDelaySeconds: 5,
+		MaxRestarts:     4,
+		WindowSeconds:   60,
+	}, time.Second)
+	now := time.Now()
+	var delays []time.Duration
+	for range 4 {
+		d, ok := b.failed(now)
+		assert.True(t, ok)
+		delays = append(delays, d)
+		now = now.Add(d)
+	}
+	assert.Equal(t, []time.Duration{time.Second, 2 * time.Second, 4 * time.Second, 5 * time.Second}, delays)
+	_, ok := b.failed(now)
+	assert.False(t, ok, "should be crash looping")
+
+	// old restarts fall out of the window
+	d, ok := b.failed(now.Add(time.Minute))
+	assert.True(t, ok)
+	assert.Equal(t, 5*time.Second, d, "delay should not reset without being healthy")
+
+	b.healthyFor(time.Second)
+	d, _ = b.failed(now.Add(2 * time.Minute))
+	assert.Equal(t, 5*time.Second, d)
+	b.healthyFor(30 * time.Second)
+	d, _ = b.failed(now.Add(3 * time.Minute))
+	assert.Equal(t, time.Second, d)
+
+	// defaults, unlimited
+	b = newRestartBackoff(&api.RestartPolicy{MaxRestarts: -1}, 10*time.Millisecond)
+	for range 20 {
+		d, ok = b.failed(now)
+		assert.True(t, ok)
+	}
+	assert.Equal(t, time.Minute, d)
+}
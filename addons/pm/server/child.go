@@ -1,9 +1,11 @@
 package server
 
 import (
+	"bytes"
 	"context"
 	"crypto/tls"
 	"errors"
+	"fmt"
 	"io"
 	"log"
 	"net"
@@ -18,6 +20,11 @@ import (
 	"syscall"
 	"time"
 
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+
 	"fastcat.org/go/gdev/addons/pm/api"
 	"fastcat.org/go/gdev/instance"
 	"fastcat.org/go/gdev/lib/sys"
@@ -27,8 +34,24 @@ type child struct {
 	def      api.Child
 	status   atomic.Pointer[api.ChildStatus]
 	cmds     chan childCmd
+	signals  chan childSignal
+	done     chan struct{} // closed when the manager exits
 	wg       sync.WaitGroup
 	isolator sys.Isolator
+	logs     *logBuffer
+
+	// if set, where to put the named pipes for exec output so execs can outlive
+	// the daemon, see [execOutput]
+	outputDir string
+	// restore is the saved state to re-adopt when the manager starts
+	restore *savedChild
+	// saved is the state to persist, onChange is called whenever it is updated
+	saved    atomic.Pointer[savedChild]
+	onChange func()
+	// output of the most recently started exec
+	output atomic.Pointer[execOutput]
+	// onEvent, if set, is called to publish changes to the child
+	onEvent func(api.Event)
 
 	restartDelay               time.Duration
 	killDelay                  time.Duration
@@ -40,10 +63,14 @@ func newChild(def api.Child, isolator sys.Isolator) *child {
 	c := &child{
 		def:      def,
 		cmds:     make(chan childCmd), // important that this be un-buffered
+		signals:  make(chan childSignal),
+		done:     make(chan struct{}),
 		isolator: isolator,
+		logs:     newLogBuffer(defaultLogLines),
 
 		// tests may override these
-		restartDelay: time.Second, // TODO: scale
+		// initial delay if the restart policy doesn't set one
+		restartDelay: time.Second,
 		killDelay:    5 * time.Second,
 		// long initial delay, will be reset to a proper interval when active
 		healthCheckInitialInterval: time.Second,
@@ -61,15 +88,34 @@ const (
 	childStart  childCmd = "start"
 	childStop   childCmd = "stop"
 	childDelete childCmd = "delete"
+	// childDetach stops managing the child, leaving any running exec for a
+	// future daemon to re-adopt
+	childDetach childCmd = "detach"
+	// childDepsReady starts a waiting child, its dependencies are met
+	childDepsReady childCmd = "deps-ready"
+	// childDepsLost stops a running child and makes it wait for its
+	// dependencies again
+	childDepsLost childCmd = "deps-lost"
 )
 
+// send sends a command to the manager, unless it has exited or ctx is done.
+func (c *child) send(ctx context.Context, cmd childCmd) {
+	select {
+	case c.cmds <- cmd:
+	case <-c.done:
+	case <-ctx.Done():
+	}
+}
+
 func (c *child) run() {
 	// TODO: this is non-standard use of the waitgroup
 	c.wg.Add(1)
 	defer c.wg.Done()
+	defer close(c.done)
+	// wake up anyone following the logs, there won't be any more
+	defer c.logs.close()
 
 	status := initialStatus(c)
-	c.status.Store(cloneStatus(status))
 
 	curExec := -1 // initially nothing is running
 	curStatus := func() *api.ExecStatus {
@@ -82,9 +128,27 @@ func (c *child) run() {
 		}
 	}
 	var curProc *os.Process
+	// start time of curProc, see [savedChild]
+	var curStart uint64
 	procExited := make(chan error, 1)
+	startExec := func() {
+		s := curStatus()
+		curProc, *s, status.State = c.start(curExec, procExited)
+		curStart = 0
+		if curProc != nil {
+			var err error
+			if curStart, err = procStartTime(curProc.Pid); err != nil {
+				log.Printf("child %s: can't get start time of pid %d: %v", c.def.Name, curProc.Pid, err)
+			}
+		}
+	}
 
 	var kill <-chan time.Time
+	// closed when the stop command, if any, finishes
+	var stopped <-chan struct{}
+	// set when stopping because the dependencies were lost, to wait for them
+	// again instead of staying stopped
+	var rewait bool
 	var restart <-chan time.Time
 	healthCheck := time.NewTicker(time.Hour)
 	healthCheck.Stop()
@@ -92,27 +156,78 @@ func (c *child) run() {
 	healthChecks := -1
 	healthResults := make(chan bool, 1)
 
+	backoff := newRestartBackoff(c.def.RestartPolicy, c.restartDelay)
+	// when the child was last seen to be running and healthy, to decide when to
+	// reset the backoff
+	var healthySince time.Time
+	scheduleRestart := func(what string) {
+		now := time.Now()
+		if !healthySince.IsZero() {
+			backoff.healthyFor(now.Sub(healthySince))
+		}
+		if delay, ok := backoff.failed(now); ok {
+			log.Printf("child %s %s, will restart in %v", c.def.Name, what, delay)
+			restart = time.After(delay)
+		} else {
+			status.State = api.ChildCrashLooping
+			log.Printf("child %s %s, crash looping after %d restarts, will not automatically restart",
+				c.def.Name, what, status.Restarts)
+		}
+	}
+
+	if r := c.restore; r != nil {
+		c.restore = nil
+		status, curExec = *cloneStatus(r.Status), r.Exec
+		if p, err := c.adopt(r, procExited); err != nil {
+			log.Printf("child %s: can't re-adopt: %v", c.def.Name, err)
+		} else if p != nil {
+			curProc, curStart = p, r.StartTime
+			log.Printf("re-adopted child %s pid %d in state %s", c.def.Name, p.Pid, status.State)
+			if status.State == api.ChildStopping {
+				// finish stopping it, the stop command may have already run
+				c.terminate(curProc, curStatus(), c.stopSignal(c.execDef(curExec)))
+				kill = time.After(c.stopGrace())
+			}
+		}
+		if curProc == nil {
+			c.restoreLost(&status, &curExec, scheduleRestart)
+		}
+	}
+	c.status.Store(cloneStatus(status))
+	c.save(status, curExec, curStart)
+
 MANAGER:
 	for {
+		before := getEventState(status)
+		var events []api.Event
 		select {
 		case cmd := <-c.cmds:
 			switch cmd {
 			case childStart:
 				switch status.State {
-				case api.ChildStopped, api.ChildError, api.ChildInitError, api.ChildDone:
-					// start over from scratch
+				case api.ChildStopped, api.ChildError, api.ChildInitError, api.ChildDone, api.ChildCrashLooping:
+					// start over from scratch, clearing any backoff
+					restart = nil
+					backoff.reset()
+					status.Restarts = 0
 					curExec = 0
-					s := curStatus()
-					curProc, *s, status.State = c.start(curExec, procExited)
+					if len(c.def.DependsOn) != 0 {
+						// the daemon will tell us when the dependencies are met
+						log.Printf("child %s waiting for dependencies", c.def.Name)
+						status.State = api.ChildWaiting
+					} else {
+						startExec()
+					}
 				default:
 					log.Printf("cannot start child %s from state %s", c.def.Name, status.State)
 				}
 			case childStop:
 				if curProc == nil {
 					switch status.State {
-					case api.ChildError, api.ChildInitError:
+					case api.ChildError, api.ChildInitError, api.ChildCrashLooping, api.ChildWaiting:
 						curExec = 0
 						// cancel any restart
+						restart = nil
 						status.State = api.ChildStopped
 					case api.ChildStopped, api.ChildDone:
 						// ok
@@ -122,17 +237,57 @@ MANAGER:
 					}
 					break
 				}
-				c.terminate(curProc, curStatus())
-				kill = time.After(c.killDelay)
+				stopped = c.beginStop(curExec, curProc, curStatus())
+				kill = time.After(c.stopGrace())
 				status.State = api.ChildStopping
+				// an explicit stop wins over waiting for dependencies
+				rewait = false
+			case childDepsReady:
+				if status.State == api.ChildWaiting {
+					log.Printf("child %s dependencies met, starting", c.def.Name)
+					curExec = 0
+					startExec()
+				}
+			case childDepsLost:
+				switch status.State {
+				case api.ChildInitRunning, api.ChildRunning:
+					if curProc == nil {
+						break
+					}
+					stopped = c.beginStop(curExec, curProc, curStatus())
+					kill = time.After(c.stopGrace())
+					status.State = api.ChildStopping
+					rewait = true
+				case api.ChildInitError, api.ChildError:
+					// cancel any restart, it will start again once the dependencies are met
+					restart = nil
+					curExec = 0
+					status.State = api.ChildWaiting
+				}
 			case childDelete:
 				if status.State != api.ChildStopped && status.State != api.ChildDone {
 					log.Printf("cannot delete child %s in state %s", c.def.Name, status.State)
 					break
 				}
 				// TODO: assert curProc != nil?
+				if c.outputDir != "" {
+					_ = os.RemoveAll(c.outputDir)
+				}
+				break MANAGER
+			case childDetach:
+				log.Printf("detaching from child %s in state %s", c.def.Name, status.State)
+				if o := c.output.Load(); o != nil {
+					o.detach()
+				}
 				break MANAGER
 			}
+		case req := <-c.signals:
+			req.result <- c.sendSignal(curProc, status.State, req.sig)
+		case <-stopped:
+			stopped = nil
+			if curProc != nil && status.State == api.ChildStopping {
+				c.terminate(curProc, curStatus(), c.stopSignal(c.execDef(curExec)))
+			}
 		case <-kill:
 			if curProc == nil {
 				break
@@ -144,18 +299,33 @@ MANAGER:
 			if curProc == nil {
 				panic("unimplemented: wtf")
 			}
-			curProc = nil
+			curProc, curStart = nil, 0
+			// it doesn't need signalling any more once the stop command finishes
+			stopped = nil
 			s := curStatus()
 			// make sure any children that tried to fork off get caught and killed via
 			// the cgroup, unless they managed to escape into a new cgroup
 			c.cleanup(s)
 			s.State = api.ExecEnded
+			// adopted execs can't tell us if they succeeded
+			exitUnknown := errors.Is(err, errExitUnknown)
+			name := execName(curExec, len(status.Init))
+			status.LastExit = name + " exited with code 0"
+			s.ExitCode = 0
 			if ee, ok := errors.AsType[*exec.ExitError](err); ok {
 				s.ExitCode = ee.ExitCode()
-			} else {
-				s.ExitCode = 0
+				status.LastExit = name + " " + exitReason(ee)
+			} else if exitUnknown {
+				s.ExitCode = -1
+				status.LastExit = name + " exited, " + err.Error()
 			}
 			log.Printf("child %s pid %d exited with code %d", c.def.Name, s.Pid, s.ExitCode)
+			events = append(events, api.Event{
+				Type:     api.EventExecExited,
+				Exec:     name,
+				ExitCode: new(s.ExitCode),
+				Message:  status.LastExit,
+			})
 			s.Pid = 0
 			switch status.State {
 			case api.ChildStopping:
@@ -164,6 +334,10 @@ MANAGER:
 				c.cleanupAll(&status)
 				// stop completed
 				status.State = api.ChildStopped
+				if rewait {
+					rewait = false
+					status.State = api.ChildWaiting
+				}
 				// reset the starting process to the beginning
 				curExec = 0
 			case api.ChildInitRunning:
@@ -171,8 +345,12 @@ MANAGER:
 					log.Printf("child %s init %d complete, moving on", c.def.Name, curExec)
 					// start next container
 					curExec++
-					s := curStatus()
-					curProc, *s, status.State = c.start(curExec, procExited)
+					startExec()
+				} else if exitUnknown {
+					// it may well have succeeded, so running it again doesn't count as
+					// a restart to back off from
+					log.Printf("child %s init %d exited with unknown status, running it again", c.def.Name, curExec)
+					startExec()
 				} else {
 					status.State = api.ChildInitError
 					if c.def.NoRestart {
@@ -181,12 +359,15 @@ MANAGER:
 							c.def.Name, curExec, s.ExitCode,
 						)
 					} else {
-						log.Printf("child %s init %d failed with code %d, will restart", c.def.Name, curExec, s.ExitCode)
-						restart = time.After(c.restartDelay)
+						scheduleRestart(fmt.Sprintf("init %d failed with code %d", curExec, s.ExitCode))
 					}
 				}
 			case api.ChildRunning:
-				if c.def.OneShot {
+				if c.def.OneShot && exitUnknown {
+					// as for init, it may well have succeeded
+					log.Printf("child %s one-shot exited with unknown status, running it again", c.def.Name)
+					startExec()
+				} else if c.def.OneShot {
 					log.Printf("child %s one-shot completed with code %d", c.def.Name, s.ExitCode)
 					if s.ExitCode == 0 {
 						status.State = api.ChildDone
@@ -203,29 +384,24 @@ MANAGER:
 					if c.def.NoRestart {
 						log.Printf("child %s exited with code %d, will not automatically restart", c.def.Name, s.ExitCode)
 					} else {
-						log.Printf("child %s service exited with code %d, will restart", c.def.Name, s.ExitCode)
-						restart = time.After(c.restartDelay)
+						scheduleRestart(fmt.Sprintf("service exited with code %d", s.ExitCode))
 					}
 				}
 			default:
 				log.Printf("wtf? child %s got exit notification in state %s", c.def.Name, status.State)
 			}
 		case <-restart:
-			log.Printf("child %s exec %d: restarting", c.def.Name, curExec)
-			s := curStatus()
-			curProc, *s, status.State = c.start(curExec, procExited)
+			restart = nil
+			status.Restarts++
+			log.Printf("child %s exec %d: restarting (%d)", c.def.Name, curExec, status.Restarts)
+			startExec()
 		case <-healthCheck.C:
-			// TODO: do a health check
-			switch {
-			case c.def.HealthCheck.Http != nil:
-				timeout := time.Second
-				if c.def.HealthCheck.TimeoutSeconds > 0 {
-					timeout = time.Duration(c.def.HealthCheck.TimeoutSeconds) * time.Second
-				}
-				go func() { healthResults <- c.httpCheck(c.def.HealthCheck.Http, timeout) }()
-			default:
-				log.Printf("child %s: no recognized health check", c.def.Name)
+			timeout := time.Second
+			if c.def.HealthCheck.TimeoutSeconds > 0 {
+				timeout = time.Duration(c.def.HealthCheck.TimeoutSeconds) * time.Second
 			}
+			group := status.Main.Group
+			go func() { healthResults <- c.checkHealth(c.def.HealthCheck, group, timeout) }()
 
 			healthChecks++
 			// switch to the slower interval after N attempts
@@ -249,6 +425,14 @@ MANAGER:
 			}
 		}
 
+		if status.State == api.ChildRunning && (c.def.HealthCheck == nil || status.Health.Healthy) {
+			if healthySince.IsZero() {
+				healthySince = time.Now()
+			}
+		} else {
+			healthySince = time.Time{}
+		}
+
 		// if the child main just started, activate the health-check timer
 		if status.State == api.ChildRunning && c.def.HealthCheck != nil {
 			if healthChecks < 0 {
@@ -261,12 +445,129 @@ MANAGER:
 		}
 
 		c.status.Store(cloneStatus(status))
+		c.save(status, curExec, curStart)
+		if c.onEvent != nil {
+			for _, e := range statusEvents(c.def.Name, events, before, status) {
+				c.onEvent(e)
+			}
+		}
 	}
 }
 
-func (c *child) httpCheck(check *api.HttpHealthCheck, timeout time.Duration) bool {
+// adopt re-attaches to the exec that was running when the saved state was
+// recorded, if it is still running.
+func (c *child) adopt(r *savedChild, exited chan<- error) (*os.Process, error) {
+	if r.StartTime == 0 || r.Exec < 0 {
+		return nil, nil
+	}
+	s, e := &r.Status.Main, c.def.Main
+	if r.Exec < len(r.Status.Init) {
+		s, e = &r.Status.Init[r.Exec], c.def.Init[r.Exec]
+	}
+	if st, err := procStartTime(s.Pid); err != nil || st != r.StartTime {
+		return nil, fmt.Errorf("pid %d is no longer running", s.Pid)
+	}
+	pid, startTime := s.Pid, r.StartTime
+	out, err := c.openOutput(r.Exec, e, false)
+	if err != nil {
+		// don't leave it running where nobody is managing it
+		_ = syscall.Kill(-pid, syscall.SIGKILL)
+		return nil, fmt.Errorf("killed pid %d, unable to re-attach output: %w", pid, err)
+	}
+	p, err := os.FindProcess(pid)
+	if err != nil {
+		out.close()
+		return nil, err
+	}
+	out.started()
+	c.output.Store(out)
+	c.wg.Go(func() {
+		waitAdopted(pid, startTime)
+		out.finish()
+		exited <- errExitUnknown
+	})
+	return p, nil
+}
+
+// restoreLost fixes up the restored status of a child that doesn't have a
+// running exec we could re-adopt, scheduling a restart if it should be running.
+func (c *child) restoreLost(status *api.ChildStatus, curExec *int, scheduleRestart func(string)) {
+	// make sure nothing is left behind in the isolation groups
+	c.cleanupAll(status)
+	if *curExec >= 0 {
+		s := &status.Main
+		if *curExec < len(status.Init) {
+			s = &status.Init[*curExec]
+		}
+		if s.State == api.ExecRunning || s.State == api.ExecStopping {
+			s.State, s.ExitCode, s.Pid = api.ExecEnded, -1, 0
+			status.LastExit = execName(*curExec, len(status.Init)) + " exited while pm was not running"
+		}
+	}
+	switch status.State {
+	case api.ChildStopping:
+		status.State = api.ChildStopped
+		*curExec = 0
+		return
+	case api.ChildInitRunning:
+		status.State = api.ChildInitError
+	case api.ChildRunning:
+		status.State = api.ChildError
+	case api.ChildInitError, api.ChildError:
+		// a restart was probably pending
+	default:
+		return
+	}
+	if c.def.NoRestart || c.def.OneShot && status.State == api.ChildError {
+		log.Printf("child %s is not running after pm restarted, will not automatically restart", c.def.Name)
+		return
+	}
+	scheduleRestart("is not running after pm restarted")
+}
+
+// save records the state to persist, if anyone is listening for it.
+func (c *child) save(status api.ChildStatus, curExec int, curStart uint64) {
+	if c.onChange == nil {
+		return
+	}
+	c.saved.Store(&savedChild{
+		Child:     c.def,
+		Status:    *cloneStatus(status),
+		Exec:      curExec,
+		StartTime: curStart,
+	})
+	c.onChange()
+}
+
+// checkHealth runs whichever health check is configured. The group is the
+// isolation group of the main exec, if any.
+func (c *child) checkHealth(check *api.HealthCheck, group string, timeout time.Duration) bool {
 	ctx, cancel := context.WithTimeout(context.TODO(), timeout)
 	defer cancel()
+	switch {
+	case check.Http != nil:
+		return c.httpCheck(ctx, check.Http)
+	case check.Tcp != nil:
+		return c.tcpCheck(ctx, check.Tcp)
+	case check.Exec != nil:
+		return c.execCheck(ctx, check.Exec, group)
+	case check.Grpc != nil:
+		return c.grpcCheck(ctx, check.Grpc)
+	default:
+		log.Printf("child %s: no recognized health check", c.def.Name)
+		return false
+	}
+}
+
+// exitReason describes how a process exited.
+func exitReason(ee *exec.ExitError) string {
+	if ws, ok := ee.Sys().(syscall.WaitStatus); ok && ws.Signaled() {
+		return "killed by " + signalName(ws.Signal())
+	}
+	return "exited with code " + strconv.Itoa(ee.ExitCode())
+}
+
+func (c *child) httpCheck(ctx context.Context, check *api.HttpHealthCheck) bool {
 	u := &url.URL{
 		// TODO: ipv6 hackery?
 		Host: net.JoinHostPort("localhost", strconv.Itoa(check.Port)),
@@ -302,6 +603,74 @@ func (c *child) httpCheck(check *api.HttpHealthCheck, timeout time.Duration) boo
 	return true
 }
 
+func (c *child) tcpCheck(ctx context.Context, check *api.TcpHealthCheck) bool {
+	var d net.Dialer
+	conn, err := d.DialContext(ctx, "tcp", net.JoinHostPort("localhost", strconv.Itoa(check.Port)))
+	if err != nil {
+		log.Printf("failed to connect to port %d for %s: %v", check.Port, c.def.Name, err)
+		return false
+	}
+	_ = conn.Close()
+	return true
+}
+
+func (c *child) execCheck(ctx context.Context, check *api.ExecHealthCheck, group string) bool {
+	cmd := exec.CommandContext(ctx, check.Cmd, check.Args...)
+	cmd.Dir = c.def.Main.Cwd
+	cmd.Env = os.Environ()
+	for k, v := range c.def.Main.Env {
+		cmd.Env = append(cmd.Env, k+"="+v)
+	}
+	for k, v := range check.Env {
+		cmd.Env = append(cmd.Env, k+"="+v)
+	}
+	// kill the whole process group on timeout, like we do for the child
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+	cmd.Cancel = func() error { return syscall.Kill(-cmd.Process.Pid, syscall.SIGKILL) }
+	var out bytes.Buffer
+	cmd.Stdout, cmd.Stderr = &out, &out
+	if err := cmd.Start(); err != nil {
+		log.Printf("failed to start health check for %s: %v", c.def.Name, err)
+		return false
+	}
+	if group != "" {
+		if err := c.isolator.Join(ctx, group, cmd.Process); err != nil {
+			log.Printf("failed to add health check for %s to isolation group %q: %v", c.def.Name, group, err)
+		}
+	}
+	if err := cmd.Wait(); err != nil {
+		log.Printf("health check for %s failed: %v: %s", c.def.Name, err, bytes.TrimSpace(out.Bytes()))
+		return false
+	}
+	return true
+}
+
+func (c *child) grpcCheck(ctx context.Context, check *api.GrpcHealthCheck) bool {
+	creds := insecure.NewCredentials()
+	if check.TLS {
+		creds = credentials.NewTLS(&tls.Config{InsecureSkipVerify: check.Insecure})
+	}
+	conn, err := grpc.NewClient(
+		net.JoinHostPort("localhost", strconv.Itoa(check.Port)),
+		grpc.WithTransportCredentials(creds),
+	)
+	if err != nil {
+		log.Printf("failed to construct grpc client for %s: %v", c.def.Name, err)
+		return false
+	}
+	defer conn.Close() //nolint:errcheck
+	resp, err := healthpb.NewHealthClient(conn).Check(ctx, &healthpb.HealthCheckRequest{Service: check.Service})
+	if err != nil {
+		log.Printf("failed to send grpc health check for %s: %v", c.def.Name, err)
+		return false
+	}
+	if resp.GetStatus() != healthpb.HealthCheckResponse_SERVING {
+		log.Printf("grpc health check for %s returned status %s", c.def.Name, resp.GetStatus())
+		return false
+	}
+	return true
+}
+
 func initialStatus(c *child) api.ChildStatus {
 	s := api.ChildStatus{
 		State:  api.ChildStopped,
@@ -331,7 +700,7 @@ func (c *child) start(
 	if idx < len(c.def.Init) {
 		runningState, errorState = api.ChildInitRunning, api.ChildInitError
 		e = c.def.Init[idx]
-		name = c.def.Name + "-init-" + strconv.Itoa(idx)
+		name = c.def.Name + "-" + execName(idx, len(c.def.Init))
 	}
 	cmd := exec.Command(e.Cmd, e.Args...)
 	if e.Cwd != "" {
@@ -343,38 +712,41 @@ func (c *child) start(
 	}
 	// set pgid so we can kill process groups
 	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
-	// if logfile is not set, pass output to stdout/stderr and let journalctl
-	// capture it. note that this only works if we're using systemd for isolation.
-	if e.Logfile == "" {
-		cmd.Stdout, cmd.Stderr = os.Stdout, os.Stderr
-	} else {
-		lf, err := os.OpenFile(e.Logfile, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
-		if err != nil {
-			log.Printf("failed to start %s, unable to open logfile %q: %v", c.def.Name, e.Logfile, err)
-			return nil, api.ExecStatus{State: api.ExecNotStarted, StartErr: err.Error()}, errorState
-		}
-		cmd.Stdout, cmd.Stderr = lf, lf
-		// the fd will be passed directly to the child, so we can close it when we return
-		defer lf.Close() //nolint:errcheck
+	// output is always captured into the log buffer
+	out, err := c.openOutput(idx, e, true)
+	if err != nil {
+		log.Printf("failed to start %s, unable to capture output: %v", c.def.Name, err)
+		return nil, api.ExecStatus{State: api.ExecNotStarted, StartErr: err.Error()}, errorState
 	}
+	cmd.Stdout, cmd.Stderr = out.cmdOutputs()
+	// don't let background processes that inherited the output pipes block us
+	// noticing that the child has exited
+	cmd.WaitDelay = time.Second
 
 	if err := cmd.Start(); err != nil {
 		log.Printf("failed to start %s: %v", c.def.Name, err)
+		out.finish()
 		return nil, api.ExecStatus{State: api.ExecNotStarted, StartErr: err.Error()}, errorState
 	}
 	log.Printf("started %s as pid %d", name, cmd.Process.Pid)
+	out.started()
+	c.output.Store(out)
 	c.wg.Go(func() {
 		err := cmd.Wait()
+		out.finish()
 		exited <- err
 	})
 	eStat := api.ExecStatus{
 		State: api.ExecRunning,
 		Pid:   cmd.Process.Pid,
 	}
+	limits := execLimits(c.def, e)
+	c.setNice(cmd.Process.Pid, limits)
 	if isolationGroup, err := c.isolator.Isolate(
 		context.TODO(),
 		instance.AppName()+"-pm-"+name+".scope",
 		cmd.Process,
+		sysLimits(limits),
 	); err != nil {
 		if errors.Is(err, syscall.EROFS) {
 			printCGroupsROWarning()
@@ -387,17 +759,6 @@ func (c *child) start(
 	return cmd.Process, eStat, runningState
 }
 
-func (c *child) terminate(p *os.Process, s *api.ExecStatus) {
-	// signal the whole process group
-	if err := syscall.Kill(-p.Pid, syscall.SIGTERM); err != nil {
-		log.Printf("failed to terminate %d: %v", p.Pid, err)
-	} else {
-		log.Printf("sent SIGTERM to child %s pid %d", c.def.Name, p.Pid)
-	}
-
-	s.State = api.ExecStopping
-}
-
 func (c *child) kill(p *os.Process, s *api.ExecStatus) {
 	log.Printf("resorting to SIGKILL for child %s pid %d", c.def.Name, p.Pid)
 	// signal the whole process group
@@ -443,3 +804,7 @@ func (c *child) Status() api.ChildStatus {
 func (c *child) Wait() {
 	c.wg.Wait()
 }
+
+func (c *child) Logs(ctx context.Context, opts api.LogsOptions, fn func(api.LogLine) error) error {
+	return c.logs.follow(ctx, opts, fn)
+}
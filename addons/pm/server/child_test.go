@@ -176,6 +176,12 @@ func TestChildFails(t *testing.T) {
 }
 
 func TestChildLogs(t *testing.T) {
+	t.Run("pipes", func(t *testing.T) { testChildLogs(t, false) })
+	// execs that can outlive the daemon
+	t.Run("fifos", func(t *testing.T) { testChildLogs(t, true) })
+}
+
+func testChildLogs(t *testing.T, fifos bool) {
 	isolator, err := sys.GetIsolator()
 	require.NoError(t, err)
 	td := t.TempDir()
@@ -198,6 +204,9 @@ func TestChildLogs(t *testing.T) {
 		OneShot: true,
 	}
 	c := newChild(def, isolator)
+	if fifos {
+		c.outputDir = filepath.Join(td, "output")
+	}
 	t.Cleanup(c.Wait)
 	if !runChild(t, c, time.Millisecond) {
 		return
@@ -211,6 +220,14 @@ func TestChildLogs(t *testing.T) {
 	mainLog, err := os.ReadFile(filepath.Join(td, "test1-main.log"))
 	require.NoError(t, err)
 	assert.Equal(t, "hello\nworld\n", string(mainLog))
+
+	// and the logs we captured
+	var lines []string
+	require.NoError(t, c.Logs(t.Context(), api.LogsOptions{Init: true}, func(l api.LogLine) error {
+		lines = append(lines, l.Exec+" "+l.Text)
+		return nil
+	}))
+	assert.Equal(t, []string{"init-0 init1out", "init-0 init1err", "main hello", "main world"}, lines)
 }
 
 func runChild(t *testing.T, c *child, pollRate time.Duration) bool {
@@ -276,3 +293,41 @@ func runChild(t *testing.T, c *child, pollRate time.Duration) bool {
 	}
 	return success
 }
+
+func TestChildCrashLooping(t *testing.T) {
+	isolator, err := sys.GetIsolator()
+	require.NoError(t, err)
+	c := newChild(api.Child{
+		Name: "crasher",
+		Main: api.Exec{Cmd: "sh", Args: []string{"-c", "exit 3"}},
+		RestartPolicy: &api.RestartPolicy{
+			InitialDelaySeconds: 0.01,
+			MaxRestarts:         2,
+		},
+	}, isolator)
+	t.Cleanup(c.Wait)
+	go c.run()
+	c.cmds <- childPing
+	c.cmds <- childStart
+	c.cmds <- childPing
+
+	require.Eventually(t, func() bool {
+		return c.Status().State == api.ChildCrashLooping
+	}, 5*time.Second, 5*time.Millisecond)
+	s := c.Status()
+	assert.Equal(t, 2, s.Restarts)
+	assert.Equal(t, "main exited with code 3", s.LastExit)
+
+	// an explicit start clears the backoff
+	c.cmds <- childStart
+	c.cmds <- childPing
+	assert.Zero(t, c.Status().Restarts)
+	require.Eventually(t, func() bool {
+		return c.Status().State == api.ChildCrashLooping
+	}, 5*time.Second, 5*time.Millisecond)
+
+	c.cmds <- childStop
+	c.cmds <- childPing
+	assert.Equal(t, api.ChildStopped, c.Status().State)
+	c.cmds <- childDelete
+}
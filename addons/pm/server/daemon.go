@@ -6,8 +6,11 @@ import (
 	"log"
 	"maps"
 	"net/http"
+	"net/url"
+	"path/filepath"
 	"slices"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"fastcat.org/go/gdev/addons/pm/api"
@@ -21,18 +24,52 @@ type daemon struct {
 	onTerminate context.CancelFunc
 	tasks       []Task
 	isolator    sys.Isolator
+	events      eventBus
+	// stops the dependency watcher, see [daemon.watchDeps]
+	stopDeps context.CancelFunc
+
+	// if set, where the daemon persists its state, see [savedState]
+	stateDir string
+	saves    chan struct{}
+	saveMu   sync.Mutex
+	// set when the daemon is done with its children, no more state changes
+	// should be saved
+	saveStopped bool
+	detaching   atomic.Bool
 }
 
 func NewDaemon(tasks ...Task) (*daemon, error) {
+	return newDaemon(api.StateDir(), tasks...)
+}
+
+func newDaemon(stateDir string, tasks ...Task) (*daemon, error) {
 	isolator, err := sys.GetIsolator()
 	if err != nil {
 		return nil, err
 	}
-	return &daemon{
+	d := &daemon{
 		children: make(map[string]*child),
 		tasks:    slices.Clone(tasks),
 		isolator: isolator,
-	}, nil
+		stateDir: stateDir,
+	}
+	// subscribe to dependency changes before any children are added, but don't
+	// check them until all the saved children are restored, so re-adopted
+	// children aren't seen to have lost dependencies that just aren't back yet
+	var depsCtx context.Context
+	depsCtx, d.stopDeps = context.WithCancel(context.Background())
+	depsSub := d.events.add(api.EventsOptions{})
+	if stateDir != "" {
+		d.saves = make(chan struct{}, 1)
+		go d.saver()
+		if err := d.restore(); err != nil {
+			d.stopDeps()
+			d.events.remove(depsSub)
+			return nil, err
+		}
+	}
+	go d.watchDeps(depsCtx, depsSub)
+	return d, nil
 }
 
 var _ api.API = (*daemon)(nil)
@@ -57,9 +94,11 @@ func (d *daemon) Child(ctx context.Context, name string) (*api.ChildWithStatus,
 	if c == nil {
 		return nil, internal.WithStatus(http.StatusNotFound, fmt.Errorf("child %s not found", name))
 	}
+	status := c.Status()
+	status.Usage = c.Usage(ctx)
 	return &api.ChildWithStatus{
 		Child:  c.def,
-		Status: c.Status(),
+		Status: status,
 	}, nil
 }
 
@@ -92,20 +131,47 @@ func (d *daemon) PutChild(ctx context.Context, child api.Child) (*api.ChildWithS
 	if _, ok := d.children[child.Name]; ok {
 		return nil, internal.WithStatus(http.StatusConflict, fmt.Errorf("child %s already exists", child.Name))
 	}
-	c := newChild(child, d.isolator)
-	d.children[child.Name] = c
+	if err := checkDepCycle(child, d.children); err != nil {
+		return nil, internal.WithStatus(http.StatusBadRequest, err)
+	}
+	c := d.add(child, nil)
+	return &api.ChildWithStatus{
+		Child:  child,
+		Status: c.Status(),
+	}, nil
+}
+
+// add creates a child and starts its manager, restoring it from saved state if
+// given. The caller must hold the mutex.
+func (d *daemon) add(def api.Child, restore *savedChild) *child {
+	c := newChild(def, d.isolator)
+	if d.stateDir != "" {
+		c.outputDir = filepath.Join(d.stateDir, "output", url.PathEscape(def.Name))
+		c.onChange = d.requestSave
+	}
+	c.restore = restore
+	c.onEvent = d.events.publish
+	d.children[def.Name] = c
 	go func() {
 		c.run()
 		d.mu.Lock()
-		delete(d.children, child.Name)
+		delete(d.children, def.Name)
 		d.mu.Unlock()
+		d.requestSave()
+		if !d.detaching.Load() {
+			d.events.publish(api.Event{Type: api.EventChildRemoved, Child: def.Name, State: c.Status().State})
+		}
 	}()
 	// ensure the manager goroutine has started
 	c.cmds <- childPing
-	return &api.ChildWithStatus{
-		Child:  child,
-		Status: c.Status(),
-	}, nil
+	s := c.Status()
+	d.events.publish(api.Event{
+		Type:    api.EventChildAdded,
+		Child:   def.Name,
+		State:   s.State,
+		Healthy: new(s.Health.Healthy),
+	})
+	return c
 }
 
 // StartChild implements api.API.
@@ -116,9 +182,9 @@ func (d *daemon) StartChild(ctx context.Context, name string) (*api.ChildWithSta
 	}
 	s := c.Status()
 	switch s.State {
-	case api.ChildError, api.ChildInitError, api.ChildStopped:
-		// ok
-	case api.ChildInitRunning, api.ChildRunning:
+	case api.ChildError, api.ChildInitError, api.ChildStopped, api.ChildCrashLooping:
+		// ok, starting clears any restart backoff
+	case api.ChildWaiting, api.ChildInitRunning, api.ChildRunning:
 		return nil, internal.WithStatus(
 			http.StatusPreconditionFailed,
 			fmt.Errorf("child %s already running (%s)", name, s.State),
@@ -145,7 +211,7 @@ func (d *daemon) StopChild(ctx context.Context, name string) (*api.ChildWithStat
 	switch s.State {
 	case api.ChildInitRunning, api.ChildRunning:
 		// ok
-	case api.ChildError, api.ChildInitError:
+	case api.ChildError, api.ChildInitError, api.ChildCrashLooping, api.ChildWaiting:
 		// also ok
 	case api.ChildStopping, api.ChildStopped:
 		// already stopping or stopped, just sync / wait for it to finish stopping
@@ -180,6 +246,41 @@ func (d *daemon) StopChild(ctx context.Context, name string) (*api.ChildWithStat
 	return &api.ChildWithStatus{Child: c.def, Status: c.Status()}, nil
 }
 
+// SignalChild implements api.API.
+func (d *daemon) SignalChild(ctx context.Context, name, signal string) (*api.ChildWithStatus, error) {
+	c := d.child(name)
+	if c == nil {
+		return nil, internal.WithStatus(http.StatusNotFound, fmt.Errorf("child %s not found", name))
+	}
+	sig, err := internal.ParseSignal(signal)
+	if err != nil {
+		return nil, internal.WithStatus(http.StatusBadRequest, err)
+	}
+	if err := c.signal(ctx, sig); err != nil {
+		return nil, err
+	}
+	return &api.ChildWithStatus{Child: c.def, Status: c.Status()}, nil
+}
+
+// ChildLogs implements api.API.
+func (d *daemon) ChildLogs(
+	ctx context.Context,
+	name string,
+	opts api.LogsOptions,
+	fn func(api.LogLine) error,
+) error {
+	c := d.child(name)
+	if c == nil {
+		return internal.WithStatus(http.StatusNotFound, fmt.Errorf("child %s not found", name))
+	}
+	return c.Logs(ctx, opts, fn)
+}
+
+// Events implements api.API.
+func (d *daemon) Events(ctx context.Context, opts api.EventsOptions, fn func(api.Event) error) error {
+	return d.events.subscribe(ctx, opts, fn)
+}
+
 // Summary implements api.API.
 func (d *daemon) Summary(ctx context.Context) ([]api.ChildSummary, error) {
 	d.mu.Lock()
@@ -201,6 +302,8 @@ func (d *daemon) Summary(ctx context.Context) ([]api.ChildSummary, error) {
 			Annotations: maps.Clone(child.def.Annotations),
 			State:       status.State,
 			Pid:         pid,
+			Restarts:    status.Restarts,
+			LastExit:    status.LastExit,
 		}
 		if status.Health.LastHealthy != nil || status.Health.LastUnhealthy != nil {
 			cs.Healthy = new(status.Health.Healthy)
@@ -215,9 +318,12 @@ func (d *daemon) Terminate(context.Context) error {
 	if d.onTerminate != nil {
 		d.onTerminate()
 	}
+	// don't start or restart anything while we're stopping it all
+	d.stopDeps()
 	d.mu.Lock()
 	if len(d.children) == 0 {
 		d.mu.Unlock()
+		d.stopSaving()
 		return nil
 	}
 	log.Print("terminating pm children")
@@ -248,6 +354,101 @@ func (d *daemon) Terminate(context.Context) error {
 		})
 	}
 	wg.Wait()
+	d.stopSaving()
 	log.Print("daemon done")
 	return nil
 }
+
+// Detach implements api.API.
+func (d *daemon) Detach(context.Context) error {
+	d.detaching.Store(true)
+	if d.onTerminate != nil {
+		d.onTerminate()
+	}
+	return nil
+}
+
+// detach saves the daemon state one last time and stops managing the children,
+// leaving them running for a new daemon to re-adopt.
+func (d *daemon) detach() {
+	d.stopDeps()
+	d.stopSaving()
+
+	d.mu.Lock()
+	children := slices.Collect(maps.Values(d.children))
+	d.mu.Unlock()
+	for _, c := range children {
+		c.cmds <- childDetach
+	}
+	log.Printf("detached from %d pm children", len(children))
+}
+
+// restore re-creates the children from the saved state. Each child will
+// re-adopt its running exec if it's still there.
+func (d *daemon) restore() error {
+	s, err := loadState(d.stateDir)
+	if err != nil {
+		// don't let a bad state file stop the daemon from working at all
+		log.Printf("ERROR: failed to load pm state, starting fresh: %v", err)
+		return nil
+	}
+	if len(s.Children) == 0 {
+		return nil
+	}
+	log.Printf("restoring %d pm children", len(s.Children))
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	for _, sc := range s.Children {
+		if _, ok := d.children[sc.Child.Name]; ok {
+			log.Printf("ignoring duplicate saved child %s", sc.Child.Name)
+			continue
+		}
+		d.add(sc.Child, &sc)
+	}
+	return nil
+}
+
+func (d *daemon) requestSave() {
+	select {
+	case d.saves <- struct{}{}:
+	default:
+		// a save is already pending
+	}
+}
+
+func (d *daemon) saver() {
+	for range d.saves {
+		d.saveMu.Lock()
+		if !d.saveStopped {
+			d.saveState()
+		}
+		d.saveMu.Unlock()
+	}
+}
+
+// stopSaving saves the state one last time and disables any further saves.
+func (d *daemon) stopSaving() {
+	if d.stateDir == "" {
+		return
+	}
+	d.saveMu.Lock()
+	defer d.saveMu.Unlock()
+	d.saveStopped = true
+	d.saveState()
+}
+
+// saveState writes the state of all the children. The caller must hold the save
+// mutex.
+func (d *daemon) saveState() {
+	var s savedState
+	d.mu.Lock()
+	for _, c := range d.children {
+		if sc := c.saved.Load(); sc != nil {
+			s.Children = append(s.Children, *sc)
+		}
+	}
+	d.mu.Unlock()
+	if err := writeState(d.stateDir, &s); err != nil {
+		log.Printf("ERROR: failed to save pm state: %v", err)
+	}
+}
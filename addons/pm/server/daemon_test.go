@@ -0,0 +1,168 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"fastcat.org/go/gdev/addons/pm/api"
+)
+
+var errGotTick = errors.New("got tick")
+
+func TestDaemonReadopt(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow() // does not return
+	}
+
+	td := t.TempDir()
+	def := api.Child{
+		Name: "ticker",
+		Main: api.Exec{
+			Cmd:  "sh",
+			Args: []string{"-c", "while true; do echo tick; sleep 0.05; done"},
+		},
+	}
+	waitTick := func(t *testing.T, d *daemon) {
+		t.Helper()
+		ctx, cancel := context.WithTimeout(t.Context(), 5*time.Second)
+		defer cancel()
+		err := d.ChildLogs(ctx, def.Name, api.LogsOptions{Follow: true, Tail: 0}, func(l api.LogLine) error {
+			if l.Text == "tick" {
+				return errGotTick
+			}
+			return nil
+		})
+		require.ErrorIs(t, err, errGotTick)
+	}
+
+	d1, err := newDaemon(td)
+	require.NoError(t, err)
+	_, err = d1.PutChild(t.Context(), def)
+	require.NoError(t, err)
+	stat, err := d1.StartChild(t.Context(), def.Name)
+	require.NoError(t, err)
+	require.Equal(t, api.ChildRunning, stat.Status.State)
+	pid := stat.Status.Main.Pid
+	t.Cleanup(func() { _ = syscall.Kill(-pid, syscall.SIGKILL) })
+	waitTick(t, d1)
+	d1.detach()
+
+	t.Run("readopt", func(t *testing.T) {
+		d2, err := newDaemon(td)
+		require.NoError(t, err)
+		stat, err := d2.Child(t.Context(), def.Name)
+		require.NoError(t, err)
+		assert.Equal(t, api.ChildRunning, stat.Status.State)
+		assert.Equal(t, pid, stat.Status.Main.Pid)
+		// make sure it's still alive and we're reading its output
+		waitTick(t, d2)
+		d2.detach()
+	})
+
+	t.Run("lost", func(t *testing.T) {
+		require.NoError(t, syscall.Kill(-pid, syscall.SIGKILL))
+		require.Eventually(t, func() bool {
+			_, err := procStartTime(pid)
+			return err != nil
+		}, 5*time.Second, 10*time.Millisecond)
+
+		d3, err := newDaemon(td)
+		require.NoError(t, err)
+		t.Cleanup(func() { assert.NoError(t, d3.Terminate(context.Background())) })
+		stat, err := d3.Child(t.Context(), def.Name)
+		require.NoError(t, err)
+		// it should be waiting to restart
+		assert.Equal(t, api.ChildError, stat.Status.State)
+		assert.Equal(t, "main exited while pm was not running", stat.Status.LastExit)
+		assert.Zero(t, stat.Status.Main.Pid)
+	})
+}
+
+func TestDaemonReadoptDeps(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow() // does not return
+	}
+
+	td := t.TempDir()
+	sleeper := api.Exec{Cmd: "sleep", Args: []string{"1h"}}
+	d, err := newDaemon(td)
+	require.NoError(t, err)
+	_, err = d.PutChild(t.Context(), api.Child{Name: "db", Main: sleeper})
+	require.NoError(t, err)
+	_, err = d.PutChild(t.Context(), api.Child{
+		Name:      "app",
+		Main:      sleeper,
+		DependsOn: []api.Dependency{{Child: "db"}},
+	})
+	require.NoError(t, err)
+	_, err = d.StartChild(t.Context(), "app")
+	require.NoError(t, err)
+	stat, err := d.StartChild(t.Context(), "db")
+	require.NoError(t, err)
+	dbPid := stat.Status.Main.Pid
+	t.Cleanup(func() { _ = syscall.Kill(-dbPid, syscall.SIGKILL) })
+	require.EventuallyWithT(t, func(c *assert.CollectT) {
+		stat, err = d.Child(t.Context(), "app")
+		require.NoError(c, err)
+		assert.Equal(c, api.ChildRunning, stat.Status.State)
+	}, 5*time.Second, 10*time.Millisecond)
+	appPid := stat.Status.Main.Pid
+	t.Cleanup(func() { _ = syscall.Kill(-appPid, syscall.SIGKILL) })
+
+	// the children are saved and restored in no particular order, so a dependent
+	// may come back before its dependency, try a few times to catch that
+	for i := range 10 {
+		d.detach()
+		d, err = newDaemon(td)
+		require.NoError(t, err)
+		assert.Never(t, func() bool {
+			stat, err := d.Child(t.Context(), "app")
+			return err != nil || stat.Status.State != api.ChildRunning || stat.Status.Main.Pid != appPid
+		}, 100*time.Millisecond, 10*time.Millisecond, i)
+	}
+	assert.NoError(t, d.Terminate(context.Background()))
+}
+
+func TestDaemonReadoptUnknownExit(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow() // does not return
+	}
+
+	td := t.TempDir()
+	brief := api.Exec{Cmd: "sleep", Args: []string{"0.5"}}
+	oneShot := api.Child{Name: "once", Main: brief, OneShot: true}
+	withInit := api.Child{
+		Name: "init",
+		Init: []api.Exec{brief},
+		Main: api.Exec{Cmd: "sleep", Args: []string{"1h"}},
+	}
+	d1, err := newDaemon(td)
+	require.NoError(t, err)
+	for _, def := range []api.Child{oneShot, withInit} {
+		_, err = d1.PutChild(t.Context(), def)
+		require.NoError(t, err)
+		_, err = d1.StartChild(t.Context(), def.Name)
+		require.NoError(t, err)
+	}
+	d1.detach()
+
+	// the execs finish while they are adopted, so their exit status is unknown,
+	// which must not be treated as a failure
+	d2, err := newDaemon(td)
+	require.NoError(t, err)
+	t.Cleanup(func() { assert.NoError(t, d2.Terminate(context.Background())) })
+	for name, want := range map[string]api.ChildState{"once": api.ChildDone, "init": api.ChildRunning} {
+		require.EventuallyWithT(t, func(c *assert.CollectT) {
+			stat, err := d2.Child(t.Context(), name)
+			require.NoError(c, err)
+			assert.Equal(c, want, stat.Status.State)
+			assert.Zero(c, stat.Status.Restarts)
+		}, 5*time.Second, 10*time.Millisecond, name)
+	}
+}
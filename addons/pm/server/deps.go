@@ -0,0 +1,170 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"maps"
+	"slices"
+	"strings"
+
+	"fastcat.org/go/gdev/addons/pm/api"
+)
+
+// depState is what the dependency watcher knows about a child, from its events.
+type depState struct {
+	state   api.ChildState
+	healthy bool
+}
+
+// depSatisfied checks if a dependency in the given state meets the condition.
+func depSatisfied(cond api.DependencyCondition, dep api.Child, st depState) bool {
+	switch cond {
+	case api.DependencyCompleted:
+		return st.state == api.ChildDone
+	case api.DependencyHealthy:
+		// one-shots aren't healthy until they are done
+		return st.state == api.ChildDone ||
+			st.state == api.ChildRunning && !dep.OneShot && (dep.HealthCheck == nil || st.healthy)
+	default:
+		return st.state == api.ChildRunning || st.state == api.ChildDone
+	}
+}
+
+// unmetDeps describes the dependencies of def that aren't satisfied.
+func unmetDeps(def api.Child, children map[string]*child, states map[string]depState) []string {
+	var unmet []string
+	for _, dep := range def.DependsOn {
+		cond := dep.Condition
+		if cond == "" {
+			cond = api.DependencyStarted
+		}
+		if c := children[dep.Child]; c == nil {
+			unmet = append(unmet, dep.Child+" (missing)")
+		} else if !depSatisfied(cond, c.def, states[dep.Child]) {
+			unmet = append(unmet, dep.Child+" ("+string(cond)+")")
+		}
+	}
+	return unmet
+}
+
+// needsDeps checks if a child in the given state should go back to waiting if
+// its dependencies aren't met.
+func needsDeps(state api.ChildState) bool {
+	switch state {
+	case api.ChildInitRunning, api.ChildRunning, api.ChildInitError, api.ChildError:
+		return true
+	default:
+		return false
+	}
+}
+
+// depCycle finds the cycle adding def would create in the dependencies, if any,
+// as the path of child names around it. The existing children can't have a
+// cycle, so it must go through def.
+func depCycle(def api.Child, children map[string]*child) []string {
+	seen := make(map[string]bool)
+	var find func(path []string, deps []api.Dependency) []string
+	find = func(path []string, deps []api.Dependency) []string {
+		for _, dep := range deps {
+			p := append(slices.Clip(path), dep.Child)
+			if dep.Child == def.Name {
+				return p
+			} else if seen[dep.Child] {
+				continue
+			}
+			seen[dep.Child] = true
+			if c := children[dep.Child]; c != nil {
+				if cycle := find(p, c.def.DependsOn); cycle != nil {
+					return cycle
+				}
+			}
+		}
+		return nil
+	}
+	return find([]string{def.Name}, def.DependsOn)
+}
+
+func checkDepCycle(def api.Child, children map[string]*child) error {
+	if cycle := depCycle(def, children); cycle != nil {
+		return fmt.Errorf("dependency cycle: %s", strings.Join(cycle, " -> "))
+	}
+	return nil
+}
+
+// watchDeps starts waiting children once their dependencies are met, and sends
+// running ones back to waiting when they no longer are, based on the events
+// from all the children. s must be added before any children so none of their
+// events are missed. The states of any children that already exist, such as
+// restored ones, are loaded first, as their events may not say anything about
+// their dependencies.
+func (d *daemon) watchDeps(ctx context.Context, s *eventSub) {
+	states := d.depStates()
+	d.checkDeps(ctx, states, "")
+	for {
+		err := s.run(ctx, func(e api.Event) error {
+			if e.Type == api.EventChildRemoved {
+				delete(states, e.Child)
+			} else {
+				st := states[e.Child]
+				st.state = e.State
+				if e.Healthy != nil {
+					st.healthy = *e.Healthy
+				}
+				states[e.Child] = st
+			}
+			d.checkDeps(ctx, states, e.Child)
+			return nil
+		})
+		d.events.remove(s)
+		if !errors.Is(err, errEventsOverflow) {
+			return
+		}
+		log.Print("pm dependency watcher fell behind, resyncing")
+		s = d.events.add(api.EventsOptions{})
+		states = d.depStates()
+		d.checkDeps(ctx, states, "")
+	}
+}
+
+// depStates gets the current states of all the children.
+func (d *daemon) depStates() map[string]depState {
+	d.mu.Lock()
+	children := slices.Collect(maps.Values(d.children))
+	d.mu.Unlock()
+	states := make(map[string]depState, len(children))
+	for _, c := range children {
+		status := c.Status()
+		states[c.def.Name] = depState{state: status.State, healthy: status.Health.Healthy}
+	}
+	return states
+}
+
+// checkDeps tells children whose dependencies have changed about it. If changed
+// is set, only that child and those that depend on it are checked, otherwise
+// all of them are.
+func (d *daemon) checkDeps(ctx context.Context, states map[string]depState, changed string) {
+	d.mu.Lock()
+	children := maps.Clone(d.children)
+	d.mu.Unlock()
+	for name, c := range children {
+		if len(c.def.DependsOn) == 0 {
+			continue
+		} else if changed != "" && name != changed && !slices.ContainsFunc(
+			c.def.DependsOn,
+			func(dep api.Dependency) bool { return dep.Child == changed },
+		) {
+			continue
+		}
+		// the child will ignore these if its real state doesn't match, e.g. if it
+		// was stopped and we haven't seen the event yet
+		unmet := unmetDeps(c.def, children, states)
+		if st := states[name].state; st == api.ChildWaiting && len(unmet) == 0 {
+			c.send(ctx, childDepsReady)
+		} else if len(unmet) != 0 && needsDeps(st) {
+			log.Printf("child %s dependencies are no longer met: %s", name, strings.Join(unmet, ", "))
+			c.send(ctx, childDepsLost)
+		}
+	}
+}
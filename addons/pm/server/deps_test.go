@@ -0,0 +1,133 @@
+package server
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"fastcat.org/go/gdev/addons/pm/api"
+)
+
+func TestDepSatisfied(t *testing.T) {
+	svc := api.Child{Name: "svc"}
+	checked := api.Child{Name: "checked", HealthCheck: &api.HealthCheck{}}
+	oneShot := api.Child{Name: "job", OneShot: true}
+	running := depState{state: api.ChildRunning}
+	healthy := depState{state: api.ChildRunning, healthy: true}
+	done := depState{state: api.ChildDone}
+	for _, tt := range []struct {
+		name string
+		cond api.DependencyCondition
+		dep  api.Child
+		st   depState
+		want bool
+	}{
+		{"started running", api.DependencyStarted, svc, running, true},
+		{"started stopped", api.DependencyStarted, svc, depState{state: api.ChildStopped}, false},
+		{"started error", api.DependencyStarted, svc, depState{state: api.ChildError}, false},
+		{"started one-shot done", api.DependencyStarted, oneShot, done, true},
+		{"healthy no check", api.DependencyHealthy, svc, running, true},
+		{"healthy unchecked", api.DependencyHealthy, checked, running, false},
+		{"healthy checked", api.DependencyHealthy, checked, healthy, true},
+		{"healthy one-shot running", api.DependencyHealthy, oneShot, running, false},
+		{"healthy one-shot done", api.DependencyHealthy, oneShot, done, true},
+		{"completed running", api.DependencyCompleted, oneShot, running, false},
+		{"completed done", api.DependencyCompleted, oneShot, done, true},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, depSatisfied(tt.cond, tt.dep, tt.st))
+		})
+	}
+}
+
+func TestDepCycle(t *testing.T) {
+	deps := func(names ...string) []api.Dependency {
+		var ret []api.Dependency
+		for _, n := range names {
+			ret = append(ret, api.Dependency{Child: n})
+		}
+		return ret
+	}
+	children := map[string]*child{}
+	for _, def := range []api.Child{
+		{Name: "a", DependsOn: deps("b")},
+		{Name: "b", DependsOn: deps("c", "d")},
+		{Name: "c"},
+		{Name: "d", DependsOn: deps("e")},
+	} {
+		children[def.Name] = newChild(def, nil)
+	}
+	assert.Nil(t, depCycle(api.Child{Name: "x", DependsOn: deps("a", "c")}, children))
+	assert.Nil(t, depCycle(api.Child{Name: "e", DependsOn: deps("c", "missing")}, children))
+	assert.Equal(t,
+		[]string{"e", "a", "b", "d", "e"},
+		depCycle(api.Child{Name: "e", DependsOn: deps("a")}, children))
+	assert.Equal(t, []string{"x", "x"}, depCycle(api.Child{Name: "x", DependsOn: deps("x")}, children))
+}
+
+func TestDaemonDeps(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow() // does not return
+	}
+
+	d, err := newDaemon("")
+	require.NoError(t, err)
+	t.Cleanup(func() { assert.NoError(t, d.Terminate(context.Background())) })
+
+	sleeper := api.Exec{Cmd: "sleep", Args: []string{"1h"}}
+	waitState := func(t *testing.T, name string, want api.ChildState) {
+		t.Helper()
+		require.EventuallyWithT(t, func(c *assert.CollectT) {
+			stat, err := d.Child(t.Context(), name)
+			require.NoError(c, err)
+			assert.Equal(c, want, stat.Status.State)
+		}, 5*time.Second, 10*time.Millisecond)
+	}
+
+	// the dependency doesn't need to exist yet
+	_, err = d.PutChild(t.Context(), api.Child{
+		Name:      "app",
+		Main:      sleeper,
+		DependsOn: []api.Dependency{{Child: "db"}},
+	})
+	require.NoError(t, err)
+	stat, err := d.StartChild(t.Context(), "app")
+	require.NoError(t, err)
+	assert.Equal(t, api.ChildWaiting, stat.Status.State)
+
+	// adding the dependency can't make a cycle
+	_, err = d.PutChild(t.Context(), api.Child{
+		Name:      "db",
+		Main:      sleeper,
+		DependsOn: []api.Dependency{{Child: "app"}},
+	})
+	assert.Equal(t, http.StatusBadRequest, statusCode(err))
+	assert.ErrorContains(t, err, "db -> app -> db")
+
+	_, err = d.PutChild(t.Context(), api.Child{Name: "db", Main: sleeper})
+	require.NoError(t, err)
+	_, err = d.StartChild(t.Context(), "db")
+	require.NoError(t, err)
+	waitState(t, "app", api.ChildRunning)
+
+	// restarting the dependency restarts the dependent
+	_, err = d.StopChild(t.Context(), "db")
+	require.NoError(t, err)
+	waitState(t, "app", api.ChildWaiting)
+	_, err = d.StartChild(t.Context(), "db")
+	require.NoError(t, err)
+	waitState(t, "app", api.ChildRunning)
+
+	// an explicit stop doesn't wait for the dependencies again
+	_, err = d.StopChild(t.Context(), "app")
+	require.NoError(t, err)
+	_, err = d.StopChild(t.Context(), "db")
+	require.NoError(t, err)
+	stat, err = d.Child(t.Context(), "app")
+	require.NoError(t, err)
+	assert.Equal(t, api.ChildStopped, stat.Status.State)
+}
@@ -0,0 +1,147 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"sync"
+	"time"
+
+	"fastcat.org/go/gdev/addons/pm/api"
+)
+
+// eventBufferSize is how many events a subscriber may fall behind by before it
+// is dropped.
+const eventBufferSize = 256
+
+var errEventsOverflow = errors.New("fell too far behind on pm events")
+
+// eventBus fans out events to subscribers, in order. The zero value is ready to
+// use.
+type eventBus struct {
+	mu   sync.Mutex
+	seq  uint64
+	subs map[*eventSub]struct{}
+}
+
+type eventSub struct {
+	ch       chan api.Event
+	children []string
+}
+
+func (b *eventBus) publish(e api.Event) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.seq++
+	e.Seq = b.seq
+	if e.Time.IsZero() {
+		e.Time = time.Now()
+	}
+	for s := range b.subs {
+		if len(s.children) != 0 && !slices.Contains(s.children, e.Child) {
+			continue
+		}
+		select {
+		case s.ch <- e:
+		default:
+			// never block publishing on a slow subscriber, drop it instead so it
+			// doesn't silently miss events
+			close(s.ch)
+			delete(b.subs, s)
+		}
+	}
+}
+
+// subscribe calls fn for each event published until ctx is done or fn returns
+// an error.
+func (b *eventBus) subscribe(ctx context.Context, opts api.EventsOptions, fn func(api.Event) error) error {
+	s := b.add(opts)
+	defer b.remove(s)
+	return s.run(ctx, fn)
+}
+
+// add registers a subscriber, which will receive all events published from now
+// on. It must be removed when done.
+func (b *eventBus) add(opts api.EventsOptions) *eventSub {
+	s := &eventSub{
+		ch:       make(chan api.Event, eventBufferSize),
+		children: opts.Children,
+	}
+	b.mu.Lock()
+	if b.subs == nil {
+		b.subs = make(map[*eventSub]struct{})
+	}
+	b.subs[s] = struct{}{}
+	b.mu.Unlock()
+	return s
+}
+
+func (b *eventBus) remove(s *eventSub) {
+	b.mu.Lock()
+	delete(b.subs, s)
+	b.mu.Unlock()
+}
+
+// run calls fn for each event received until ctx is done or fn returns an
+// error. It returns errEventsOverflow if the subscriber was dropped for falling
+// behind.
+func (s *eventSub) run(ctx context.Context, fn func(api.Event) error) error {
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case e, ok := <-s.ch:
+			if !ok {
+				return errEventsOverflow
+			}
+			if err := fn(e); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// eventState is the part of the child status we publish changes to.
+type eventState struct {
+	state    api.ChildState
+	healthy  bool
+	restarts int
+}
+
+func getEventState(s api.ChildStatus) eventState {
+	return eventState{
+		state:    s.State,
+		healthy:  s.Health.Healthy,
+		restarts: s.Restarts,
+	}
+}
+
+// statusEvents works out the events for the changes from before to status,
+// adding them to any already pending.
+func statusEvents(name string, pending []api.Event, before eventState, status api.ChildStatus) []api.Event {
+	if status.Restarts > before.restarts {
+		pending = append(pending, api.Event{
+			Type:     api.EventRestart,
+			Restarts: status.Restarts,
+		})
+	}
+	if status.State != before.state {
+		pending = append(pending, api.Event{
+			Type:      api.EventState,
+			PrevState: before.state,
+			Message:   string(before.state) + " -> " + string(status.State),
+		})
+	}
+	if status.Health.Healthy != before.healthy {
+		e := api.Event{Type: api.EventHealth, Healthy: new(status.Health.Healthy), Message: "unhealthy"}
+		if status.Health.Healthy {
+			e.Message = "healthy"
+		}
+		pending = append(pending, e)
+	}
+	for i := range pending {
+		pending[i].Child = name
+		pending[i].State = status.State
+	}
+	return pending
+}
@@ -0,0 +1,173 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"fastcat.org/go/gdev/addons/pm/api"
+)
+
+func TestStatusEvents(t *testing.T) {
+	before := eventState{state: api.ChildError, restarts: 1}
+	status := api.ChildStatus{
+		State:    api.ChildRunning,
+		Restarts: 2,
+		Health:   api.HealthStatus{Healthy: true},
+	}
+	pending := []api.Event{{Type: api.EventExecExited, Exec: api.ExecMain, ExitCode: new(1)}}
+	events := statusEvents("svc", pending, before, status)
+	require.Len(t, events, 4)
+	types := []api.EventType{api.EventExecExited, api.EventRestart, api.EventState, api.EventHealth}
+	for i, typ := range types {
+		assert.Equal(t, typ, events[i].Type)
+		assert.Equal(t, "svc", events[i].Child)
+		assert.Equal(t, api.ChildRunning, events[i].State)
+	}
+	assert.Equal(t, 2, events[1].Restarts)
+	assert.Equal(t, api.ChildError, events[2].PrevState)
+	assert.Equal(t, new(true), events[3].Healthy)
+
+	assert.Empty(t, statusEvents("svc", nil, getEventState(status), status))
+}
+
+func TestEventBus(t *testing.T) {
+	var b eventBus
+	errDone := errors.New("done")
+
+	t.Run("filtered in order", func(t *testing.T) {
+		var got []api.Event
+		errCh := make(chan error, 1)
+		go func() {
+			errCh <- b.subscribe(t.Context(), api.EventsOptions{Children: []string{"a"}}, func(e api.Event) error {
+				got = append(got, e)
+				if len(got) == 2 {
+					return errDone
+				}
+				return nil
+			})
+		}()
+		waitSubscribed(t, &b, 1)
+		b.publish(api.Event{Child: "a", Type: api.EventChildAdded})
+		b.publish(api.Event{Child: "b", Type: api.EventChildAdded})
+		b.publish(api.Event{Child: "a", Type: api.EventState})
+		require.ErrorIs(t, <-errCh, errDone)
+		require.Len(t, got, 2)
+		assert.Equal(t, api.EventChildAdded, got[0].Type)
+		assert.Equal(t, api.EventState, got[1].Type)
+		assert.Less(t, got[0].Seq, got[1].Seq)
+		assert.False(t, got[0].Time.IsZero())
+	})
+
+	t.Run("overflow", func(t *testing.T) {
+		block := make(chan struct{})
+		errCh := make(chan error, 1)
+		go func() {
+			errCh <- b.subscribe(t.Context(), api.EventsOptions{}, func(api.Event) error {
+				<-block
+				return nil
+			})
+		}()
+		waitSubscribed(t, &b, 1)
+		for range eventBufferSize + 2 {
+			b.publish(api.Event{Child: "a"})
+		}
+		close(block)
+		assert.ErrorIs(t, <-errCh, errEventsOverflow)
+	})
+}
+
+func TestDaemonEvents(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow() // does not return
+	}
+
+	d, err := newDaemon("")
+	require.NoError(t, err)
+	t.Cleanup(func() { assert.NoError(t, d.Terminate(context.Background())) })
+
+	ctx, cancel := context.WithTimeout(t.Context(), 5*time.Second)
+	defer cancel()
+	events := make(chan api.Event, eventBufferSize)
+	go func() {
+		_ = d.Events(ctx, api.EventsOptions{}, func(e api.Event) error {
+			events <- e
+			return nil
+		})
+	}()
+	// the dependency watcher is always subscribed
+	waitSubscribed(t, &d.events, 2)
+
+	def := api.Child{
+		Name:    "events",
+		Main:    api.Exec{Cmd: "true"},
+		OneShot: true,
+	}
+	_, err = d.PutChild(t.Context(), def)
+	require.NoError(t, err)
+	_, err = d.StartChild(t.Context(), def.Name)
+	require.NoError(t, err)
+
+	var got []string
+	for e := range events {
+		desc := string(e.Type)
+		if e.Type == api.EventState {
+			desc += " " + string(e.State)
+		}
+		got = append(got, desc)
+		if e.Type == api.EventState && e.State == api.ChildDone {
+			break
+		}
+	}
+	assert.Equal(t, []string{"child-added", "state running", "exec-exited", "state done"}, got)
+}
+
+func waitSubscribed(t *testing.T, b *eventBus, n int) {
+	t.Helper()
+	require.Eventually(t, func() bool {
+		b.mu.Lock()
+		defer b.mu.Unlock()
+		return len(b.subs) == n
+	}, time.Second, time.Millisecond)
+}
+
+func TestHTTPShutdownEndsEvents(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow() // does not return
+	}
+
+	d, err := newDaemon("")
+	require.NoError(t, err)
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	h := &HTTP{Server: &http.Server{Handler: NewHTTPMux(d)}, Listener: l, daemon: d}
+	ctx, cancel := context.WithCancel(t.Context())
+	defer cancel()
+	runErr := make(chan error, 1)
+	go func() { runErr <- h.Run(ctx) }()
+
+	req, err := http.NewRequestWithContext(t.Context(), http.MethodGet,
+		"http://"+l.Addr().String()+api.PathEvents, nil)
+	require.NoError(t, err)
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	defer resp.Body.Close() //nolint:errcheck
+	waitSubscribed(t, &d.events, 2)
+
+	start := time.Now()
+	cancel()
+	select {
+	case err := <-runErr:
+		assert.NoError(t, err)
+		// the shutdown timeout is 5s
+		assert.Less(t, time.Since(start), 2*time.Second)
+	case <-time.After(10 * time.Second):
+		t.Fatal("server did not stop")
+	}
+}
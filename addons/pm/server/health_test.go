@@ -0,0 +1,106 @@
+package server
+
+import (
+	"io"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+
+	"fastcat.org/go/gdev/addons/pm/api"
+	"fastcat.org/go/gdev/addons/pm/internal"
+	"fastcat.org/go/gdev/lib/sys"
+)
+
+func listenLocal(t *testing.T) (net.Listener, int) {
+	l, err := net.Listen("tcp", "localhost:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = l.Close() })
+	return l, l.Addr().(*net.TCPAddr).Port
+}
+
+func TestChildHealthChecks(t *testing.T) {
+	isolator, err := sys.GetIsolator()
+	require.NoError(t, err)
+	c := newChild(api.Child{
+		Name: "health",
+		Main: api.Exec{Cmd: "true", Env: map[string]string{"FROM_MAIN": "x"}},
+	}, isolator)
+	check := func(hc *api.HealthCheck) bool {
+		return c.checkHealth(hc, "", time.Second)
+	}
+
+	t.Run("tcp", func(t *testing.T) {
+		l, port := listenLocal(t)
+		assert.True(t, check(&api.HealthCheck{Tcp: &api.TcpHealthCheck{Port: port}}))
+		require.NoError(t, l.Close())
+		assert.False(t, check(&api.HealthCheck{Tcp: &api.TcpHealthCheck{Port: port}}))
+	})
+
+	t.Run("exec", func(t *testing.T) {
+		sh := func(script string) *api.HealthCheck {
+			return &api.HealthCheck{Exec: &api.ExecHealthCheck{
+				Cmd:  "sh",
+				Args: []string{"-c", script},
+				Env:  map[string]string{"FROM_CHECK": "y"},
+			}}
+		}
+		assert.True(t, check(sh(`test "$FROM_MAIN$FROM_CHECK" = xy`)))
+		assert.False(t, check(sh("exit 1")))
+		// timeout
+		assert.False(t, c.checkHealth(sh("sleep 10"), "", 50*time.Millisecond))
+	})
+
+	t.Run("grpc", func(t *testing.T) {
+		l, port := listenLocal(t)
+		s := grpc.NewServer()
+		hs := health.NewServer()
+		healthpb.RegisterHealthServer(s, hs)
+		go func() { _ = s.Serve(l) }()
+		t.Cleanup(s.Stop)
+
+		hs.SetServingStatus("svc", healthpb.HealthCheckResponse_SERVING)
+		assert.True(t, check(&api.HealthCheck{Grpc: &api.GrpcHealthCheck{Port: port}}))
+		assert.True(t, check(&api.HealthCheck{Grpc: &api.GrpcHealthCheck{Port: port, Service: "svc"}}))
+		hs.SetServingStatus("svc", healthpb.HealthCheckResponse_NOT_SERVING)
+		assert.False(t, check(&api.HealthCheck{Grpc: &api.GrpcHealthCheck{Port: port, Service: "svc"}}))
+		assert.False(t, check(&api.HealthCheck{Grpc: &api.GrpcHealthCheck{Port: port, Service: "other"}}))
+	})
+}
+
+func TestHealthCheckValidation(t *testing.T) {
+	for _, tt := range []struct {
+		name  string
+		check string
+		ok    bool
+	}{
+		{"http", `"http":{"port":80,"path":"/","scheme":"http"}`, true},
+		{"tcp", `"tcp":{"port":5432}`, true},
+		{"exec", `"exec":{"cmd":"pg_isready"}`, true},
+		{"grpc", `"grpc":{"port":9000}`, true},
+		{"none", ``, false},
+		{"two", `"tcp":{"port":5432},"grpc":{"port":9000}`, false},
+		{"invalid", `"tcp":{"port":0}`, false},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			body := `{"timeout":1`
+			if tt.check != "" {
+				body += "," + tt.check
+			}
+			body += "}"
+			_, err := internal.JSONBody[api.HealthCheck](
+				t.Context(), io.NopCloser(strings.NewReader(body)), "", false)
+			if tt.ok {
+				assert.NoError(t, err)
+			} else {
+				assert.Error(t, err)
+			}
+		})
+	}
+}
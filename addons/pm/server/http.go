@@ -9,6 +9,7 @@ import (
 	"net"
 	"net/http"
 	"os"
+	"strconv"
 	"strings"
 	"sync"
 	"time"
@@ -56,6 +57,10 @@ func NewHTTP(tasks ...Task) (*HTTP, error) {
 func (h *HTTP) Run(ctx context.Context) error {
 	ctx, shutdown := context.WithCancel(ctx)
 	h.daemon.onTerminate = shutdown
+	// Shutdown doesn't cancel the contexts of requests in progress, so streaming
+	// requests such as events and following logs would hold it up until it times
+	// out, unless their contexts derive from ours
+	h.Server.BaseContext = func(net.Listener) context.Context { return ctx }
 	var wg sync.WaitGroup
 
 	wg.Go(func() {
@@ -77,6 +82,10 @@ func (h *HTTP) Run(ctx context.Context) error {
 	}
 
 	wg.Wait()
+	if h.daemon.detaching.Load() {
+		h.daemon.detach()
+		return err
+	}
 	err2 := h.daemon.Terminate(ctx)
 	return errors.Join(err, err2)
 }
@@ -97,8 +106,12 @@ func NewHTTPMux(impl api.API) *http.ServeMux {
 	reg(http.MethodPut, api.PathChild, w.PutChild)
 	reg(http.MethodPost, api.PathStartChild, w.StartChild)
 	reg(http.MethodPost, api.PathStopChild, w.StopChild)
+	reg(http.MethodPost, api.PathSignalChild, w.SignalChild)
+	reg(http.MethodGet, api.PathChildLogs, w.ChildLogs)
 	reg(http.MethodDelete, api.PathOneChild, w.DeleteChild)
 	reg(http.MethodPost, api.PathTerminate, w.Terminate)
+	reg(http.MethodPost, api.PathDetach, w.Detach)
+	reg(http.MethodGet, api.PathEvents, w.Events)
 	return m
 }
 
@@ -168,6 +181,93 @@ func (h *httpWrapper) StopChild(w http.ResponseWriter, r *http.Request) {
 	h.json(r, w, resp)
 }
 
+func (h *httpWrapper) SignalChild(w http.ResponseWriter, r *http.Request) {
+	name := r.PathValue(api.PathChildParamName)
+	resp, err := h.impl.SignalChild(r.Context(), name, r.URL.Query().Get(api.QuerySignal))
+	if err != nil {
+		h.error(w, err)
+		return
+	}
+	h.json(r, w, resp)
+}
+
+// ChildLogs streams the log lines as newline-delimited JSON.
+func (h *httpWrapper) ChildLogs(w http.ResponseWriter, r *http.Request) {
+	name := r.PathValue(api.PathChildParamName)
+	q := r.URL.Query()
+	var opts api.LogsOptions
+	var err error
+	badQuery := func(param string, err error) {
+		h.error(w, internal.WithStatus(http.StatusBadRequest, fmt.Errorf("bad %s: %w", param, err)))
+	}
+	if v := q.Get(api.QueryLogsFollow); v != "" {
+		if opts.Follow, err = strconv.ParseBool(v); err != nil {
+			badQuery(api.QueryLogsFollow, err)
+			return
+		}
+	}
+	if v := q.Get(api.QueryLogsInit); v != "" {
+		if opts.Init, err = strconv.ParseBool(v); err != nil {
+			badQuery(api.QueryLogsInit, err)
+			return
+		}
+	}
+	if v := q.Get(api.QueryLogsSince); v != "" {
+		if opts.Since, err = time.Parse(time.RFC3339Nano, v); err != nil {
+			badQuery(api.QueryLogsSince, err)
+			return
+		}
+	}
+	if v := q.Get(api.QueryLogsTail); v != "" {
+		if opts.Tail, err = strconv.Atoi(v); err != nil {
+			badQuery(api.QueryLogsTail, err)
+			return
+		}
+	}
+
+	// the status can't be changed once we start streaming, so check the child
+	// exists first
+	if _, err := h.impl.Child(r.Context(), name); err != nil {
+		h.error(w, err)
+		return
+	}
+	w.Header().Set("content-type", "application/x-ndjson")
+	w.WriteHeader(http.StatusOK)
+	rc := http.NewResponseController(w)
+	_ = rc.Flush()
+	e := json.NewEncoder(w)
+	if err := h.impl.ChildLogs(r.Context(), name, opts, func(l api.LogLine) error {
+		if err := e.Encode(l); err != nil {
+			return err
+		}
+		if opts.Follow {
+			return rc.Flush()
+		}
+		return nil
+	}); err != nil && r.Context().Err() == nil {
+		log.Printf("failed to write logs for %s: %v", name, err)
+	}
+}
+
+// Events streams the events as newline-delimited JSON.
+func (h *httpWrapper) Events(w http.ResponseWriter, r *http.Request) {
+	opts := api.EventsOptions{Children: r.URL.Query()[api.QueryEventsChild]}
+	w.Header().Set("content-type", "application/x-ndjson")
+	w.WriteHeader(http.StatusOK)
+	rc := http.NewResponseController(w)
+	_ = rc.Flush()
+	e := json.NewEncoder(w)
+	if err := h.impl.Events(r.Context(), opts, func(ev api.Event) error {
+		if err := e.Encode(ev); err != nil {
+			return err
+		}
+		return rc.Flush()
+	}); err != nil && r.Context().Err() == nil {
+		// can't change the status now, the client will see the stream end early
+		log.Printf("failed to write events: %v", err)
+	}
+}
+
 func (h *httpWrapper) DeleteChild(w http.ResponseWriter, r *http.Request) {
 	name := r.PathValue(api.PathChildParamName)
 	resp, err := h.impl.DeleteChild(r.Context(), name)
@@ -187,6 +287,15 @@ func (h *httpWrapper) Terminate(w http.ResponseWriter, r *http.Request) {
 	w.WriteHeader(http.StatusNoContent)
 }
 
+func (h *httpWrapper) Detach(w http.ResponseWriter, r *http.Request) {
+	err := h.impl.Detach(r.Context())
+	if err != nil {
+		h.error(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
 func (h *httpWrapper) error(w http.ResponseWriter, err error) {
 	w.Header().Set("content-type", "text/plain")
 	sc := http.StatusInternalServerError
@@ -0,0 +1,70 @@
+package server
+
+import (
+	"context"
+	"log"
+	"syscall"
+
+	"fastcat.org/go/gdev/addons/pm/api"
+	"fastcat.org/go/gdev/lib/sys"
+)
+
+// execLimits gets the limits that apply to an exec.
+func execLimits(def api.Child, e api.Exec) *api.Limits {
+	if e.Limits != nil {
+		return e.Limits
+	}
+	return def.Limits
+}
+
+func sysLimits(l *api.Limits) *sys.Limits {
+	if l == nil {
+		return nil
+	}
+	return &sys.Limits{
+		MemoryMax: l.MemoryMaxBytes,
+		CPUWeight: uint64(l.CPUWeight),
+		CPUQuota:  l.CPUQuota,
+		PidsMax:   int64(l.PidsMax),
+		IOWeight:  uint64(l.IOWeight),
+	}
+}
+
+// setNice applies the nice level from the limits, if any, to the process. Any
+// children it forks will inherit it.
+func (c *child) setNice(pid int, l *api.Limits) {
+	if l == nil || l.Nice == 0 {
+		return
+	}
+	if err := syscall.Setpriority(syscall.PRIO_PROCESS, pid, l.Nice); err != nil {
+		log.Printf("ERROR: failed to set nice %d for child %s pid %d: %v", l.Nice, c.def.Name, pid, err)
+	}
+}
+
+// Usage gets the current resource usage of the running exec, if any.
+func (c *child) Usage(ctx context.Context) *api.ResourceUsage {
+	status := c.Status()
+	group := status.Main.Group
+	if status.State == api.ChildInitRunning {
+		group = ""
+		for _, i := range status.Init {
+			if i.State == api.ExecRunning {
+				group = i.Group
+				break
+			}
+		}
+	}
+	if group == "" {
+		return nil
+	}
+	u, err := c.isolator.Usage(ctx, group)
+	if err != nil {
+		log.Printf("failed to get resource usage of child %s: %v", c.def.Name, err)
+		return nil
+	}
+	return &api.ResourceUsage{
+		MemoryBytes: u.MemoryBytes,
+		CPUSeconds:  u.CPUTime.Seconds(),
+		Pids:        u.Pids,
+	}
+}
@@ -0,0 +1,87 @@
+package server
+
+import (
+	"io"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"fastcat.org/go/gdev/addons/pm/api"
+	"fastcat.org/go/gdev/addons/pm/internal"
+	"fastcat.org/go/gdev/lib/sys"
+)
+
+func TestLimitsValidation(t *testing.T) {
+	for _, tt := range []struct {
+		name   string
+		limits string
+		ok     bool
+	}{
+		{"empty", `{}`, true},
+		{
+			"all",
+			`{"memoryMaxBytes":536870912,"cpuWeight":50,"cpuQuota":1.5,"pidsMax":100,"ioWeight":10,"nice":5}`,
+			true,
+		},
+		{"tiny memory", `{"memoryMaxBytes":512}`, false},
+		{"negative memory", `{"memoryMaxBytes":-1}`, false},
+		{"tiny quota", `{"cpuQuota":0.001}`, false},
+		{"cpu weight", `{"cpuWeight":10001}`, false},
+		{"io weight", `{"ioWeight":-1}`, false},
+		{"nice", `{"nice":20}`, false},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := internal.JSONBody[api.Limits](
+				t.Context(), io.NopCloser(strings.NewReader(tt.limits)), "", false)
+			if tt.ok {
+				assert.NoError(t, err)
+			} else {
+				assert.Error(t, err)
+			}
+		})
+	}
+}
+
+func TestExecLimits(t *testing.T) {
+	childLimits := &api.Limits{Nice: 5}
+	execOwn := &api.Limits{PidsMax: 10}
+	def := api.Child{Limits: childLimits}
+	assert.Same(t, childLimits, execLimits(def, api.Exec{}))
+	assert.Same(t, execOwn, execLimits(def, api.Exec{Limits: execOwn}))
+	assert.Nil(t, execLimits(api.Child{}, api.Exec{}))
+}
+
+func TestChildNice(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow() // does not return
+	}
+
+	isolator, err := sys.GetIsolator()
+	require.NoError(t, err)
+	c := newChild(api.Child{
+		Name:   "nice",
+		Main:   api.Exec{Cmd: "sleep", Args: []string{"1h"}},
+		Limits: &api.Limits{Nice: 5},
+	}, isolator)
+	t.Cleanup(c.Wait)
+	go c.run()
+	c.cmds <- childStart
+	c.cmds <- childPing
+	t.Cleanup(func() {
+		c.cmds <- childStop
+		for c.Status().State != api.ChildStopped {
+			time.Sleep(10 * time.Millisecond)
+		}
+		c.cmds <- childDelete
+	})
+	s := c.Status()
+	require.Equal(t, api.ChildRunning, s.State)
+	// getpriority returns 20-nice to avoid negative values
+	prio, err := syscall.Getpriority(syscall.PRIO_PROCESS, s.Main.Pid)
+	require.NoError(t, err)
+	assert.Equal(t, 5, 20-prio)
+}
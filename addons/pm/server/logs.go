@@ -0,0 +1,169 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"sync"
+	"time"
+
+	"fastcat.org/go/gdev/addons/pm/api"
+)
+
+const (
+	// defaultLogLines is how many lines of output are kept for each child.
+	defaultLogLines = 1000
+	// maxLogLine is the longest line that is kept whole, longer lines are split.
+	maxLogLine = 16 * 1024
+)
+
+// logBuffer is a ring buffer of the most recent output lines from a child's
+// execs, which readers can follow for new lines.
+type logBuffer struct {
+	mu    sync.Mutex
+	lines []api.LogLine
+	// next is the sequence number of the next line to be added; the buffer holds
+	// lines with sequence numbers in [next-len(lines), next).
+	next uint64
+	// changed is closed and replaced when lines are added or the buffer is
+	// closed.
+	changed chan struct{}
+	closed  bool
+}
+
+func newLogBuffer(size int) *logBuffer {
+	return &logBuffer{
+		lines:   make([]api.LogLine, 0, size),
+		changed: make(chan struct{}),
+	}
+}
+
+func (b *logBuffer) add(line api.LogLine) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.closed {
+		// output can still trickle in from an exec we detached from
+		return
+	}
+	if len(b.lines) < cap(b.lines) {
+		b.lines = append(b.lines, line)
+	} else {
+		b.lines[b.next%uint64(cap(b.lines))] = line
+	}
+	b.next++
+	close(b.changed)
+	b.changed = make(chan struct{})
+}
+
+// close wakes any followers and tells them no more lines will be added.
+func (b *logBuffer) close() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if !b.closed {
+		b.closed = true
+		close(b.changed)
+	}
+}
+
+// since returns the buffered lines from sequence number seq onward, the
+// sequence number to read from next, a channel that will be closed when that
+// changes, and whether the buffer is closed. If seq has already fallen out of
+// the buffer, the lines returned start at the oldest one that is kept.
+func (b *logBuffer) since(seq uint64) (_ []api.LogLine, next uint64, changed <-chan struct{}, closed bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	oldest := b.next - uint64(len(b.lines))
+	seq = max(seq, oldest)
+	ret := make([]api.LogLine, 0, b.next-seq)
+	for i := seq; i < b.next; i++ {
+		ret = append(ret, b.lines[i%uint64(cap(b.lines))])
+	}
+	return ret, b.next, b.changed, b.closed
+}
+
+// follow implements [api.API.ChildLogs] for the buffer.
+func (b *logBuffer) follow(ctx context.Context, opts api.LogsOptions, fn func(api.LogLine) error) error {
+	lines, next, changed, closed := b.since(0)
+	keep := func(l api.LogLine) bool {
+		return (opts.Init || !isInitExec(l.Exec)) && (opts.Since.IsZero() || !l.Time.Before(opts.Since))
+	}
+	var backlog []api.LogLine
+	for _, l := range lines {
+		if keep(l) {
+			backlog = append(backlog, l)
+		}
+	}
+	if opts.Tail > 0 && len(backlog) > opts.Tail {
+		backlog = backlog[len(backlog)-opts.Tail:]
+	}
+	for _, l := range backlog {
+		if err := fn(l); err != nil {
+			return err
+		}
+	}
+	for opts.Follow && !closed {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-changed:
+		}
+		lines, next, changed, closed = b.since(next)
+		for _, l := range lines {
+			if !keep(l) {
+				continue
+			}
+			if err := fn(l); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// logWriter splits output from an exec into lines for a logBuffer, passing it
+// through unchanged to an underlying writer, if any.
+type logWriter struct {
+	buf     *logBuffer
+	exec    string
+	stream  string
+	next    io.Writer
+	partial []byte
+}
+
+func (w *logWriter) Write(p []byte) (int, error) {
+	w.partial = append(w.partial, p...)
+	for {
+		if i := bytes.IndexByte(w.partial, '\n'); i >= 0 && i <= maxLogLine {
+			w.emit(w.partial[:i])
+			w.partial = w.partial[i+1:]
+		} else if len(w.partial) >= maxLogLine {
+			w.emit(w.partial[:maxLogLine])
+			w.partial = w.partial[maxLogLine:]
+		} else {
+			break
+		}
+	}
+	// reset the buffer so it doesn't grow without bound
+	w.partial = append([]byte(nil), w.partial...)
+	if w.next != nil {
+		return w.next.Write(p)
+	}
+	return len(p), nil
+}
+
+func (w *logWriter) emit(text []byte) {
+	w.buf.add(api.LogLine{
+		Time:   time.Now(),
+		Exec:   w.exec,
+		Stream: w.stream,
+		Text:   string(bytes.TrimSuffix(text, []byte{'\r'})),
+	})
+}
+
+// flush emits any trailing output that did not end with a newline.
+func (w *logWriter) flush() {
+	if len(w.partial) != 0 {
+		w.emit(w.partial)
+		w.partial = nil
+	}
+}
@@ -0,0 +1,95 @@
+package server
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"fastcat.org/go/gdev/addons/pm/api"
+)
+
+func collectLogs(t *testing.T, b *logBuffer, opts api.LogsOptions) []string {
+	var lines []string
+	require.NoError(t, b.follow(t.Context(), opts, func(l api.LogLine) error {
+		lines = append(lines, l.Exec+"/"+l.Stream+": "+l.Text)
+		return nil
+	}))
+	return lines
+}
+
+func TestLogWriter(t *testing.T) {
+	b := newLogBuffer(10)
+	var passThrough strings.Builder
+	w := &logWriter{buf: b, exec: api.ExecMain, stream: api.StreamStdout, next: &passThrough}
+	for _, chunk := range []string{"hel", "lo\nwor", "ld\r\n\n", "partial"} {
+		n, err := w.Write([]byte(chunk))
+		require.NoError(t, err)
+		assert.Equal(t, len(chunk), n)
+	}
+	assert.Equal(t, "hello\nworld\r\n\npartial", passThrough.String())
+	assert.Equal(t, []string{"main/stdout: hello", "main/stdout: world", "main/stdout: "},
+		collectLogs(t, b, api.LogsOptions{}))
+	w.flush()
+	assert.Equal(t, "main/stdout: partial", collectLogs(t, b, api.LogsOptions{Tail: 1})[0])
+
+	// long lines are split
+	_, err := w.Write([]byte(strings.Repeat("x", maxLogLine+1) + "\n"))
+	require.NoError(t, err)
+	lines, _, _, _ := b.since(0)
+	require.Len(t, lines, 6)
+	assert.Len(t, lines[4].Text, maxLogLine)
+	assert.Equal(t, "x", lines[5].Text)
+}
+
+func TestLogBuffer(t *testing.T) {
+	b := newLogBuffer(3)
+	start := time.Now()
+	for i, text := range []string{"a", "b", "c", "d"} {
+		exec := api.ExecMain
+		if i == 0 {
+			exec = "init-0"
+		}
+		b.add(api.LogLine{Time: start.Add(time.Duration(i) * time.Second), Exec: exec, Text: text})
+	}
+	// oldest line is dropped
+	assert.Equal(t, []string{"main/: b", "main/: c", "main/: d"},
+		collectLogs(t, b, api.LogsOptions{Init: true}))
+	assert.Equal(t, []string{"main/: c", "main/: d"},
+		collectLogs(t, b, api.LogsOptions{Since: start.Add(2 * time.Second)}))
+	assert.Equal(t, []string{"main/: d"}, collectLogs(t, b, api.LogsOptions{Tail: 1}))
+
+	b.add(api.LogLine{Exec: "init-0", Text: "init"})
+	assert.Equal(t, []string{"main/: c", "main/: d"}, collectLogs(t, b, api.LogsOptions{}))
+}
+
+func TestLogBuffer_follow(t *testing.T) {
+	b := newLogBuffer(10)
+	b.add(api.LogLine{Exec: api.ExecMain, Text: "before"})
+	got := make(chan string)
+	done := make(chan error)
+	ctx, cancel := context.WithCancel(t.Context())
+	defer cancel()
+	go func() {
+		done <- b.follow(ctx, api.LogsOptions{Follow: true}, func(l api.LogLine) error {
+			got <- l.Text
+			return nil
+		})
+	}()
+	assert.Equal(t, "before", <-got)
+	b.add(api.LogLine{Exec: api.ExecMain, Text: "after"})
+	assert.Equal(t, "after", <-got)
+	b.close()
+	require.NoError(t, <-done)
+
+	// canceling the context also stops following
+	b = newLogBuffer(10)
+	go func() {
+		done <- b.follow(ctx, api.LogsOptions{Follow: true}, func(api.LogLine) error { return nil })
+	}()
+	cancel()
+	require.NoError(t, <-done)
+}
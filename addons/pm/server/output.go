@@ -0,0 +1,188 @@
+package server
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"time"
+
+	"fastcat.org/go/gdev/addons/pm/api"
+)
+
+// drainDelay is how long we keep reading output after an exec exits, in case
+// background processes it left behind are still holding the output open.
+const drainDelay = time.Second
+
+// execOutput captures the stdout/stderr of an exec into the child's log buffer.
+//
+// When the child has an output dir, the exec writes to named pipes in that dir
+// which it opens read-write, so it never sees a broken pipe if the daemon goes
+// away, and a new daemon can re-open them to re-adopt it. Otherwise, output
+// goes through ordinary pipes and the exec can't outlive the daemon.
+type execOutput struct {
+	stdout, stderr *logWriter
+	lf             *os.File
+	// the read ends of the named pipes, and the write ends to pass to the exec
+	readers, writers []*os.File
+	paths            []string
+	copying          sync.WaitGroup
+	detached         atomic.Bool
+}
+
+func execName(idx, numInit int) string {
+	if idx < numInit {
+		return "init-" + strconv.Itoa(idx)
+	}
+	return api.ExecMain
+}
+
+// isInitExec checks if the exec name in a log line is for an init exec, or its
+// stop command.
+func isInitExec(name string) bool {
+	return strings.HasPrefix(name, "init-")
+}
+
+// openOutput sets up output capture for an exec. If create is false, the named
+// pipes are expected to already exist, from re-adopting the exec after the
+// daemon restarted.
+func (c *child) openOutput(idx int, e api.Exec, create bool) (_ *execOutput, err error) {
+	name := execName(idx, len(c.def.Init))
+	// if logfile is not set, output is also passed to stdout/stderr to let
+	// journalctl capture it. note that this only works if we're using systemd
+	// for isolation.
+	o := &execOutput{
+		stdout: &logWriter{buf: c.logs, exec: name, stream: api.StreamStdout, next: os.Stdout},
+		stderr: &logWriter{buf: c.logs, exec: name, stream: api.StreamStderr, next: os.Stderr},
+	}
+	defer func() {
+		if err != nil {
+			o.close()
+		}
+	}()
+	if e.Logfile != "" {
+		if o.lf, err = os.OpenFile(e.Logfile, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644); err != nil {
+			return nil, fmt.Errorf("unable to open logfile %q: %w", e.Logfile, err)
+		}
+		// share one stream so the order of the output in the file is preserved
+		o.stdout.next, o.stdout.stream = o.lf, ""
+		o.stderr = o.stdout
+	}
+	if c.outputDir == "" {
+		return o, nil
+	}
+
+	if err := os.MkdirAll(c.outputDir, 0o700); err != nil {
+		return nil, err
+	}
+	streams := []string{api.StreamStdout, api.StreamStderr}
+	if o.stderr == o.stdout {
+		streams = []string{"output"}
+	}
+	for _, stream := range streams {
+		path := filepath.Join(c.outputDir, name+"."+stream)
+		if create {
+			if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+				return nil, err
+			}
+			if err := syscall.Mkfifo(path, 0o600); err != nil {
+				return nil, &os.PathError{Op: "mkfifo", Path: path, Err: err}
+			}
+		}
+		o.paths = append(o.paths, path)
+		// open the read end non-blocking so it doesn't wait for a writer, and so
+		// we can use deadlines to stop reading
+		r, err := os.OpenFile(path, os.O_RDONLY|syscall.O_NONBLOCK, 0)
+		if err != nil {
+			return nil, err
+		}
+		o.readers = append(o.readers, r)
+		if create {
+			// the exec needs a plain blocking fd, which os.OpenFile won't give us
+			// for a fifo
+			fd, err := syscall.Open(path, os.O_RDWR|syscall.O_CLOEXEC, 0)
+			if err != nil {
+				return nil, &os.PathError{Op: "open", Path: path, Err: err}
+			}
+			o.writers = append(o.writers, os.NewFile(uintptr(fd), path))
+		}
+	}
+	return o, nil
+}
+
+// cmdOutputs returns the writers to use for the exec's stdout and stderr.
+func (o *execOutput) cmdOutputs() (stdout, stderr io.Writer) {
+	switch len(o.writers) {
+	case 0:
+		return o.stdout, o.stderr
+	case 1:
+		return o.writers[0], o.writers[0]
+	default:
+		return o.writers[0], o.writers[1]
+	}
+}
+
+// started closes our copies of the write ends and starts copying the output
+// into the log buffer.
+func (o *execOutput) started() {
+	for _, w := range o.writers {
+		_ = w.Close()
+	}
+	o.writers = nil
+	for i, r := range o.readers {
+		w := o.stdout
+		if i > 0 {
+			w = o.stderr
+		}
+		o.copying.Go(func() { _, _ = io.Copy(w, r) })
+	}
+}
+
+// detach stops reading the output, leaving it for a new daemon to pick up.
+func (o *execOutput) detach() {
+	o.detached.Store(true)
+	for _, r := range o.readers {
+		_ = r.SetReadDeadline(time.Now())
+	}
+	o.copying.Wait()
+}
+
+// finish drains any remaining output after the exec exits, and releases
+// everything.
+func (o *execOutput) finish() {
+	if o.detached.Load() {
+		// the pipes belong to whoever re-adopted the exec now
+		o.close()
+		return
+	}
+	for _, r := range o.readers {
+		_ = r.SetReadDeadline(time.Now().Add(drainDelay))
+	}
+	o.copying.Wait()
+	o.stdout.flush()
+	if o.stderr != o.stdout {
+		o.stderr.flush()
+	}
+	o.close()
+	for _, p := range o.paths {
+		_ = os.Remove(p)
+	}
+}
+
+func (o *execOutput) close() {
+	for _, f := range o.writers {
+		_ = f.Close()
+	}
+	for _, f := range o.readers {
+		_ = f.Close()
+	}
+	if o.lf != nil {
+		_ = o.lf.Close()
+	}
+}
@@ -0,0 +1,112 @@
+package server
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	"fastcat.org/go/gdev/addons/pm/api"
+)
+
+// savedState is what the daemon persists so that a new daemon can pick up the
+// children of one that crashed or was upgraded.
+type savedState struct {
+	Children []savedChild `json:"children"`
+}
+
+type savedChild struct {
+	Child  api.Child       `json:"child"`
+	Status api.ChildStatus `json:"status"`
+	// Exec is the index of the current exec, len(Init) for the main exec, or -1
+	// if nothing has been started.
+	Exec int `json:"exec"`
+	// StartTime is the start time of the running exec's process, from
+	// /proc/<pid>/stat, so we can tell if the pid has been reused. It is zero if
+	// nothing is running.
+	StartTime uint64 `json:"startTime,omitempty"`
+}
+
+const stateFile = "state.json"
+
+func loadState(dir string) (*savedState, error) {
+	data, err := os.ReadFile(filepath.Join(dir, stateFile))
+	if errors.Is(err, os.ErrNotExist) {
+		return &savedState{}, nil
+	} else if err != nil {
+		return nil, err
+	}
+	var s savedState
+	if err := json.Unmarshal(data, &s); err != nil {
+		return nil, fmt.Errorf("corrupt state file: %w", err)
+	}
+	return &s, nil
+}
+
+func writeState(dir string, s *savedState) error {
+	data, err := json.Marshal(s)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return err
+	}
+	// write & rename so a crash never leaves a partial file
+	f, err := os.CreateTemp(dir, stateFile+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name()) //nolint:errcheck
+	if _, err := f.Write(data); err != nil {
+		_ = f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	return os.Rename(f.Name(), filepath.Join(dir, stateFile))
+}
+
+// procStartTime gets the start time of a process, in clock ticks since boot. It
+// returns an error if the process doesn't exist or is a zombie.
+func procStartTime(pid int) (uint64, error) {
+	data, err := os.ReadFile("/proc/" + strconv.Itoa(pid) + "/stat")
+	if err != nil {
+		return 0, err
+	}
+	// the command name may contain spaces and parens, skip past it
+	i := bytes.LastIndexByte(data, ')')
+	if i < 0 {
+		return 0, fmt.Errorf("malformed stat for pid %d", pid)
+	}
+	// fields after the command start with the state, field 3
+	fields := bytes.Fields(data[i+1:])
+	if len(fields) < 20 {
+		return 0, fmt.Errorf("malformed stat for pid %d", pid)
+	}
+	if string(fields[0]) == "Z" || string(fields[0]) == "X" {
+		return 0, fmt.Errorf("pid %d has exited", pid)
+	}
+	// start time is field 22
+	return strconv.ParseUint(string(fields[19]), 10, 64)
+}
+
+// errExitUnknown is reported when an adopted process exits, as it isn't our
+// child we can't get its exit status.
+var errExitUnknown = errors.New("exit status unknown")
+
+// waitAdopted waits for a process we adopted to exit, by polling it as it isn't
+// our child.
+func waitAdopted(pid int, startTime uint64) {
+	t := time.NewTicker(250 * time.Millisecond)
+	defer t.Stop()
+	for range t.C {
+		if st, err := procStartTime(pid); err != nil || st != startTime {
+			return
+		}
+	}
+}
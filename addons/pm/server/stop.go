@@ -0,0 +1,179 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"os/exec"
+	"strconv"
+	"syscall"
+	"time"
+
+	"golang.org/x/sys/unix"
+
+	"fastcat.org/go/gdev/addons/pm/api"
+	"fastcat.org/go/gdev/addons/pm/internal"
+)
+
+// childSignal is a request to the manager to signal the running exec.
+type childSignal struct {
+	sig    syscall.Signal
+	result chan<- error
+}
+
+// execDef gets the definition of the exec at idx, as used by [child.start].
+func (c *child) execDef(idx int) api.Exec {
+	if idx < len(c.def.Init) {
+		return c.def.Init[idx]
+	}
+	return c.def.Main
+}
+
+// stopGrace is how long to wait for an exec to stop before killing it.
+func (c *child) stopGrace() time.Duration {
+	if c.def.StopGraceSeconds > 0 {
+		return time.Duration(c.def.StopGraceSeconds * float64(time.Second))
+	}
+	return c.killDelay
+}
+
+// stopSignal gets the signal to send to stop an exec.
+func (c *child) stopSignal(e api.Exec) syscall.Signal {
+	if e.StopSignal == "" {
+		return syscall.SIGTERM
+	}
+	sig, err := internal.ParseSignal(e.StopSignal)
+	if err != nil {
+		// should have been caught by validation
+		log.Printf("child %s: bad stop signal, using SIGTERM: %v", c.def.Name, err)
+		return syscall.SIGTERM
+	}
+	return sig
+}
+
+// beginStop starts stopping the exec at idx. If it has a stop command, that is
+// started and the returned channel is closed when it finishes, at which point
+// the caller should terminate the exec if it is still running. Otherwise the
+// exec is terminated right away and the returned channel is nil.
+func (c *child) beginStop(idx int, p *os.Process, s *api.ExecStatus) <-chan struct{} {
+	e := c.execDef(idx)
+	if e.Stop == nil {
+		c.terminate(p, s, c.stopSignal(e))
+		return nil
+	}
+	s.State = api.ExecStopping
+	done := make(chan struct{})
+	name := execName(idx, len(c.def.Init)) + api.ExecStopSuffix
+	grace := c.stopGrace()
+	c.wg.Go(func() {
+		defer close(done)
+		ctx, cancel := context.WithTimeout(context.Background(), grace)
+		defer cancel()
+		c.runStop(ctx, name, e)
+	})
+	return done
+}
+
+// runStop runs the stop command for an exec, capturing its output into the
+// logs under the given name.
+func (c *child) runStop(ctx context.Context, name string, e api.Exec) {
+	stop := e.Stop
+	cmd := exec.CommandContext(ctx, stop.Cmd, stop.Args...)
+	cmd.Dir = e.Cwd
+	if stop.Cwd != "" {
+		cmd.Dir = stop.Cwd
+	}
+	cmd.Env = os.Environ()
+	for k, v := range e.Env {
+		cmd.Env = append(cmd.Env, k+"="+v)
+	}
+	for k, v := range stop.Env {
+		cmd.Env = append(cmd.Env, k+"="+v)
+	}
+	// kill the whole process group on timeout, like we do for the child
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+	cmd.Cancel = func() error { return syscall.Kill(-cmd.Process.Pid, syscall.SIGKILL) }
+	cmd.WaitDelay = time.Second
+	stdout := &logWriter{buf: c.logs, exec: name, stream: api.StreamStdout, next: os.Stdout}
+	stderr := &logWriter{buf: c.logs, exec: name, stream: api.StreamStderr, next: os.Stderr}
+	cmd.Stdout, cmd.Stderr = stdout, stderr
+	log.Printf("running stop command for child %s %s", c.def.Name, name)
+	err := cmd.Run()
+	stdout.flush()
+	stderr.flush()
+	if err != nil {
+		log.Printf("stop command for child %s %s failed: %v", c.def.Name, name, err)
+	}
+}
+
+func (c *child) terminate(p *os.Process, s *api.ExecStatus, sig syscall.Signal) {
+	// signal the whole process group
+	if err := syscall.Kill(-p.Pid, sig); err != nil {
+		log.Printf("failed to terminate %d: %v", p.Pid, err)
+	} else {
+		log.Printf("sent %s to child %s pid %d", signalName(sig), c.def.Name, p.Pid)
+	}
+
+	s.State = api.ExecStopping
+}
+
+// signal asks the manager to send a signal to the running exec.
+func (c *child) signal(ctx context.Context, sig syscall.Signal) error {
+	// the manager may have exited since the child was looked up, e.g. if it was
+	// deleted or the daemon detached
+	gone := func() error {
+		return internal.WithStatus(http.StatusNotFound, fmt.Errorf("child %s is gone", c.def.Name))
+	}
+	result := make(chan error, 1)
+	select {
+	case c.signals <- childSignal{sig, result}:
+	case <-c.done:
+		return gone()
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+	select {
+	case err := <-result:
+		return err
+	case <-c.done:
+		// it may have handled the signal just before exiting
+		select {
+		case err := <-result:
+			return err
+		default:
+			return gone()
+		}
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// sendSignal handles a [childSignal] in the manager.
+func (c *child) sendSignal(p *os.Process, state api.ChildState, sig syscall.Signal) error {
+	if p == nil {
+		return internal.WithStatus(
+			http.StatusPreconditionFailed,
+			fmt.Errorf("child %s is not running (%s)", c.def.Name, state),
+		)
+	}
+	// signal the whole process group, like we do to stop it
+	if err := syscall.Kill(-p.Pid, sig); err != nil {
+		if errors.Is(err, syscall.EINVAL) {
+			return internal.WithStatus(http.StatusBadRequest, fmt.Errorf("invalid signal %d: %w", sig, err))
+		}
+		return fmt.Errorf("failed to signal child %s pid %d: %w", c.def.Name, p.Pid, err)
+	}
+	log.Printf("sent %s to child %s pid %d", signalName(sig), c.def.Name, p.Pid)
+	return nil
+}
+
+// signalName gets the conventional name of a signal, e.g. SIGTERM.
+func signalName(sig syscall.Signal) string {
+	if name := unix.SignalName(sig); name != "" {
+		return name
+	}
+	return "signal " + strconv.Itoa(int(sig))
+}
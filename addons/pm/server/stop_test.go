@@ -0,0 +1,175 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"fastcat.org/go/gdev/addons/pm/api"
+	"fastcat.org/go/gdev/addons/pm/internal"
+	"fastcat.org/go/gdev/lib/httpx"
+)
+
+var errGotLine = errors.New("got line")
+
+func TestParseSignal(t *testing.T) {
+	for _, tt := range []struct {
+		in   string
+		want syscall.Signal
+	}{
+		{"SIGTERM", syscall.SIGTERM},
+		{"hup", syscall.SIGHUP},
+		{"Int", syscall.SIGINT},
+		{"9", syscall.SIGKILL},
+		{"", 0},
+		{"-1", 0},
+		{"SIGBOGUS", 0},
+	} {
+		t.Run(tt.in, func(t *testing.T) {
+			sig, err := internal.ParseSignal(tt.in)
+			if tt.want == 0 {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tt.want, sig)
+			}
+		})
+	}
+}
+
+func TestChildStop(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow() // does not return
+	}
+
+	d, err := newDaemon("")
+	require.NoError(t, err)
+	t.Cleanup(func() { assert.NoError(t, d.Terminate(context.Background())) })
+
+	runUntilStopped := func(t *testing.T, def api.Child) api.ChildStatus {
+		t.Helper()
+		_, err := d.PutChild(t.Context(), def)
+		require.NoError(t, err)
+		_, err = d.StartChild(t.Context(), def.Name)
+		require.NoError(t, err)
+		waitLine(t, d, def.Name, api.LogsOptions{Follow: true}, "ready")
+		stat, err := d.StopChild(t.Context(), def.Name)
+		require.NoError(t, err)
+		return stat.Status
+	}
+
+	t.Run("stop signal", func(t *testing.T) {
+		s := runUntilStopped(t, api.Child{
+			Name: "stop-signal",
+			Main: api.Exec{
+				Cmd:        "sh",
+				Args:       []string{"-c", `trap "exit 0" INT; echo ready; while true; do sleep 0.05; done`},
+				StopSignal: "INT",
+			},
+		})
+		assert.Equal(t, "main exited with code 0", s.LastExit)
+	})
+
+	t.Run("stop command", func(t *testing.T) {
+		td := t.TempDir()
+		s := runUntilStopped(t, api.Child{
+			Name: "stop-command",
+			Main: api.Exec{
+				Cmd: "sh",
+				// ignores SIGTERM, only stops when asked nicely
+				Args: []string{"-c", `trap "" TERM; echo ready; while [ ! -f stop ]; do sleep 0.05; done`},
+				Cwd:  td,
+				Stop: &api.Exec{Cmd: "sh", Args: []string{"-c", "echo stopping && touch stop"}},
+			},
+		})
+		assert.Equal(t, "main exited with code 0", s.LastExit)
+		waitLine(t, d, "stop-command", api.LogsOptions{}, "stopping")
+	})
+
+	t.Run("grace period", func(t *testing.T) {
+		s := runUntilStopped(t, api.Child{
+			Name: "stop-grace",
+			Main: api.Exec{
+				Cmd:  "sh",
+				Args: []string{"-c", `trap "" TERM; echo ready; while true; do sleep 0.05; done`},
+			},
+			StopGraceSeconds: 0.2,
+		})
+		assert.Equal(t, "main killed by SIGKILL", s.LastExit)
+	})
+}
+
+func TestSignalChild(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow() // does not return
+	}
+
+	d, err := newDaemon("")
+	require.NoError(t, err)
+	t.Cleanup(func() { assert.NoError(t, d.Terminate(context.Background())) })
+
+	def := api.Child{
+		Name: "reload",
+		Main: api.Exec{
+			Cmd:  "sh",
+			Args: []string{"-c", `trap "echo reloaded" HUP; echo ready; while true; do sleep 0.05; done`},
+		},
+	}
+	_, err = d.PutChild(t.Context(), def)
+	require.NoError(t, err)
+
+	_, err = d.SignalChild(t.Context(), def.Name, "HUP")
+	assert.Equal(t, http.StatusPreconditionFailed, statusCode(err))
+
+	_, err = d.StartChild(t.Context(), def.Name)
+	require.NoError(t, err)
+	waitLine(t, d, def.Name, api.LogsOptions{Follow: true}, "ready")
+
+	_, err = d.SignalChild(t.Context(), def.Name, "SIGBOGUS")
+	assert.Equal(t, http.StatusBadRequest, statusCode(err))
+	_, err = d.SignalChild(t.Context(), "missing", "HUP")
+	assert.Equal(t, http.StatusNotFound, statusCode(err))
+
+	stat, err := d.SignalChild(t.Context(), def.Name, "HUP")
+	require.NoError(t, err)
+	assert.Equal(t, api.ChildRunning, stat.Status.State)
+	waitLine(t, d, def.Name, api.LogsOptions{Follow: true}, "reloaded")
+}
+
+func TestSignalChild_gone(t *testing.T) {
+	// the manager was never started, and has exited
+	c := newChild(api.Child{Name: "gone"}, nil)
+	close(c.done)
+	err := c.signal(t.Context(), syscall.SIGHUP)
+	assert.Equal(t, http.StatusNotFound, statusCode(err))
+	assert.ErrorContains(t, err, "child gone is gone")
+}
+
+func waitLine(t *testing.T, d *daemon, name string, opts api.LogsOptions, text string) {
+	t.Helper()
+	ctx, cancel := context.WithTimeout(t.Context(), 5*time.Second)
+	defer cancel()
+	var got []string
+	err := d.ChildLogs(ctx, name, opts, func(l api.LogLine) error {
+		if l.Text == text {
+			return errGotLine
+		}
+		got = append(got, l.Exec+": "+l.Text)
+		return nil
+	})
+	require.ErrorIs(t, err, errGotLine, "got lines:\n%s", strings.Join(got, "\n"))
+}
+
+func statusCode(err error) int {
+	if sc, ok := errors.AsType[httpx.StatusCodeErr](err); ok {
+		return sc.StatusCode()
+	}
+	return 0
+}
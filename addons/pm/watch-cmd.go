@@ -0,0 +1,62 @@
+package pm
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"fastcat.org/go/gdev/addons/pm/api"
+	"fastcat.org/go/gdev/addons/pm/client"
+)
+
+func pmWatch() *cobra.Command {
+	jsonOut := false
+	c := &cobra.Command{
+		Use:   "watch [name...]",
+		Short: "show changes to pm services as they happen",
+		Long: "Prints events from the pm daemon as services are added, removed, change state, " +
+			"exit, change health, or are restarted. With names, only shows events for those services.",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return PMWatch(cmd.Context(), client.NewHTTP(), jsonOut, cmd.OutOrStdout(), args...)
+		},
+	}
+	c.Flags().BoolVar(&jsonOut, "json", jsonOut, "print events as JSON lines")
+	return c
+}
+
+// PMWatch writes events for the named children, or all children if none are
+// named, to out until ctx is done.
+func PMWatch(ctx context.Context, c api.API, jsonOut bool, out io.Writer, names ...string) error {
+	e := json.NewEncoder(out)
+	err := c.Events(ctx, api.EventsOptions{Children: names}, func(ev api.Event) error {
+		if jsonOut {
+			return e.Encode(ev)
+		}
+		_, err := fmt.Fprintln(out, FormatEvent(ev))
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("failed watching pm events: %w", err)
+	}
+	return nil
+}
+
+// FormatEvent describes an event on one line.
+func FormatEvent(ev api.Event) string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "%s %s %s", ev.Time.Local().Format("15:04:05.000"), ev.Child, ev.Type)
+	switch ev.Type {
+	case api.EventChildAdded, api.EventChildRemoved:
+		fmt.Fprintf(&sb, " (%s)", ev.State)
+	case api.EventRestart:
+		fmt.Fprintf(&sb, " #%d", ev.Restarts)
+	}
+	if ev.Message != "" {
+		sb.WriteString(": " + ev.Message)
+	}
+	return sb.String()
+}
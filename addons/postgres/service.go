@@ -49,6 +49,7 @@ func Service(
 	return service.New(
 		cfg.name,
 		service.WithResources(resources...),
+		service.WithEnv(cfg.env()),
 	)
 }
 
@@ -337,6 +338,17 @@ func (c svcConfig) Credentials() map[string]string {
 	}
 }
 
+// env is the environment published by the service, which adds the connection
+// address to the credentials if it is reachable from the host.
+func (c svcConfig) env() map[string]string {
+	env := c.Credentials()
+	if c.nodePort > 0 {
+		env["PGHOST"] = "localhost"
+		env["PGPORT"] = strconv.Itoa(c.nodePort)
+	}
+	return env
+}
+
 func (c svcConfig) selector() map[string]string {
 	return map[string]string{
 		"app": c.name,
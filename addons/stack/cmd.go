@@ -1,9 +1,14 @@
 package stack
 
 import (
+	"errors"
 	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
 	"slices"
 	"strings"
+	"time"
 
 	"github.com/spf13/cobra"
 
@@ -19,42 +24,31 @@ func registerCommands() {
 	}
 
 	instance.AddCommandBuilders(
-		func() *cobra.Command {
-			instance.CheckLockedDown()
-			scd := cobra.ShellCompDirectiveNoFileComp
-			cmd := &cobra.Command{
-				Use:   "start",
-				Short: "start the stack",
-				Args:  cobra.NoArgs,
-				CompletionOptions: cobra.CompletionOptions{
-					DefaultShellCompDirective: &scd,
-				},
-				RunE: func(cmd *cobra.Command, args []string) error {
-					return Start(cmd.Context(), service.WithServiceModes(service.ConfiguredModes()))
-				},
-			}
-			f := cmd.Flags()
-			for _, fn := range startFlaggers {
-				if err := fn(f, cmd.RegisterFlagCompletionFunc); err != nil {
-					panic(err)
-				}
-			}
-			return cmd
-		},
+		func() *cobra.Command { return startCommand(false) },
+		func() *cobra.Command { return startCommand(true) },
 		func() *cobra.Command {
 			var opts StackStopOptions
 			scd := cobra.ShellCompDirectiveNoFileComp
 			stopCmd := &cobra.Command{
-				Use:   "stop",
-				Short: "stop the stack",
-				Args:  cobra.NoArgs,
+				Use:   "stop [service...]",
+				Short: "stop the stack, or just the named services",
 				CompletionOptions: cobra.CompletionOptions{
 					DefaultShellCompDirective: &scd,
 				},
+				ValidArgsFunction: completeServiceNames,
 				RunE: func(cmd *cobra.Command, args []string) error {
+					opts.Services = args
+					opts.ServiceModes = service.ConfiguredModes()
 					return StackStop(cmd.Context(), opts)
 				},
 			}
+			opts.WaitTimeout = DefaultStopWaitTimeout
+			stopCmd.Flags().BoolVar(&opts.Wait, "wait", opts.Wait,
+				"wait until the stopped resources have fully shut down")
+			stopCmd.Flags().DurationVar(&opts.WaitTimeout, "wait-timeout", opts.WaitTimeout,
+				"maximum time to wait with --wait (0 for no limit)")
+			stopCmd.Flags().BoolVar(&opts.WithDependencies, "with-deps", opts.WithDependencies,
+				"also stop the dependencies of the named services")
 			stopCmd.Flags().BoolVar(&opts.IncludeInfrastructure, "include-infrastructure",
 				opts.IncludeInfrastructure,
 				"stop infrastructure too, not just normal services")
@@ -62,8 +56,164 @@ func registerCommands() {
 				"exclude these resources from stopping (suffix match after slash)")
 			return stopCmd
 		},
+		func() *cobra.Command {
+			output := "table"
+			statusCmd := &cobra.Command{
+				Use:               "status [service...]",
+				Short:             "show the live state of stack services",
+				Long:              "Shows the mode, resources, readiness, and images for each service, without changing anything",
+				ValidArgsFunction: completeServiceNames,
+				RunE: func(cmd *cobra.Command, args []string) error {
+					if output != "table" && output != "json" {
+						return fmt.Errorf("invalid output format %q", output)
+					}
+					statuses, err := Status(cmd.Context(),
+						[]service.ContextOption{service.WithServiceModes(service.ConfiguredModes())},
+						args...,
+					)
+					if err != nil {
+						return err
+					}
+					if output == "json" {
+						return StatusJSON(statuses, cmd.OutOrStdout())
+					}
+					StatusTable(statuses, cmd.OutOrStdout())
+					return nil
+				},
+			}
+			statusCmd.Flags().StringVarP(&output, "output", "o", output, "output format (table or json)")
+			_ = statusCmd.RegisterFlagCompletionFunc("output", cobra.FixedCompletions(
+				[]string{"table", "json"},
+				cobra.ShellCompDirectiveNoFileComp,
+			))
+			return statusCmd
+		},
+		func() *cobra.Command {
+			var sel ServiceSelection
+			format := "export"
+			envCmd := &cobra.Command{
+				Use:   "env [service...]",
+				Short: "print the connection environment variables published by stack services",
+				Long: "Prints the variables (host, port, credentials, URLs, ...) published by the stack services, " +
+					"or just the named services, as shell exports, dotenv, or JSON",
+				ValidArgsFunction: completeServiceNames,
+				RunE: func(cmd *cobra.Command, args []string) error {
+					if !slices.Contains(EnvFormats, format) {
+						return fmt.Errorf("invalid env format %q", format)
+					}
+					sel.Services = args
+					env, err := Env(cmd.Context(), sel, service.WithServiceModes(service.ConfiguredModes()))
+					if err != nil {
+						return err
+					}
+					return WriteEnv(env, format, cmd.OutOrStdout())
+				},
+			}
+			envCmd.Flags().StringVarP(&format, "format", "f", format,
+				"output format ("+strings.Join(EnvFormats, ", ")+")")
+			_ = envCmd.RegisterFlagCompletionFunc("format", cobra.FixedCompletions(
+				EnvFormats,
+				cobra.ShellCompDirectiveNoFileComp,
+			))
+			envCmd.Flags().BoolVar(&sel.WithDependencies, "with-deps", sel.WithDependencies,
+				"also include the dependencies of the named services")
+			return envCmd
+		},
+		func() *cobra.Command {
+			debugCmd := &cobra.Command{
+				Use:   "debug",
+				Short: "helpers for debugging stack services",
+			}
+			var sel ServiceSelection
+			launchJSON := filepath.Join(".vscode", "launch.json")
+			printOnly := false
+			configCmd := &cobra.Command{
+				Use:   "config [service...]",
+				Short: "write debugger launch configurations for services in debug mode",
+				Long: "Writes or updates VS Code launch.json entries, and prints dlv / node --inspect " +
+					"command lines, for the services (or just the named services) configured in debug mode, " +
+					"with the environment values of their dependencies injected. Those values are " +
+					"written to separate env files, readable only by the current user, as they may include " +
+					"credentials. Comments in an existing launch.json are not preserved.",
+				ValidArgsFunction: completeServiceNames,
+				RunE: func(cmd *cobra.Command, args []string) error {
+					sel.Services = args
+					launches, err := DebugLaunches(cmd.Context(), sel,
+						service.WithServiceModes(service.ConfiguredModes()))
+					if err != nil {
+						return err
+					}
+					if len(launches) == 0 {
+						fmt.Fprintln(cmd.ErrOrStderr(), "No services are configured in debug mode")
+						return nil
+					}
+					errOut := cmd.ErrOrStderr()
+					for i := range launches {
+						l := &launches[i]
+						if printOnly || l.Launch == nil || len(l.ProvidedEnv) == 0 {
+							continue
+						}
+						if err := WriteDebugEnvFile(filepath.Dir(launchJSON), l); err != nil {
+							return fmt.Errorf("error writing environment for %s: %w", l.Service, err)
+						}
+						fmt.Fprintf(errOut, "WARNING: wrote environment for %s, which may include credentials, "+
+							"to %s, keep it out of version control\n", l.Service, l.EnvFile)
+					}
+					if printOnly && slices.ContainsFunc(launches, func(l ServiceDebugLaunch) bool {
+						return l.Launch != nil && len(l.ProvidedEnv) != 0
+					}) {
+						fmt.Fprintln(errOut, "WARNING: the environment provided by service dependencies "+
+							"is not included when printing")
+					}
+					existing, err := os.ReadFile(launchJSON)
+					if err != nil && !errors.Is(err, fs.ErrNotExist) {
+						return err
+					}
+					merged, err := MergeVSCodeLaunch(existing, launches)
+					if err != nil {
+						return fmt.Errorf("error updating %s: %w", launchJSON, err)
+					}
+					out := cmd.OutOrStdout()
+					if printOnly {
+						if _, err := out.Write(merged); err != nil {
+							return err
+						}
+					} else {
+						if err := os.MkdirAll(filepath.Dir(launchJSON), 0o755); err != nil {
+							return err
+						}
+						if err := os.WriteFile(launchJSON, merged, 0o644); err != nil {
+							return err
+						}
+						fmt.Fprintf(out, "Updated %s\n", launchJSON)
+					}
+					for _, l := range launches {
+						if l.Launch == nil {
+							fmt.Fprintf(errOut, "Service %s does not support running under a debugger\n", l.Service)
+							continue
+						}
+						if cl := DebugCommandLine(l.Launch); cl != "" {
+							if l.EnvFile != "" {
+								cl = "set -a && . " + shellQuote(l.EnvFile) + " && set +a && " + cl
+							}
+							fmt.Fprintf(out, "# %s\n%s\n", l.Service, cl)
+						}
+					}
+					return nil
+				},
+			}
+			configCmd.Flags().StringVar(&launchJSON, "launch-json", launchJSON,
+				"path to the VS Code launch.json to write or update")
+			configCmd.Flags().BoolVar(&printOnly, "print", printOnly,
+				"print the merged launch.json instead of writing it")
+			configCmd.Flags().BoolVar(&sel.WithDependencies, "with-deps", sel.WithDependencies,
+				"also include the dependencies of the named services")
+			debugCmd.AddCommand(configCmd)
+			return debugCmd
+		},
 	)
 
+	cmd.AddConfigCommandBuilder(profileCommand)
 	cmd.AddConfigCommandBuilder(func() *cobra.Command {
 		return &cobra.Command{
 			Use:  "mode",
@@ -108,10 +258,14 @@ func registerCommands() {
 					}
 					for s, m := range modes {
 						if svc, ok := findSvc(s); ok {
-							if m != service.ModeDisabled && m != service.ModeDebug && !svc.HasModal(m) {
+							if m.RequiresModal() && !svc.HasModal(m) {
 								fmt.Printf("WARNING: service %s does not have support for %s mode\n", s, m)
 							}
-							fmt.Printf("Service %s will run in %s mode\n", s, m)
+							if m == service.ModeExcluded {
+								fmt.Printf("Service %s is excluded and will not be started or stopped\n", s)
+							} else {
+								fmt.Printf("Service %s will run in %s mode\n", s, m)
+							}
 						} else {
 							fmt.Printf("WARNING: unknown service %q configured for %s mode\n", s, m)
 						}
@@ -128,9 +282,7 @@ func registerCommands() {
 
 				if len(args) == 1 {
 					fmt.Printf("Current mode for service %s: %s\n", args[0], currentMode)
-					if currentMode != service.ModeDisabled &&
-						currentMode != service.ModeDebug &&
-						svc != nil && !svc.HasModal(currentMode) {
+					if currentMode.RequiresModal() && svc != nil && !svc.HasModal(currentMode) {
 						fmt.Printf("WARNING: service %s does not have support for %s mode\n", args[0], currentMode)
 					}
 					return nil
@@ -139,7 +291,7 @@ func registerCommands() {
 				newMode, ok := service.ParseMode(args[1])
 				if !ok {
 					return fmt.Errorf("invalid mode %q for service %q", args[1], args[0])
-				} else if newMode != service.ModeDebug && newMode != service.ModeDisabled && !svc.HasModal(newMode) {
+				} else if newMode.RequiresModal() && svc != nil && !svc.HasModal(newMode) {
 					return fmt.Errorf("service %s does not have support for %s mode", args[0], newMode)
 				}
 				if newMode == currentMode {
@@ -150,9 +302,143 @@ func registerCommands() {
 				if err := gConfig.Save(); err != nil {
 					return fmt.Errorf("failed to save config: %w", err)
 				}
-				fmt.Printf("service %s will run in %s mode on next start\n", args[0], newMode)
+				if newMode == service.ModeExcluded {
+					fmt.Printf("service %s will be left alone by start and stop\n", args[0])
+				} else {
+					fmt.Printf("service %s will run in %s mode on next start\n", args[0], newMode)
+				}
 				return nil
 			},
 		}
 	})
 }
+
+// timingSummaryItems is how many items start --timing shows.
+const timingSummaryItems = 10
+
+// startCommand builds the start command, or if restart is set, the restart
+// command, which stops the named services before starting them again.
+func startCommand(restart bool) *cobra.Command {
+	instance.CheckLockedDown()
+	waitTimeouts := service.WaitTimeouts{Overall: 10 * time.Minute}
+	var sel ServiceSelection
+	var profile string
+	var dryRun bool
+	var showTiming bool
+	var rollback bool
+	var timingHistory string
+	scd := cobra.ShellCompDirectiveNoFileComp
+	cmd := &cobra.Command{
+		Use:   "start [service...]",
+		Short: "start the stack, or just the named services",
+		CompletionOptions: cobra.CompletionOptions{
+			DefaultShellCompDirective: &scd,
+		},
+		ValidArgsFunction: completeServiceNames,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if waitTimeouts.Overall < 0 {
+				return fmt.Errorf("invalid --wait-timeout %v, must not be negative", waitTimeouts.Overall)
+			} else if waitTimeouts.PerResource < 0 {
+				return fmt.Errorf("invalid --resource-wait-timeout %v, must not be negative",
+					waitTimeouts.PerResource)
+			}
+			sel.Services = args
+			modes := service.ConfiguredModes()
+			if profile != "" {
+				var ok bool
+				if modes, ok = service.ModeProfile(profile); !ok {
+					return fmt.Errorf("unknown mode profile %q", profile)
+				}
+			}
+			if dryRun {
+				plans, err := Plan(cmd.Context(), sel, service.WithServiceModes(modes))
+				if err != nil {
+					return err
+				}
+				PlanTable(plans, cmd.OutOrStdout())
+				return nil
+			}
+			if restart {
+				// wait for the stop to finish so the start doesn't race with it
+				if err := StackStop(cmd.Context(), StackStopOptions{
+					ServiceSelection: sel,
+					ServiceModes:     modes,
+					Wait:             true,
+					WaitTimeout:      DefaultStopWaitTimeout,
+				}); err != nil {
+					return err
+				}
+			}
+			startOpts := []any{
+				sel,
+				service.WithServiceModes(modes),
+				service.WithServiceWaitTimeouts(waitTimeouts),
+			}
+			if rollback {
+				startOpts = append(startOpts, service.WithRollbackOnFailure())
+			}
+			var timing *StartTiming
+			if showTiming || timingHistory != "" {
+				timing = &StartTiming{}
+				startOpts = append(startOpts, timing)
+			}
+			err := Start(cmd.Context(), startOpts...)
+			if showTiming {
+				timing.SummaryTable(timingSummaryItems, cmd.OutOrStdout())
+			}
+			if timingHistory != "" {
+				if herr := timing.AppendHistory(timingHistory, sel.Services, err); herr != nil {
+					err = errors.Join(err, herr)
+				}
+			}
+			return err
+		},
+	}
+	if restart {
+		cmd.Use = "restart service..."
+		cmd.Short = "stop and then start the named services"
+		cmd.Args = cobra.MinimumNArgs(1)
+	}
+	f := cmd.Flags()
+	if !restart {
+		f.BoolVar(&dryRun, "dry-run", dryRun, "show what would be started or changed, without changing anything")
+	}
+	f.BoolVar(&sel.WithDependencies, "with-deps", sel.WithDependencies,
+		"also "+cmd.Name()+" the dependencies of the named services")
+	f.DurationVar(&waitTimeouts.Overall, "wait-timeout", waitTimeouts.Overall,
+		"maximum time to wait for all services to be ready (0 for no limit)")
+	f.DurationVar(&waitTimeouts.PerResource, "resource-wait-timeout", waitTimeouts.PerResource,
+		"maximum time to wait for each resource to be ready after it is started (0 for no limit)")
+	f.BoolVar(&rollback, "rollback-on-failure", rollback,
+		"if any resource fails to start, stop the ones that were started by this run")
+	f.BoolVar(&showTiming, "timing", showTiming,
+		fmt.Sprintf("print the %d slowest steps of starting the stack", timingSummaryItems))
+	f.StringVar(&timingHistory, "timing-history", timingHistory,
+		"append the start timing to this file as a line of JSON")
+	f.StringVar(&profile, "profile", profile,
+		"use the service modes from this profile for this run, without changing the configured modes")
+	_ = cmd.RegisterFlagCompletionFunc("profile", completeProfileNames)
+	for _, fn := range startFlaggers {
+		if err := fn(f, cmd.RegisterFlagCompletionFunc); err != nil {
+			panic(err)
+		}
+	}
+	return cmd
+}
+
+// completeServiceNames provides shell completion for commands that take a list
+// of service names as args.
+func completeServiceNames(
+	_ *cobra.Command,
+	args []string,
+	toComplete string,
+) ([]string, cobra.ShellCompDirective) {
+	allServices := append(AllInfrastructure(), AllServices()...)
+	candidates := make([]string, 0, len(allServices))
+	for _, s := range allServices {
+		if n := s.Name(); strings.HasPrefix(n, toComplete) && !slices.Contains(args, n) {
+			candidates = append(candidates, n)
+		}
+	}
+	return candidates, cobra.ShellCompDirectiveNoFileComp
+}
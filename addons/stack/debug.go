@@ -0,0 +1,248 @@
+package stack
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"fastcat.org/go/gdev/instance"
+	"fastcat.org/go/gdev/service"
+)
+
+// ServiceDebugLaunch is how to launch a service in debug mode.
+type ServiceDebugLaunch struct {
+	Service string
+	// Launch is nil if the service does not support being run under a debugger.
+	Launch *service.DebugLaunch
+	// ProvidedEnv is the environment provided by the services it depends on (see
+	// [service.ProvidedEnv]). It is kept apart from the Launch's own Env as it
+	// may contain credentials, see [WriteDebugEnvFile].
+	ProvidedEnv map[string]string
+	// EnvFile, if set, is the path of a file holding the ProvidedEnv, which
+	// launch configurations will load.
+	EnvFile string
+}
+
+// DebugLaunches gets the launch configurations for the selected services that
+// are in [service.ModeDebug]. The options are as for [Start].
+func DebugLaunches(ctx context.Context, opts ...any) ([]ServiceDebugLaunch, error) {
+	ctx, sel, err := startContext(ctx, opts)
+	if err != nil {
+		return nil, err
+	}
+	infra, svcs, err := sel.selected()
+	if err != nil {
+		return nil, err
+	}
+	var ret []ServiceDebugLaunch
+	var errs []error
+	for _, svc := range append(infra, svcs...) {
+		if m, _ := service.ServiceMode(ctx, svc.Name()); m != service.ModeDebug {
+			continue
+		}
+		launch, err := service.GetDebugLaunch(ctx, svc)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("error getting debug launch for service %s: %w", svc.Name(), err))
+			continue
+		}
+		var env map[string]string
+		if launch != nil {
+			if env, err = service.ProvidedEnv(service.WithCurrentService(ctx, svc)); err != nil {
+				errs = append(errs, fmt.Errorf("error getting provided environment for service %s: %w",
+					svc.Name(), err))
+				continue
+			}
+		}
+		ret = append(ret, ServiceDebugLaunch{Service: svc.Name(), Launch: launch, ProvidedEnv: env})
+	}
+	return ret, errors.Join(errs...)
+}
+
+// DebugLaunchName is the name used for the service's launch configuration in
+// IDEs.
+func DebugLaunchName(svc string) string {
+	return instance.AppName() + ": " + svc
+}
+
+// WriteDebugEnvFile writes the launch's ProvidedEnv, if any, to a dotenv file
+// in dir that only the current user can read, and sets its EnvFile.
+func WriteDebugEnvFile(dir string, l *ServiceDebugLaunch) error {
+	if l.Launch == nil || len(l.ProvidedEnv) == 0 {
+		return nil
+	}
+	fn, err := filepath.Abs(filepath.Join(dir, instance.AppName()+"-"+l.Service+".env"))
+	if err != nil {
+		return err
+	}
+	var buf bytes.Buffer
+	if err := WriteEnv(l.ProvidedEnv, "dotenv", &buf); err != nil {
+		return err
+	}
+	if err := os.WriteFile(fn, buf.Bytes(), 0o600); err != nil {
+		return err
+	}
+	// WriteFile doesn't change the mode of an existing file
+	if err := os.Chmod(fn, 0o600); err != nil {
+		return err
+	}
+	l.EnvFile = fn
+	return nil
+}
+
+// vscodeLaunchConfig converts the launch to a VS Code launch.json entry.
+func vscodeLaunchConfig(name string, l *service.DebugLaunch, envFile string) map[string]any {
+	c := map[string]any{
+		"name":    name,
+		"type":    string(l.Debugger),
+		"request": "launch",
+		"program": l.Program,
+	}
+	if l.Debugger == service.DebuggerGo {
+		c["mode"] = "auto"
+	}
+	if len(l.Args) != 0 {
+		c["args"] = l.Args
+	}
+	if len(l.Env) != 0 {
+		c["env"] = l.Env
+	}
+	if envFile != "" {
+		c["envFile"] = envFile
+	}
+	if l.Cwd != "" {
+		c["cwd"] = l.Cwd
+	}
+	return c
+}
+
+// MergeVSCodeLaunch merges launch configurations for the services into the
+// existing content of a VS Code launch.json file, replacing any previous
+// configurations with the same names. Other content is kept, though comments
+// are not.
+func MergeVSCodeLaunch(existing []byte, launches []ServiceDebugLaunch) ([]byte, error) {
+	doc := map[string]json.RawMessage{}
+	var configs []json.RawMessage
+	if len(bytes.TrimSpace(existing)) != 0 {
+		if err := json.Unmarshal(stripJSONC(existing), &doc); err != nil {
+			return nil, fmt.Errorf("can't parse launch.json: %w", err)
+		}
+		if raw, ok := doc["configurations"]; ok {
+			if err := json.Unmarshal(raw, &configs); err != nil {
+				return nil, fmt.Errorf("can't parse launch.json configurations: %w", err)
+			}
+		}
+	}
+	if _, ok := doc["version"]; !ok {
+		doc["version"] = json.RawMessage(`"0.2.0"`)
+	}
+	for _, l := range launches {
+		if l.Launch == nil {
+			continue
+		}
+		name := DebugLaunchName(l.Service)
+		raw, err := json.Marshal(vscodeLaunchConfig(name, l.Launch, l.EnvFile))
+		if err != nil {
+			return nil, err
+		}
+		if i := slices.IndexFunc(configs, func(c json.RawMessage) bool {
+			var named struct{ Name string }
+			return json.Unmarshal(c, &named) == nil && named.Name == name
+		}); i >= 0 {
+			configs[i] = raw
+		} else {
+			configs = append(configs, raw)
+		}
+	}
+	var err error
+	if doc["configurations"], err = json.Marshal(configs); err != nil {
+		return nil, err
+	}
+	out, err := json.MarshalIndent(doc, "", "\t")
+	if err != nil {
+		return nil, err
+	}
+	return append(out, '\n'), nil
+}
+
+// stripJSONC removes comments and trailing commas from JSON-with-comments, as
+// used by VS Code config files.
+func stripJSONC(in []byte) []byte {
+	out := make([]byte, 0, len(in))
+	inString := false
+	for i := 0; i < len(in); i++ {
+		c := in[i]
+		switch {
+		case inString:
+			out = append(out, c)
+			if c == '\\' && i+1 < len(in) {
+				i++
+				out = append(out, in[i])
+			} else if c == '"' {
+				inString = false
+			}
+		case c == '"':
+			inString = true
+			out = append(out, c)
+		case c == '/' && i+1 < len(in) && in[i+1] == '/':
+			for i < len(in) && in[i] != '\n' {
+				i++
+			}
+			if i < len(in) {
+				out = append(out, '\n')
+			}
+		case c == '/' && i+1 < len(in) && in[i+1] == '*':
+			end := bytes.Index(in[i+2:], []byte("*/"))
+			if end < 0 {
+				return out
+			}
+			i += end + 3
+		case c == '}' || c == ']':
+			// drop a trailing comma before the closing bracket
+			trimmed := bytes.TrimRight(out, " \t\r\n")
+			if len(trimmed) != 0 && trimmed[len(trimmed)-1] == ',' {
+				out = append(trimmed[:len(trimmed)-1], out[len(trimmed):]...)
+			}
+			out = append(out, c)
+		default:
+			out = append(out, c)
+		}
+	}
+	return out
+}
+
+// DebugCommandLine gives a shell command line to run the launch under a
+// debugger from a terminal, or an empty string if the debugger is not known.
+func DebugCommandLine(l *service.DebugLaunch) string {
+	var parts []string
+	if l.Cwd != "" {
+		parts = append(parts, "cd", shellQuote(l.Cwd), "&&")
+	}
+	if len(l.Env) != 0 {
+		parts = append(parts, "env")
+		for _, k := range slices.Sorted(maps.Keys(l.Env)) {
+			parts = append(parts, k+"="+shellQuote(l.Env[k]))
+		}
+	}
+	switch l.Debugger {
+	case service.DebuggerGo:
+		parts = append(parts, "dlv", "debug", shellQuote(l.Program))
+		if len(l.Args) != 0 {
+			parts = append(parts, "--")
+		}
+	case service.DebuggerNode:
+		parts = append(parts, "node", "--inspect", shellQuote(l.Program))
+	default:
+		return ""
+	}
+	for _, a := range l.Args {
+		parts = append(parts, shellQuote(a))
+	}
+	return strings.Join(parts, " ")
+}
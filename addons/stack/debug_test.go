@@ -0,0 +1,117 @@
+package stack
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"fastcat.org/go/gdev/service"
+)
+
+func TestStripJSONC(t *testing.T) {
+	in := `{
+	// line comment
+	"a": "http://x/*y*/", /* block
+	comment */
+	"b": [1, 2,],
+}`
+	var v map[string]any
+	require.NoError(t, json.Unmarshal(stripJSONC([]byte(in)), &v))
+	assert.Equal(t, map[string]any{"a": "http://x/*y*/", "b": []any{1.0, 2.0}}, v)
+}
+
+func TestMergeVSCodeLaunch(t *testing.T) {
+	existing := `{
+	// keep me
+	"version": "0.2.0",
+	"compounds": [],
+	"configurations": [
+		{"name": "other", "type": "go"},
+		{"name": "test: api", "type": "go", "program": "old"},
+	]
+}`
+	launches := []ServiceDebugLaunch{
+		{Service: "api", Launch: &service.DebugLaunch{
+			Debugger: service.DebuggerGo,
+			Program:  "./cmd/api",
+			Args:     []string{"serve"},
+			Env:      map[string]string{"PORT": "8080"},
+		}, EnvFile: "/tmp/test-api.env"},
+		{Service: "web", Launch: &service.DebugLaunch{
+			Debugger: service.DebuggerNode,
+			Program:  "index.js",
+			Cwd:      "web",
+		}},
+		{Service: "db"},
+	}
+	out, err := MergeVSCodeLaunch([]byte(existing), launches)
+	require.NoError(t, err)
+
+	var doc struct {
+		Version        string
+		Compounds      []any
+		Configurations []map[string]any
+	}
+	require.NoError(t, json.Unmarshal(out, &doc))
+	assert.Equal(t, "0.2.0", doc.Version)
+	assert.NotNil(t, doc.Compounds)
+	assert.Equal(t, []map[string]any{
+		{"name": "other", "type": "go"},
+		{
+			"name": "test: api", "type": "go", "request": "launch", "mode": "auto",
+			"program": "./cmd/api", "args": []any{"serve"}, "env": map[string]any{"PORT": "8080"},
+			"envFile": "/tmp/test-api.env",
+		},
+		{"name": "test: web", "type": "node", "request": "launch", "program": "index.js", "cwd": "web"},
+	}, doc.Configurations)
+
+	out, err = MergeVSCodeLaunch(nil, launches[1:])
+	require.NoError(t, err)
+	require.NoError(t, json.Unmarshal(out, &doc))
+	assert.Equal(t, "0.2.0", doc.Version)
+	assert.Len(t, doc.Configurations, 1)
+}
+
+func TestDebugCommandLine(t *testing.T) {
+	assert.Equal(t,
+		`cd 'svc dir' && env A='1' B='it'\''s' dlv debug './cmd/api' -- 'serve' '--x'`,
+		DebugCommandLine(&service.DebugLaunch{
+			Debugger: service.DebuggerGo,
+			Program:  "./cmd/api",
+			Args:     []string{"serve", "--x"},
+			Env:      map[string]string{"B": "it's", "A": "1"},
+			Cwd:      "svc dir",
+		}),
+	)
+	assert.Equal(t,
+		`node --inspect 'index.js'`,
+		DebugCommandLine(&service.DebugLaunch{Debugger: service.DebuggerNode, Program: "index.js"}),
+	)
+	assert.Empty(t, DebugCommandLine(&service.DebugLaunch{Debugger: "python", Program: "x"}))
+}
+
+func TestWriteDebugEnvFile(t *testing.T) {
+	dir := t.TempDir()
+	l := ServiceDebugLaunch{
+		Service:     "api",
+		Launch:      &service.DebugLaunch{Debugger: service.DebuggerGo, Program: "."},
+		ProvidedEnv: map[string]string{"PGPASSWORD": "secret"},
+	}
+	require.NoError(t, WriteDebugEnvFile(dir, &l))
+	assert.Equal(t, filepath.Join(dir, "test-api.env"), l.EnvFile)
+	fi, err := os.Stat(l.EnvFile)
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0o600), fi.Mode().Perm())
+	content, err := os.ReadFile(l.EnvFile)
+	require.NoError(t, err)
+	assert.Equal(t, "PGPASSWORD=\"secret\"\n", string(content))
+
+	// nothing to write
+	l = ServiceDebugLaunch{Service: "web", Launch: l.Launch}
+	require.NoError(t, WriteDebugEnvFile(dir, &l))
+	assert.Empty(t, l.EnvFile)
+}
@@ -0,0 +1,105 @@
+package stack
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"maps"
+	"slices"
+	"strings"
+
+	"fastcat.org/go/gdev/service"
+)
+
+// EnvFormats are the formats supported by [WriteEnv].
+var EnvFormats = []string{"export", "dotenv", "json"}
+
+// Env gets the environment variables published by the services selected by
+// the options, which are as for [Start]. Pre-start hooks are not run.
+func Env(ctx context.Context, opts ...any) (map[string]string, error) {
+	ctx, sel, err := startContext(ctx, opts)
+	if err != nil {
+		return nil, err
+	}
+	infra, svcs, err := sel.selected()
+	if err != nil {
+		return nil, err
+	}
+	return ServicesEnv(ctx, append(infra, svcs...)...)
+}
+
+// ServicesEnv merges the environment variables published by the given services
+// (see [service.EnvProvider]). It is an error for two services to publish
+// different values for the same variable.
+func ServicesEnv(ctx context.Context, svcs ...service.Service) (map[string]string, error) {
+	env := map[string]string{}
+	from := map[string]string{}
+	for _, svc := range svcs {
+		svcEnv, err := service.Env(ctx, svc)
+		if err != nil {
+			return nil, fmt.Errorf("error getting environment for service %s: %w", svc.Name(), err)
+		}
+		for _, k := range slices.Sorted(maps.Keys(svcEnv)) {
+			v := svcEnv[k]
+			if prev, ok := env[k]; ok && prev != v {
+				return nil, fmt.Errorf(
+					"services %s and %s provide conflicting values for %s",
+					from[k], svc.Name(), k,
+				)
+			}
+			env[k], from[k] = v, svc.Name()
+		}
+	}
+	return env, nil
+}
+
+// dependenciesEnv is the default source for [service.ProvidedEnv]: it merges
+// the environment published by the services svc depends on, skipping any that
+// are disabled or excluded.
+func dependenciesEnv(ctx context.Context, svc service.Service) (map[string]string, error) {
+	var deps []service.Service
+	for _, name := range service.Dependencies(svc) {
+		if m, _ := service.ServiceMode(ctx, name); m == service.ModeDisabled || m == service.ModeExcluded {
+			continue
+		}
+		if dep := ServiceByName(name); dep != nil {
+			deps = append(deps, dep)
+		}
+	}
+	return ServicesEnv(ctx, deps...)
+}
+
+// WriteEnv writes the environment variables to w in the given format, one of
+// [EnvFormats]. Variables are written in sorted order.
+func WriteEnv(env map[string]string, format string, w io.Writer) error {
+	switch format {
+	case "json":
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(env)
+	case "export", "dotenv":
+		prefix, quote := "export ", shellQuote
+		if format == "dotenv" {
+			prefix, quote = "", dotenvQuote
+		}
+		for _, k := range slices.Sorted(maps.Keys(env)) {
+			if _, err := fmt.Fprintf(w, "%s%s=%s\n", prefix, k, quote(env[k])); err != nil {
+				return err
+			}
+		}
+		return nil
+	default:
+		return fmt.Errorf("invalid env format %q", format)
+	}
+}
+
+func shellQuote(v string) string {
+	return "'" + strings.ReplaceAll(v, "'", `'\''`) + "'"
+}
+
+var dotenvEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`, `$`, `\$`)
+
+func dotenvQuote(v string) string {
+	return `"` + dotenvEscaper.Replace(v) + `"`
+}
@@ -0,0 +1,120 @@
+package stack
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"fastcat.org/go/gdev/addons/stack/stacktest"
+	"fastcat.org/go/gdev/resource"
+	"fastcat.org/go/gdev/service"
+)
+
+func TestServicesEnv(t *testing.T) {
+	ready := resource.Waiter("ready", func(context.Context) (bool, error) { return true, nil })
+	a := service.New("a",
+		service.WithResources(ready),
+		service.WithEnv(map[string]string{"A_HOST": "localhost", "SHARED": "x"}),
+	)
+	b := service.New("b",
+		service.WithResources(ready),
+		service.WithEnv(map[string]string{"B_PORT": "1234", "SHARED": "x"}),
+	)
+	c := service.New("c",
+		service.WithResources(ready),
+		service.WithEnv(map[string]string{"SHARED": "y"}),
+	)
+	d := service.New("d", service.WithResources(ready))
+
+	env, err := ServicesEnv(t.Context(), a, b, d)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"A_HOST": "localhost", "B_PORT": "1234", "SHARED": "x"}, env)
+
+	_, err = ServicesEnv(t.Context(), a, c)
+	assert.ErrorContains(t, err, "services a and c provide conflicting values for SHARED")
+}
+
+func TestWriteEnv(t *testing.T) {
+	env := map[string]string{"B": `it's "$x"`, "A": "1"}
+	for _, tt := range []struct {
+		format string
+		want   string
+	}{
+		{"export", "export A='1'\nexport B='it'\\''s \"$x\"'\n"},
+		{"dotenv", "A=\"1\"\nB=\"it's \\\"\\$x\\\"\"\n"},
+		{"json", "{\n  \"A\": \"1\",\n  \"B\": \"it's \\\"$x\\\"\"\n}\n"},
+	} {
+		t.Run(tt.format, func(t *testing.T) {
+			var sb strings.Builder
+			require.NoError(t, WriteEnv(env, tt.format, &sb))
+			assert.Equal(t, tt.want, sb.String())
+		})
+	}
+	assert.Error(t, WriteEnv(env, "yaml", &strings.Builder{}))
+}
+
+// envRecorder records the provided environment it is started with.
+type envRecorder struct {
+	id  string
+	mu  *sync.Mutex
+	got map[string]map[string]string
+}
+
+func (r envRecorder) ID() string { return r.id }
+
+func (r envRecorder) Start(ctx context.Context) error {
+	env, err := service.ProvidedEnv(ctx)
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.got[r.id] = env
+	return err
+}
+
+func (r envRecorder) Stop(context.Context) error { return nil }
+
+func (r envRecorder) Ready(context.Context) (bool, error) { return true, nil }
+
+func TestStartServices_providedEnv(t *testing.T) {
+	stacktest.ResetServices()
+	t.Cleanup(stacktest.ResetServices)
+	ready := resource.Waiter("ready", func(context.Context) (bool, error) { return true, nil })
+	var mu sync.Mutex
+	got := map[string]map[string]string{}
+	consumer := func(name string) resource.Resource { return envRecorder{name, &mu, got} }
+	// two instances of the same kind of service publish conflicting values, but
+	// no one service depends on both
+	db1 := service.New("db1",
+		service.WithResources(ready),
+		service.WithEnv(map[string]string{"DB_PASSWORD": "1"}),
+	)
+	db2 := service.New("db2",
+		service.WithResources(ready),
+		service.WithEnv(map[string]string{"DB_PASSWORD": "2"}),
+	)
+	cache := service.New("cache",
+		service.WithResources(ready),
+		service.WithEnv(map[string]string{"CACHE_HOST": "x"}),
+	)
+	app := service.New("app", service.WithResources(consumer("app")), service.WithDependsOn("db1", "cache"))
+	other := service.New("other", service.WithResources(consumer("other")), service.WithDependsOn("db2"))
+	lone := service.New("lone", service.WithResources(consumer("lone")))
+	for _, svc := range []service.Service{db1, db2, cache, app, other, lone} {
+		AddService(svc)
+	}
+
+	ctx, _, err := startContext(t.Context(), []any{
+		service.WithServiceModes(map[string]service.Mode{"cache": service.ModeExcluded}),
+		service.WithoutServiceWait(),
+	})
+	require.NoError(t, err)
+	require.NoError(t, StartServices(ctx, "stack", AllServices()...))
+	assert.Equal(t, map[string]map[string]string{
+		"app":   {"DB_PASSWORD": "1"},
+		"other": {"DB_PASSWORD": "2"},
+		"lone":  {},
+	}, got)
+}
@@ -0,0 +1,184 @@
+package stack
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"fastcat.org/go/gdev/service"
+)
+
+// serviceGraph captures the dependency relationships among a set of services,
+// as declared with [service.WithDependsOn].
+type serviceGraph struct {
+	svcs []service.Service
+	// deps[i] holds the indexes in svcs of the services svcs[i] depends on.
+	deps [][]int
+	// dependents[i] holds the indexes in svcs of the services that depend on
+	// svcs[i].
+	dependents [][]int
+}
+
+// newServiceGraph builds the dependency graph for the given services.
+//
+// Dependencies on registered services that are not in svcs are assumed to be
+// handled separately (e.g. infrastructure started before the stack services),
+// and are ignored. Dependencies on services that are not registered at all, or
+// from infrastructure on non-infrastructure services, are errors, as are
+// dependency cycles.
+func newServiceGraph(svcs []service.Service) (*serviceGraph, error) {
+	g := &serviceGraph{
+		svcs:       svcs,
+		deps:       make([][]int, len(svcs)),
+		dependents: make([][]int, len(svcs)),
+	}
+	index := make(map[string]int, len(svcs))
+	for i, svc := range svcs {
+		if _, ok := index[svc.Name()]; ok {
+			return nil, fmt.Errorf("duplicate service %s", svc.Name())
+		}
+		index[svc.Name()] = i
+	}
+	var errs []error
+	for i, svc := range svcs {
+		for _, dep := range service.Dependencies(svc) {
+			if j, ok := index[dep]; ok {
+				g.deps[i] = append(g.deps[i], j)
+				g.dependents[j] = append(g.dependents[j], i)
+			} else if ServiceByName(dep) == nil {
+				errs = append(errs, fmt.Errorf("service %s depends on unknown service %s", svc.Name(), dep))
+			} else if slices.Contains(infraOrder, svc.Name()) && !slices.Contains(infraOrder, dep) {
+				errs = append(errs, fmt.Errorf(
+					"infrastructure %s cannot depend on non-infrastructure service %s",
+					svc.Name(), dep,
+				))
+			}
+		}
+	}
+	if len(errs) != 0 {
+		return nil, errors.Join(errs...)
+	}
+	if cycle := g.findCycle(); cycle != nil {
+		names := make([]string, 0, len(cycle))
+		for _, i := range cycle {
+			names = append(names, svcs[i].Name())
+		}
+		return nil, fmt.Errorf("service dependency cycle: %s", strings.Join(names, " -> "))
+	}
+	return g, nil
+}
+
+// findCycle returns the indexes of a dependency cycle in the graph, with the
+// first element repeated at the end, or nil if there is no cycle.
+func (g *serviceGraph) findCycle() []int {
+	const (
+		unvisited = iota
+		visiting
+		visited
+	)
+	state := make([]int, len(g.svcs))
+	var stack []int
+	var visit func(i int) []int
+	visit = func(i int) []int {
+		state[i] = visiting
+		stack = append(stack, i)
+		for _, j := range g.deps[i] {
+			switch state[j] {
+			case visiting:
+				start := slices.Index(stack, j)
+				return append(slices.Clone(stack[start:]), j)
+			case unvisited:
+				if cycle := visit(j); cycle != nil {
+					return cycle
+				}
+			}
+		}
+		stack = stack[:len(stack)-1]
+		state[i] = visited
+		return nil
+	}
+	for i := range g.svcs {
+		if state[i] == unvisited {
+			if cycle := visit(i); cycle != nil {
+				return cycle
+			}
+		}
+	}
+	return nil
+}
+
+// walk calls fn for every service in the graph, only after it has completed
+// for all of the service's dependencies, or if reverse is set, for all of its
+// dependents.
+//
+// If parallel is set, services whose prerequisites are complete will be handled
+// concurrently. Otherwise they will be handled one at a time, preferring
+// registration order (or its reverse) where the dependencies allow.
+//
+// If keepGoing is false, no further calls to fn will be started after one
+// fails, though those already in progress will be allowed to finish. All errors
+// are joined and returned.
+func (g *serviceGraph) walk(
+	ctx context.Context,
+	reverse, parallel, keepGoing bool,
+	fn func(context.Context, service.Service) error,
+) error {
+	before, after := g.deps, g.dependents
+	if reverse {
+		before, after = after, before
+	}
+	remaining := make([]int, len(g.svcs))
+	var ready []int
+	for i := range g.svcs {
+		remaining[i] = len(before[i])
+		if remaining[i] == 0 {
+			ready = append(ready, i)
+		}
+	}
+	next := func() int {
+		// keep ready sorted so we can follow registration order
+		slices.Sort(ready)
+		var i int
+		if reverse {
+			i, ready = ready[len(ready)-1], ready[:len(ready)-1]
+		} else {
+			i, ready = ready[0], ready[1:]
+		}
+		return i
+	}
+
+	type result struct {
+		i   int
+		err error
+	}
+	done := make(chan result)
+	running := 0
+	failed := false
+	var errs []error
+	for {
+		for len(ready) > 0 && !failed && (parallel || running == 0) {
+			i := next()
+			running++
+			go func() { done <- result{i, fn(ctx, g.svcs[i])} }()
+		}
+		if running == 0 {
+			break
+		}
+		r := <-done
+		running--
+		if r.err != nil {
+			errs = append(errs, r.err)
+			if !keepGoing {
+				failed = true
+			}
+		}
+		for _, j := range after[r.i] {
+			if remaining[j]--; remaining[j] == 0 {
+				ready = append(ready, j)
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
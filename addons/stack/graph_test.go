@@ -0,0 +1,164 @@
+package stack
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"fastcat.org/go/gdev/addons/stack/stacktest"
+	"fastcat.org/go/gdev/resource"
+	"fastcat.org/go/gdev/service"
+)
+
+func testServices(t *testing.T, deps map[string][]string, order ...string) []service.Service {
+	t.Helper()
+	stacktest.ResetServices()
+	t.Cleanup(stacktest.ResetServices)
+	svcs := make([]service.Service, 0, len(order))
+	for _, name := range order {
+		svc := service.New(name,
+			service.WithResources(resource.Waiter(name, func(context.Context) (bool, error) { return true, nil })),
+			service.WithDependsOn(deps[name]...),
+		)
+		AddService(svc)
+		svcs = append(svcs, svc)
+	}
+	return svcs
+}
+
+func Test_serviceGraph_walk(t *testing.T) {
+	tests := []struct {
+		name        string
+		deps        map[string][]string
+		order       []string
+		wantForward []string
+		wantReverse []string
+	}{
+		{
+			name:        "no deps",
+			order:       []string{"a", "b", "c"},
+			wantForward: []string{"a", "b", "c"},
+			wantReverse: []string{"c", "b", "a"},
+		},
+		{
+			name:        "reverse chain",
+			deps:        map[string][]string{"a": {"b"}, "b": {"c"}},
+			order:       []string{"a", "b", "c"},
+			wantForward: []string{"c", "b", "a"},
+			wantReverse: []string{"a", "b", "c"},
+		},
+		{
+			name:        "diamond",
+			deps:        map[string][]string{"a": {"b", "c"}, "b": {"d"}, "c": {"d"}},
+			order:       []string{"a", "b", "c", "d", "e"},
+			wantForward: []string{"d", "b", "c", "a", "e"},
+			wantReverse: []string{"e", "a", "c", "b", "d"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g, err := newServiceGraph(testServices(t, tt.deps, tt.order...))
+			require.NoError(t, err)
+			walkOrder := func(reverse bool) []string {
+				var ret []string
+				require.NoError(t, g.walk(t.Context(), reverse, false, false,
+					func(_ context.Context, svc service.Service) error {
+						ret = append(ret, svc.Name())
+						return nil
+					},
+				))
+				return ret
+			}
+			assert.Equal(t, tt.wantForward, walkOrder(false))
+			assert.Equal(t, tt.wantReverse, walkOrder(true))
+		})
+	}
+}
+
+func Test_serviceGraph_walk_parallel(t *testing.T) {
+	deps := map[string][]string{"a": {"b", "c"}, "b": {"d"}, "c": {"d"}}
+	g, err := newServiceGraph(testServices(t, deps, "a", "b", "c", "d", "e"))
+	require.NoError(t, err)
+	for _, reverse := range []bool{false, true} {
+		t.Run(fmt.Sprintf("reverse=%v", reverse), func(t *testing.T) {
+			var mu sync.Mutex
+			var done []string
+			require.NoError(t, g.walk(t.Context(), reverse, true, false,
+				func(_ context.Context, svc service.Service) error {
+					mu.Lock()
+					defer mu.Unlock()
+					check := service.Dependencies(svc)
+					if reverse {
+						check = nil
+						for n, d := range deps {
+							if slices.Contains(d, svc.Name()) {
+								check = append(check, n)
+							}
+						}
+					}
+					for _, d := range check {
+						assert.Contains(t, done, d, "%s before %s", d, svc.Name())
+					}
+					done = append(done, svc.Name())
+					return nil
+				},
+			))
+			assert.Len(t, done, 5)
+		})
+	}
+}
+
+func Test_serviceGraph_walk_errors(t *testing.T) {
+	deps := map[string][]string{"a": {"b"}}
+	g, err := newServiceGraph(testServices(t, deps, "a", "b", "c"))
+	require.NoError(t, err)
+	fail := func(_ context.Context, svc service.Service) error {
+		if svc.Name() == "b" {
+			return fmt.Errorf("boom")
+		}
+		return nil
+	}
+	var visited []string
+	record := func(ctx context.Context, svc service.Service) error {
+		visited = append(visited, svc.Name())
+		return fail(ctx, svc)
+	}
+	assert.Error(t, g.walk(t.Context(), false, false, false, record))
+	assert.Equal(t, []string{"b"}, visited)
+	visited = nil
+	assert.Error(t, g.walk(t.Context(), false, false, true, record))
+	assert.Equal(t, []string{"b", "a", "c"}, visited)
+}
+
+func Test_newServiceGraph_errors(t *testing.T) {
+	tests := []struct {
+		name    string
+		deps    map[string][]string
+		order   []string
+		wantErr string
+	}{
+		{
+			name:    "unknown",
+			deps:    map[string][]string{"a": {"x"}},
+			order:   []string{"a"},
+			wantErr: "service a depends on unknown service x",
+		},
+		{
+			name:    "cycle",
+			deps:    map[string][]string{"a": {"b"}, "b": {"c"}, "c": {"a"}},
+			order:   []string{"a", "b", "c", "d"},
+			wantErr: "service dependency cycle: a -> b -> c -> a",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := newServiceGraph(testServices(t, tt.deps, tt.order...))
+			assert.EqualError(t, err, tt.wantErr)
+		})
+	}
+}
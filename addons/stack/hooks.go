@@ -3,6 +3,7 @@ package stack
 import (
 	"context"
 	"fmt"
+	"time"
 
 	sInternal "fastcat.org/go/gdev/addons/stack/internal"
 	"fastcat.org/go/gdev/internal"
@@ -106,7 +107,9 @@ func PreStartHookFuncs(
 	}
 }
 
-func preStart(ctx context.Context) (infra, svcs []service.Service, _ error) {
+// preStart runs the pre-start hooks for the selected services, returning the
+// selected infrastructure and stack services.
+func preStart(ctx context.Context, sel ServiceSelection) (infra, svcs []service.Service, _ error) {
 	internal.CheckLockedDown()
 	hooks := make([]PreStartHook, 0, len(preStartHookFactories))
 	for _, factory := range preStartHookFactories {
@@ -114,19 +117,32 @@ func preStart(ctx context.Context) (infra, svcs []service.Service, _ error) {
 		hooks = append(hooks, hook)
 	}
 	for _, hook := range hooks {
-		if err := hook.LoadServices(ctx); err != nil {
+		start := time.Now()
+		err := hook.LoadServices(ctx)
+		recordStartStep(ctx, TimingHook, hook.Name()+" load", start)
+		if err != nil {
 			return nil, nil, fmt.Errorf("error loading services in pre-start hook %s: %w", hook.Name(), err)
 		}
 	}
-	infra, svcs = AllInfrastructure(), AllServices()
+	// resolve the selection after loading, as the hooks may add services
+	infra, svcs, err := sel.selected()
+	if err != nil {
+		return nil, nil, err
+	}
 	for _, hook := range hooks {
-		if err := hook.BeforeServices(ctx, infra, svcs); err != nil {
+		start := time.Now()
+		err := hook.BeforeServices(ctx, infra, svcs)
+		recordStartStep(ctx, TimingHook, hook.Name()+" before", start)
+		if err != nil {
 			return nil, nil, fmt.Errorf("error running pre-start hook %s: %w", hook.Name(), err)
 		}
 	}
 	for _, svc := range svcs {
 		for _, hook := range hooks {
-			if err := hook.Service(ctx, svc); err != nil {
+			start := time.Now()
+			err := hook.Service(ctx, svc)
+			recordStartStep(ctx, TimingHook, hook.Name()+" "+svc.Name(), start)
+			if err != nil {
 				return nil, nil, fmt.Errorf(
 					"error running pre-start hook %s for service %s: %w",
 					hook.Name(), svc.Name(), err,
@@ -135,7 +151,10 @@ func preStart(ctx context.Context) (infra, svcs []service.Service, _ error) {
 		}
 	}
 	for _, hook := range hooks {
-		if err := hook.AfterServices(ctx, infra, svcs); err != nil {
+		start := time.Now()
+		err := hook.AfterServices(ctx, infra, svcs)
+		recordStartStep(ctx, TimingHook, hook.Name()+" after", start)
+		if err != nil {
 			return nil, nil, fmt.Errorf("error running pre-start hook %s: %w", hook.Name(), err)
 		}
 	}
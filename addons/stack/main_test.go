@@ -0,0 +1,39 @@
+package stack
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"fastcat.org/go/gdev/internal"
+	gConfig "fastcat.org/go/gdev/lib/config"
+	"fastcat.org/go/gdev/service"
+)
+
+func TestMain(m *testing.M) {
+	// allow tests to access the service registry
+	internal.SetAppName("test")
+	service.AddDefaultModeProfile(testBuiltinProfile, map[string]service.Mode{"svc": service.ModeLocal})
+	internal.LockCustomizations()
+	os.Exit(run(m)) //nolint:forbidigo // entrypoint
+}
+
+func run(m *testing.M) int {
+	// keep the tests away from the real config file
+	home, err := os.MkdirTemp("", "stack-test-home")
+	if err != nil {
+		panic(err)
+	}
+	defer os.RemoveAll(home) //nolint:errcheck
+	if err := os.Mkdir(filepath.Join(home, ".config"), 0o755); err != nil {
+		panic(err)
+	}
+	if err := os.Setenv("HOME", home); err != nil {
+		panic(err)
+	}
+	if err := gConfig.Initialize(); err != nil {
+		panic(fmt.Errorf("failed to initialize config: %w", err))
+	}
+	return m.Run()
+}
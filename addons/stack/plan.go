@@ -0,0 +1,113 @@
+package stack
+
+import (
+	"context"
+	"io"
+	"strings"
+	"sync"
+
+	"github.com/jedib0t/go-pretty/v6/table"
+
+	"fastcat.org/go/gdev/resource"
+	"fastcat.org/go/gdev/service"
+)
+
+// ServicePlan describes what starting a service would do.
+type ServicePlan struct {
+	Name string `json:"name"`
+	// Kind is either "infrastructure" or "stack"
+	Kind      string         `json:"kind"`
+	Mode      service.Mode   `json:"mode"`
+	Resources []ResourcePlan `json:"resources"`
+	// Error is set if the service's resources could not be resolved.
+	Error string `json:"error,omitempty"`
+}
+
+// ResourcePlan describes what starting a single resource would do.
+type ResourcePlan struct {
+	ID   string        `json:"id"`
+	Plan resource.Plan `json:"plan"`
+	// Error is set if planning the resource failed.
+	Error string `json:"error,omitempty"`
+}
+
+// Plan computes what [Start] would do with the same options, without changing
+// anything. The resource context is put in dry-run mode (see
+// [resource.WithDryRun]), and pre-start hooks such as builds are not run.
+func Plan(ctx context.Context, opts ...any) ([]ServicePlan, error) {
+	ctx, sel, err := startContext(ctx, opts, resource.WithDryRun())
+	if err != nil {
+		return nil, err
+	}
+	infra, svcs, err := sel.selected()
+	if err != nil {
+		return nil, err
+	}
+	ret := make([]ServicePlan, 0, len(infra)+len(svcs))
+	for _, svc := range infra {
+		ret = append(ret, ServicePlan{Name: svc.Name(), Kind: "infrastructure"})
+	}
+	for _, svc := range svcs {
+		ret = append(ret, ServicePlan{Name: svc.Name(), Kind: "stack"})
+	}
+	var wg sync.WaitGroup
+	for i := range ret {
+		sp := &ret[i]
+		sp.Mode, _ = service.ServiceMode(ctx, sp.Name)
+		if sp.Mode == service.ModeExcluded {
+			continue
+		}
+		svc := ServiceByName(sp.Name)
+		rs, err := startResources(ctx, svc)
+		if err != nil {
+			sp.Error = err.Error()
+		}
+		sp.Resources = make([]ResourcePlan, len(rs))
+		svcCtx := service.WithCurrentService(ctx, svc)
+		for j, r := range rs {
+			wg.Go(func() { sp.Resources[j] = resourcePlan(svcCtx, r) })
+		}
+	}
+	wg.Wait()
+	return ret, nil
+}
+
+func resourcePlan(ctx context.Context, r resource.Resource) ResourcePlan {
+	rp := ResourcePlan{ID: r.ID()}
+	var err error
+	if rp.Plan, err = resource.PlanStart(ctx, r); err != nil {
+		rp.Plan.Action = resource.PlanUnknown
+		rp.Error = err.Error()
+	}
+	return rp
+}
+
+// PlanTable renders the plans as a table.
+func PlanTable(plans []ServicePlan, out io.Writer) {
+	tw := table.NewWriter()
+	tw.SetStyle(table.StyleColoredBlueWhiteOnBlack)
+	tw.SetOutputMirror(out)
+	tw.AppendHeader(table.Row{"Service", "Kind", "Mode", "Resource", "Action", "Changes"})
+	tw.AppendSeparator()
+	for _, sp := range plans {
+		if sp.Error != "" {
+			tw.AppendRow(table.Row{sp.Name, sp.Kind, sp.Mode, "", "❌", sp.Error})
+		}
+		if sp.Mode == service.ModeExcluded {
+			tw.AppendRow(table.Row{sp.Name, sp.Kind, sp.Mode, "", "skip", "excluded services are left alone"})
+		}
+		for _, rp := range sp.Resources {
+			changes := strings.Join(rp.Plan.Changes, ", ")
+			if rp.Error != "" {
+				changes = rp.Error
+			}
+			tw.AppendRow(table.Row{sp.Name, sp.Kind, sp.Mode, rp.ID, rp.Plan.Action, changes})
+		}
+	}
+	tw.SetColumnConfigs([]table.ColumnConfig{
+		{Number: 1, AutoMerge: true},
+		{Number: 2, AutoMerge: true},
+		{Number: 3, AutoMerge: true},
+	})
+	tw.Render()
+}
@@ -0,0 +1,55 @@
+package stack
+
+import (
+	"bytes"
+	"context"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"fastcat.org/go/gdev/addons/stack/stacktest"
+	"fastcat.org/go/gdev/resource"
+	"fastcat.org/go/gdev/service"
+)
+
+func TestPlan(t *testing.T) {
+	stacktest.ResetServices()
+	t.Cleanup(stacktest.ResetServices)
+	var mu sync.Mutex
+	var calls []string
+	ready := func(context.Context) (bool, error) { return true, nil }
+	AddInfrastructure(service.New("infra", service.WithResources(resource.Waiter("db", ready))))
+	AddService(service.New("svc1", service.WithResources(recordingResource{"a", &mu, &calls})))
+	AddService(service.New("svc2", service.WithResources(recordingResource{"b", &mu, &calls})))
+	AddService(service.New("svc3", service.WithResources(recordingResource{"c", &mu, &calls})))
+
+	plans, err := Plan(t.Context(),
+		service.WithServiceModes(map[string]service.Mode{
+			"svc2": service.ModeDisabled,
+			"svc3": service.ModeExcluded,
+		}),
+	)
+	require.NoError(t, err)
+	assert.Empty(t, calls, "planning must not start or stop anything")
+	assert.Equal(t, []ServicePlan{
+		{
+			Name: "infra", Kind: "infrastructure", Mode: service.ModeDefault,
+			Resources: []ResourcePlan{{ID: "Wait/db", Plan: resource.Plan{Action: resource.PlanNone}}},
+		},
+		{
+			Name: "svc1", Kind: "stack", Mode: service.ModeDefault,
+			Resources: []ResourcePlan{{ID: "a", Plan: resource.Plan{Action: resource.PlanUnknown}}},
+		},
+		{
+			Name: "svc2", Kind: "stack", Mode: service.ModeDisabled,
+			Resources: []ResourcePlan{{ID: "anti/b", Plan: resource.Plan{Action: resource.PlanStop}}},
+		},
+		{Name: "svc3", Kind: "stack", Mode: service.ModeExcluded},
+	}, plans)
+
+	var buf bytes.Buffer
+	PlanTable(plans, &buf)
+	assert.Contains(t, buf.String(), "excluded services are left alone")
+}
@@ -0,0 +1,177 @@
+package stack
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	gConfig "fastcat.org/go/gdev/lib/config"
+	"fastcat.org/go/gdev/service"
+)
+
+// profileCommand builds the `config profile` command tree for managing named
+// snapshots of the service modes.
+func profileCommand() *cobra.Command {
+	profileCmd := &cobra.Command{
+		Use:   "profile",
+		Short: "manage named profiles of service modes",
+		// just a parent for other commands
+	}
+	profileCmd.AddCommand(
+		&cobra.Command{
+			Use:               "save <name>",
+			Short:             "save the current service modes as a named profile",
+			Args:              cobra.ExactArgs(1),
+			ValidArgsFunction: completeProfileNames,
+			RunE: func(cmd *cobra.Command, args []string) error {
+				if err := service.SaveModeProfile(args[0], service.ConfiguredModes()); err != nil {
+					return err
+				}
+				if err := gConfig.Save(); err != nil {
+					return fmt.Errorf("failed to save config: %w", err)
+				}
+				fmt.Printf("saved current service modes as profile %s\n", args[0])
+				return nil
+			},
+		},
+		&cobra.Command{
+			Use:               "use <name>",
+			Short:             "replace the current service modes with those from a profile",
+			Args:              cobra.ExactArgs(1),
+			ValidArgsFunction: completeProfileNames,
+			RunE: func(cmd *cobra.Command, args []string) error {
+				if err := service.UseModeProfile(args[0]); err != nil {
+					return err
+				}
+				if err := gConfig.Save(); err != nil {
+					return fmt.Errorf("failed to save config: %w", err)
+				}
+				fmt.Printf("service modes set from profile %s\n", args[0])
+				return nil
+			},
+		},
+		&cobra.Command{
+			Use:   "list",
+			Short: "list the available profiles",
+			Args:  cobra.NoArgs,
+			RunE: func(cmd *cobra.Command, args []string) error {
+				names := service.ModeProfileNames()
+				if len(names) == 0 {
+					fmt.Println("No profiles defined")
+					return nil
+				}
+				current := service.ConfiguredModes()
+				for _, name := range names {
+					modes, _ := service.ModeProfile(name)
+					var notes []string
+					if service.IsDefaultModeProfile(name) {
+						notes = append(notes, "built-in")
+					}
+					if maps.Equal(modes, current) {
+						notes = append(notes, "active")
+					}
+					suffix := ""
+					if len(notes) != 0 {
+						suffix = " (" + strings.Join(notes, ", ") + ")"
+					}
+					fmt.Printf("%s%s: %s\n", name, suffix, formatModes(modes))
+				}
+				return nil
+			},
+		},
+		&cobra.Command{
+			Use:               "diff <name> [other]",
+			Short:             "show how a profile differs from the current modes, or from another profile",
+			Args:              cobra.RangeArgs(1, 2),
+			ValidArgsFunction: completeProfileNames,
+			RunE: func(cmd *cobra.Command, args []string) error {
+				fromName, from := "current", service.ConfiguredModes()
+				to, ok := service.ModeProfile(args[0])
+				if !ok {
+					return fmt.Errorf("unknown mode profile %q", args[0])
+				}
+				if len(args) == 2 {
+					fromName, from = args[0], to
+					if to, ok = service.ModeProfile(args[1]); !ok {
+						return fmt.Errorf("unknown mode profile %q", args[1])
+					}
+				}
+				diffs := diffModes(from, to)
+				if len(diffs) == 0 {
+					fmt.Println("No differences")
+					return nil
+				}
+				toName := args[len(args)-1]
+				fmt.Printf("service modes changing from %s to %s:\n", fromName, toName)
+				for _, d := range diffs {
+					fmt.Println("  " + d)
+				}
+				return nil
+			},
+		},
+		&cobra.Command{
+			Use:               "delete <name>",
+			Short:             "delete a saved profile",
+			Args:              cobra.ExactArgs(1),
+			ValidArgsFunction: completeProfileNames,
+			RunE: func(cmd *cobra.Command, args []string) error {
+				if err := service.DeleteModeProfile(args[0]); err != nil {
+					return err
+				}
+				if err := gConfig.Save(); err != nil {
+					return fmt.Errorf("failed to save config: %w", err)
+				}
+				fmt.Printf("deleted profile %s\n", args[0])
+				return nil
+			},
+		},
+	)
+	return profileCmd
+}
+
+// completeProfileNames provides shell completion for commands that take mode
+// profile names as args.
+func completeProfileNames(
+	_ *cobra.Command,
+	args []string,
+	toComplete string,
+) ([]string, cobra.ShellCompDirective) {
+	candidates := slices.DeleteFunc(service.ModeProfileNames(), func(n string) bool {
+		return !strings.HasPrefix(n, toComplete) || slices.Contains(args, n)
+	})
+	return candidates, cobra.ShellCompDirectiveNoFileComp
+}
+
+func formatModes(modes map[string]service.Mode) string {
+	if len(modes) == 0 {
+		return "all services in default mode"
+	}
+	parts := make([]string, 0, len(modes))
+	for _, name := range slices.Sorted(maps.Keys(modes)) {
+		parts = append(parts, fmt.Sprintf("%s=%s", name, modes[name]))
+	}
+	return strings.Join(parts, ", ")
+}
+
+// diffModes describes the changes in service modes from one map to another, in
+// sorted service order.
+func diffModes(from, to map[string]service.Mode) []string {
+	names := slices.Collect(maps.Keys(from))
+	for name := range to {
+		if !slices.Contains(names, name) {
+			names = append(names, name)
+		}
+	}
+	slices.Sort(names)
+	var diffs []string
+	for _, name := range names {
+		// missing entries are the zero value, i.e. default mode
+		if f, t := from[name], to[name]; f != t {
+			diffs = append(diffs, fmt.Sprintf("%s: %s -> %s", name, f, t))
+		}
+	}
+	return diffs
+}
@@ -0,0 +1,99 @@
+package stack
+
+import (
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"fastcat.org/go/gdev/service"
+)
+
+func Test_diffModes(t *testing.T) {
+	from := map[string]service.Mode{"a": service.ModeLocal, "b": service.ModeDebug}
+	to := map[string]service.Mode{"b": service.ModeDebug, "c": service.ModeExcluded}
+	assert.Equal(t, []string{
+		"a: local -> default",
+		"c: default -> excluded",
+	}, diffModes(from, to))
+	assert.Empty(t, diffModes(from, from))
+	assert.Empty(t, diffModes(nil, map[string]service.Mode{}))
+}
+
+func Test_formatModes(t *testing.T) {
+	assert.Equal(t, "all services in default mode", formatModes(nil))
+	assert.Equal(t, "a=local, b=debug", formatModes(map[string]service.Mode{
+		"b": service.ModeDebug,
+		"a": service.ModeLocal,
+	}))
+}
+
+// testBuiltinProfile is registered as an app-defined profile in TestMain.
+const testBuiltinProfile = "builtin"
+
+func runProfileCmd(t *testing.T, args ...string) error {
+	t.Helper()
+	cmd := profileCommand()
+	cmd.SetArgs(args)
+	cmd.SetOut(io.Discard)
+	cmd.SetErr(io.Discard)
+	return cmd.ExecuteContext(t.Context())
+}
+
+func resetModes() {
+	for name := range service.ConfiguredModes() {
+		service.SetMode(name, service.ModeDefault)
+	}
+}
+
+func TestProfileCommand(t *testing.T) {
+	t.Cleanup(resetModes)
+
+	t.Run("save use delete", func(t *testing.T) {
+		resetModes()
+		service.SetMode("a", service.ModeDebug)
+		require.NoError(t, runProfileCmd(t, "save", "dev"))
+		assert.Contains(t, service.ModeProfileNames(), "dev")
+
+		service.SetMode("a", service.ModeDefault)
+		require.NoError(t, runProfileCmd(t, "use", "dev"))
+		assert.Equal(t, map[string]service.Mode{"a": service.ModeDebug}, service.ConfiguredModes())
+
+		require.NoError(t, runProfileCmd(t, "delete", "dev"))
+		assert.NotContains(t, service.ModeProfileNames(), "dev")
+		assert.ErrorContains(t, runProfileCmd(t, "use", "dev"), "unknown mode profile")
+		assert.ErrorContains(t, runProfileCmd(t, "delete", "dev"), "unknown mode profile")
+	})
+
+	t.Run("default profile precedence", func(t *testing.T) {
+		resetModes()
+		modes, ok := service.ModeProfile(testBuiltinProfile)
+		require.True(t, ok)
+		assert.Equal(t, map[string]service.Mode{"svc": service.ModeLocal}, modes)
+		assert.True(t, service.IsDefaultModeProfile(testBuiltinProfile))
+		assert.ErrorContains(t, runProfileCmd(t, "delete", testBuiltinProfile), "built-in")
+
+		// a saved profile overrides the built-in one
+		service.SetMode("svc", service.ModeExcluded)
+		require.NoError(t, runProfileCmd(t, "save", testBuiltinProfile))
+		assert.False(t, service.IsDefaultModeProfile(testBuiltinProfile))
+		modes, _ = service.ModeProfile(testBuiltinProfile)
+		assert.Equal(t, map[string]service.Mode{"svc": service.ModeExcluded}, modes)
+
+		// deleting the override reveals the built-in one again
+		require.NoError(t, runProfileCmd(t, "delete", testBuiltinProfile))
+		assert.True(t, service.IsDefaultModeProfile(testBuiltinProfile))
+		require.NoError(t, runProfileCmd(t, "use", testBuiltinProfile))
+		assert.Equal(t, map[string]service.Mode{"svc": service.ModeLocal}, service.ConfiguredModes())
+	})
+
+	t.Run("invalid names", func(t *testing.T) {
+		for _, name := range []string{"", "has space", "a/b"} {
+			for _, op := range []string{"save", "use", "delete"} {
+				assert.ErrorContains(t, runProfileCmd(t, op, name), "invalid mode profile name", "%s %q", op, name)
+			}
+		}
+		assert.NotPanics(t, func() { _ = service.SaveModeProfile("a b", nil) })
+	})
+}
@@ -0,0 +1,66 @@
+package stack
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"fastcat.org/go/gdev/progress"
+	"fastcat.org/go/gdev/resource"
+	"fastcat.org/go/gdev/service"
+)
+
+// RestartServices restarts the resources of the given services that implement
+// [resource.Restarter], e.g. to pick up a new build of their source. Other
+// resources, such as containers or configuration, are left alone. Unless
+// [service.NoServiceWait] is set, it then waits for the restarted resources to
+// be ready.
+func RestartServices(ctx context.Context, svcs ...service.Service) error {
+	svcs = withoutExcluded(ctx, "restarting", "stack", svcs)
+	var resources []resource.Restarter
+	// the context to restart each resource with
+	var ctxs []context.Context
+	for _, svc := range svcs {
+		rs, err := startResources(ctx, svc)
+		if err != nil {
+			return err
+		}
+		for _, r := range rs {
+			// disabled services will have anti resources, which we skip
+			if rr, ok := r.(resource.Restarter); ok {
+				resources = append(resources, rr)
+				ctxs = append(ctxs, service.WithCurrentService(ctx, svc))
+			}
+		}
+	}
+	if len(resources) == 0 {
+		return nil
+	}
+	pt := &progress.Tracker{
+		Message: fmt.Sprintf("Restarting %d resources", len(resources)),
+		Total:   int64(len(resources)),
+		Units:   progress.UnitsDefault,
+	}
+	progress.AddTracker(ctx, pt)
+	started := make([]resource.Resource, 0, len(resources))
+	startedAt := make([]time.Time, 0, len(resources))
+	for i, r := range resources {
+		pt.UpdateMessage(fmt.Sprintf("Restarting %s", r.ID()))
+		if err := r.Restart(ctxs[i]); err != nil {
+			pt.MarkAsErrored()
+			return fmt.Errorf("failed to restart %s: %w", r.ID(), err)
+		}
+		started = append(started, r)
+		startedAt = append(startedAt, time.Now())
+		pt.Increment(1)
+	}
+	if !service.NoServiceWait(ctx) {
+		if err := waitResources(ctx, started, startedAt); err != nil {
+			pt.MarkAsErrored()
+			return err
+		}
+	}
+	pt.UpdateMessage(fmt.Sprintf("Restarted %d resources", len(resources)))
+	pt.MarkAsDone()
+	return nil
+}
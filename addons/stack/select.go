@@ -0,0 +1,57 @@
+package stack
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+
+	"fastcat.org/go/gdev/service"
+)
+
+// ServiceSelection limits an operation to the named services, instead of the
+// whole stack. It may be passed as an option to [Start], and is embedded in
+// [StackStopOptions].
+type ServiceSelection struct {
+	// Services are the names of the services (or infrastructure) to operate on.
+	// If empty, all services are selected.
+	Services []string
+	// WithDependencies adds the (transitive) dependencies of the named services
+	// to the selection, see [service.WithDependsOn].
+	WithDependencies bool
+}
+
+// selected returns the infrastructure and stack services in the selection, in
+// registration order.
+func (s ServiceSelection) selected() (infra, svcs []service.Service, _ error) {
+	infra, svcs = AllInfrastructure(), AllServices()
+	if len(s.Services) == 0 {
+		return infra, svcs, nil
+	}
+	names := make(map[string]bool, len(s.Services))
+	var errs []error
+	var add func(name string)
+	add = func(name string) {
+		if names[name] {
+			return
+		}
+		svc := ServiceByName(name)
+		if svc == nil {
+			errs = append(errs, fmt.Errorf("unknown service %q", name))
+			return
+		}
+		names[name] = true
+		if s.WithDependencies {
+			for _, dep := range service.Dependencies(svc) {
+				add(dep)
+			}
+		}
+	}
+	for _, name := range s.Services {
+		add(name)
+	}
+	if len(errs) != 0 {
+		return nil, nil, errors.Join(errs...)
+	}
+	notSelected := func(svc service.Service) bool { return !names[svc.Name()] }
+	return slices.DeleteFunc(infra, notSelected), slices.DeleteFunc(svcs, notSelected), nil
+}
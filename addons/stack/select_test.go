@@ -0,0 +1,44 @@
+package stack
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"fastcat.org/go/gdev/resource"
+	"fastcat.org/go/gdev/service"
+)
+
+func TestServiceSelection_selected(t *testing.T) {
+	testServices(t, map[string][]string{"b": {"a"}, "c": {"b", "db"}}, "a", "b", "c", "d")
+	AddInfrastructure(service.New("db",
+		service.WithResources(resource.Waiter("db", func(context.Context) (bool, error) { return true, nil })),
+	))
+	names := func(svcs []service.Service) []string {
+		var ret []string
+		for _, svc := range svcs {
+			ret = append(ret, svc.Name())
+		}
+		return ret
+	}
+
+	infra, svcs, err := ServiceSelection{}.selected()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"db"}, names(infra))
+	assert.Equal(t, []string{"a", "b", "c", "d"}, names(svcs))
+
+	infra, svcs, err = ServiceSelection{Services: []string{"d", "c"}}.selected()
+	require.NoError(t, err)
+	assert.Empty(t, infra)
+	assert.Equal(t, []string{"c", "d"}, names(svcs))
+
+	infra, svcs, err = ServiceSelection{Services: []string{"c"}, WithDependencies: true}.selected()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"db"}, names(infra))
+	assert.Equal(t, []string{"a", "b", "c"}, names(svcs))
+
+	_, _, err = ServiceSelection{Services: []string{"nope", "a"}}.selected()
+	assert.ErrorContains(t, err, `unknown service "nope"`)
+}
@@ -4,6 +4,8 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"slices"
+	"strings"
 	"time"
 
 	"github.com/spf13/pflag"
@@ -26,35 +28,30 @@ func AddStartFlaggers(fns ...func(*pflag.FlagSet, cmd.FlagCompletionRegistrar) e
 //
 // Options must be of type [service.ContextOption] or [resource.ContextOption],
 // and will be passed to [service.NewContext] and [resource.NewContext]
-// respectively.
+// respectively, or a [ServiceSelection] to start only some services, or a
+// *[StartTiming] to record how long each step takes.
 func Start(ctx context.Context, opts ...any) error {
 	ctx, stop := progress.StartWriter(ctx)
 	defer stop()
 
-	// TODO: make progress printing pluggable
-	var svcOpts []service.ContextOption
-	var rcOpts []resource.ContextOption
-	for _, opt := range opts {
-		switch o := opt.(type) {
-		case service.ContextOption:
-			svcOpts = append(svcOpts, o)
-		case resource.ContextOption:
-			rcOpts = append(rcOpts, o)
-		default:
-			return fmt.Errorf(
-				"unexpected option type %T, expected service.ContextOption or resource.ContextOption",
-				o,
-			)
+	var timing *StartTiming
+	opts = slices.DeleteFunc(slices.Clone(opts), func(opt any) bool {
+		t, ok := opt.(*StartTiming)
+		if ok {
+			timing = t
 		}
+		return ok
+	})
+	if timing != nil {
+		timing.Started = time.Now()
+		defer func() { timing.Total = time.Since(timing.Started) }()
+		ctx = withStartTiming(ctx, timing)
 	}
-	// TODO: don't double-layer if input already has resource/service context layers
-	// TODO: validate we have all the required service options
-	ctx = service.NewContext(ctx, svcOpts...)
-	ctx, err := resource.NewContext(ctx, rcOpts...)
+	ctx, sel, err := startContext(ctx, opts)
 	if err != nil {
 		return err
 	}
-	infra, svcs, err := preStart(ctx)
+	infra, svcs, err := preStart(ctx, sel)
 	if err != nil {
 		return fmt.Errorf("error preparing services: %w", err)
 	}
@@ -67,49 +64,137 @@ func Start(ctx context.Context, opts ...any) error {
 	return nil
 }
 
+// startContext sets up the service and resource contexts from the options to
+// [Start].
+func startContext(ctx context.Context, opts []any, extra ...resource.ContextOption) (*resource.Context, ServiceSelection, error) {
+	// options may override the default env source, so it must come first
+	svcOpts := []service.ContextOption{
+		service.WithEnvSource(dependenciesEnv),
+	}
+	rcOpts := extra
+	var sel ServiceSelection
+	for _, opt := range opts {
+		switch o := opt.(type) {
+		case service.ContextOption:
+			svcOpts = append(svcOpts, o)
+		case resource.ContextOption:
+			rcOpts = append(rcOpts, o)
+		case ServiceSelection:
+			sel = o
+		default:
+			return nil, sel, fmt.Errorf(
+				"unexpected option type %T, expected service.ContextOption, resource.ContextOption, or ServiceSelection",
+				o,
+			)
+		}
+	}
+	// TODO: don't double-layer if input already has resource/service context layers
+	// TODO: validate we have all the required service options
+	rc, err := resource.NewContext(service.NewContext(ctx, svcOpts...), rcOpts...)
+	return rc, sel, err
+}
+
+// NewContext sets up the service and resource contexts the same way [Start]
+// does, for commands that operate on the stack's resources outside of it. The
+// options are as for [Start], except that a [ServiceSelection] is not allowed.
+func NewContext(ctx context.Context, opts ...any) (*resource.Context, error) {
+	rc, sel, err := startContext(ctx, opts)
+	if err == nil && len(sel.Services) != 0 {
+		err = errors.New("service selection is not supported here")
+	}
+	return rc, err
+}
+
+// StartServices starts the resources for the given services. Services are
+// started concurrently, except that each service will not be started until all
+// the services it depends on (see [service.WithDependsOn]) have been started.
+// Each service's own resources are started sequentially, in order.
+//
+// Services in [service.ModeExcluded] are skipped entirely.
+//
+// If a resource fails to start, a *[StartFailure] is returned. With
+// [service.WithRollbackOnFailure], the resources started by this call are
+// stopped again. Only the given services are rolled back, so when [Start] fails
+// on the stack services, the infrastructure is left running, as it is usually
+// shared with other services.
 func StartServices(ctx context.Context, kind string, svcs ...service.Service) error {
+	svcs = withoutExcluded(ctx, "starting", kind, svcs)
 	if len(svcs) == 0 {
 		return nil
 	}
+	g, err := newServiceGraph(svcs)
+	if err != nil {
+		return err
+	}
 	pt := &progress.Tracker{
 		Message: fmt.Sprintf("Starting %d services (%s)", len(svcs), kind),
-		Total:   int64(len(svcs)),
 		Units:   progress.UnitsDefault,
 	}
 	progress.AddTracker(ctx, pt)
+	svcResources := make(map[string][]resource.Resource, len(svcs))
+	// offsets of each service's resources in the combined list
+	svcOffsets := make(map[string]int, len(svcs))
 	resources := make([]resource.Resource, 0, len(svcs))
 	var errs []error
 	for _, svc := range svcs {
-		rs, err := svc.Resources(ctx)
+		rs, err := startResources(ctx, svc)
 		if err != nil {
 			pt.MarkAsErrored()
 			errs = append(errs, err)
 		}
-		// if this service is disabled, force all its resources to be stopped
-		if m, _ := service.ServiceMode(ctx, svc.Name()); m == service.ModeDisabled {
-			for i, r := range rs {
-				if !resource.IsAnti(r) {
-					rs[i] = resource.Anti(r)
-				}
-			}
-		}
+		svcResources[svc.Name()] = rs
+		svcOffsets[svc.Name()] = len(resources)
 		resources = append(resources, rs...)
 	}
 	if len(errs) > 0 {
 		return errors.Join(errs...)
 	}
-	for _, r := range resources {
-		pt.UpdateMessage(fmt.Sprintf("Starting %s", r.ID()))
-		if err := r.Start(ctx); err != nil {
-			pt.MarkAsErrored()
-			return fmt.Errorf("failed to start %s: %w", r.ID(), err)
+	pt.UpdateTotal(int64(len(resources)))
+	// each service only writes to its own entries, no locking needed
+	startedAt := make([]time.Time, len(resources))
+	failed := make([]bool, len(resources))
+	if err := g.walk(ctx, false, true, false, func(ctx context.Context, svc service.Service) error {
+		ctx = service.WithCurrentService(ctx, svc)
+		offset := svcOffsets[svc.Name()]
+		for i, r := range svcResources[svc.Name()] {
+			pt.UpdateMessage(fmt.Sprintf("Starting %s", r.ID()))
+			start := time.Now()
+			if err := r.Start(ctx); err != nil {
+				pt.MarkAsErrored()
+				failed[offset+i] = true
+				return fmt.Errorf("failed to start %s: %w", r.ID(), err)
+			}
+			recordStartStep(ctx, TimingStart, r.ID(), start)
+			startedAt[offset+i] = time.Now()
+			pt.Increment(1)
 		}
-		pt.Increment(1)
+		return nil
+	}); err != nil {
+		sf := &StartFailure{Kind: kind, Err: err}
+		for i, r := range resources {
+			switch {
+			case failed[i]:
+				sf.Failed = append(sf.Failed, r.ID())
+			case !startedAt[i].IsZero():
+				sf.Started = append(sf.Started, r.ID())
+			default:
+				sf.NotAttempted = append(sf.NotAttempted, r.ID())
+			}
+		}
+		return startFailed(ctx, sf, svcs, svcResources, startedAt, failed, svcOffsets)
 	}
 
 	if kind != "infrastructure" && !service.NoServiceWait(ctx) {
-		if err := waitResources(ctx, resources); err != nil {
-			return err
+		if err := waitResources(ctx, resources, startedAt); err != nil {
+			pt.MarkAsErrored()
+			if !service.RollbackOnFailure(ctx) {
+				return err
+			}
+			sf := &StartFailure{Kind: kind, Err: err}
+			for _, r := range resources {
+				sf.Started = append(sf.Started, r.ID())
+			}
+			return startFailed(ctx, sf, svcs, svcResources, startedAt, failed, svcOffsets)
 		}
 	}
 
@@ -118,38 +203,230 @@ func StartServices(ctx context.Context, kind string, svcs ...service.Service) er
 	return nil
 }
 
-func waitResources(ctx context.Context, resources []resource.Resource) error {
-	fmt.Printf("Waiting for ready ...\n")
+// StartFailure is the error from [StartServices] when the services could not
+// all be started, describing what state they were left in.
+type StartFailure struct {
+	Kind string
+	// Started, Failed, and NotAttempted are the IDs of the resources in each
+	// state, in registration order.
+	Started      []string
+	Failed       []string
+	NotAttempted []string
+	// RolledBack is set if the started resources were stopped again, see
+	// [service.WithRollbackOnFailure].
+	RolledBack bool
+	Err        error
+}
+
+func (f *StartFailure) Error() string {
+	return f.Err.Error()
+}
+
+func (f *StartFailure) Unwrap() error {
+	return f.Err
+}
+
+// Summary describes the state of each resource, for display to the user.
+func (f *StartFailure) Summary() string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "Failed to start %s services:", f.Kind)
+	for _, group := range []struct {
+		label string
+		ids   []string
+	}{
+		{"started", f.Started},
+		{"failed", f.Failed},
+		{"not attempted", f.NotAttempted},
+	} {
+		if len(group.ids) != 0 {
+			fmt.Fprintf(&sb, "\n  %s: %s", group.label, strings.Join(group.ids, ", "))
+		}
+	}
+	if f.RolledBack {
+		sb.WriteString("\n  the started resources were stopped again")
+	} else if len(f.Started) != 0 {
+		sb.WriteString("\n  the started resources were left running")
+	}
+	return sb.String()
+}
+
+// startFailed handles a failure in [StartServices], rolling back the started
+// resources if requested, and reporting the summary.
+func startFailed(
+	ctx context.Context,
+	sf *StartFailure,
+	svcs []service.Service,
+	svcResources map[string][]resource.Resource,
+	startedAt []time.Time,
+	failed []bool,
+	svcOffsets map[string]int,
+) error {
+	var rollbackErr error
+	if service.RollbackOnFailure(ctx) && len(sf.Started)+len(sf.Failed) != 0 {
+		started := make(map[string][]resource.Resource, len(svcs))
+		for _, svc := range svcs {
+			offset := svcOffsets[svc.Name()]
+			for i, r := range svcResources[svc.Name()] {
+				// failed resources may be partially started, so clean them up too
+				if !startedAt[offset+i].IsZero() || failed[offset+i] {
+					started[svc.Name()] = append(started[svc.Name()], r)
+				}
+			}
+		}
+		rollbackErr = stopServiceResources(ctx, StackStopOptions{}, sf.Kind, svcs, started)
+		if rollbackErr != nil {
+			rollbackErr = fmt.Errorf("error rolling back started resources: %w", rollbackErr)
+		} else {
+			sf.RolledBack = true
+		}
+	}
+	progress.Logf(ctx, "%s", sf.Summary())
+	if rollbackErr != nil {
+		return errors.Join(sf, rollbackErr)
+	}
+	return sf
+}
+
+// withoutExcluded filters out services that are in [service.ModeExcluded],
+// reporting which ones were skipped.
+func withoutExcluded(ctx context.Context, action, kind string, svcs []service.Service) []service.Service {
+	var excluded []string
+	svcs = slices.DeleteFunc(slices.Clone(svcs), func(svc service.Service) bool {
+		if m, _ := service.ServiceMode(ctx, svc.Name()); m == service.ModeExcluded {
+			excluded = append(excluded, svc.Name())
+			return true
+		}
+		return false
+	})
+	if len(excluded) != 0 {
+		pt := &progress.Tracker{
+			Message: fmt.Sprintf("Not %s %d excluded services (%s): %s",
+				action, len(excluded), kind, strings.Join(excluded, ", ")),
+			Units: progress.UnitsDefault,
+		}
+		progress.AddTracker(ctx, pt)
+		pt.MarkAsDone()
+	}
+	return svcs
+}
+
+// startResources gets the resources for the service as they should be when the
+// stack is started, taking into account the service mode.
+func startResources(ctx context.Context, svc service.Service) ([]resource.Resource, error) {
+	rs, err := svc.Resources(ctx)
+	// if this service is disabled, force all its resources to be stopped
+	if m, _ := service.ServiceMode(ctx, svc.Name()); m == service.ModeDisabled {
+		for i, r := range rs {
+			if !resource.IsAnti(r) {
+				rs[i] = resource.Anti(r)
+			}
+		}
+	}
+	return rs, err
+}
+
+// waitResources waits for all the given resources to be ready, polling them
+// all together so that a resource that becomes ready and then fails again
+// (e.g. a crash loop) is noticed, instead of hanging on the first resource that
+// is not ready.
+//
+// The startedAt times are used to apply the per-resource timeout from
+// [service.ServiceWaitTimeouts].
+func waitResources(
+	ctx context.Context,
+	resources []resource.Resource,
+	startedAt []time.Time,
+) error {
+	timeouts := service.ServiceWaitTimeouts(ctx)
+	var overallDeadline time.Time
+	if timeouts.Overall > 0 {
+		overallDeadline = time.Now().Add(timeouts.Overall)
+	}
+	type waitState struct {
+		r         resource.Resource
+		pt        *progress.Tracker
+		startedAt time.Time
+		deadline  time.Time
+		ready     bool
+	}
+	states := make([]*waitState, 0, len(resources))
+	for i, r := range resources {
+		ws := &waitState{
+			r:         r,
+			startedAt: startedAt[i],
+			pt: &progress.Tracker{
+				Message: fmt.Sprintf("Waiting on %s", r.ID()),
+				Units:   progress.UnitsDefault,
+			},
+		}
+		if timeouts.PerResource > 0 {
+			ws.deadline = startedAt[i].Add(timeouts.PerResource)
+		}
+		progress.AddTracker(ctx, ws.pt)
+		states = append(states, ws)
+	}
+	failAll := func() {
+		for _, ws := range states {
+			if !ws.ready {
+				ws.pt.MarkAsErrored()
+			}
+		}
+	}
+
+	// re-check as soon as a resource that supports it says it changed, and keep
+	// polling for the rest and to notice timeouts
+	watchCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	changed := resource.WatchReady(watchCtx, resources...)
 	ticker := time.NewTicker(time.Second)
 	defer ticker.Stop()
-	// TODO: wait for all to be ready in a single pass, instead of all being ready
-	// sequentially, catches crash loops better
-	printedDots := false
-	for _, r := range resources {
-		fmt.Printf("Waiting on %s ", r.ID())
-		for {
-			if ready, err := r.Ready(ctx); err != nil {
-				if printedDots {
-					fmt.Print(" ")
-				}
-				fmt.Println("FAILED")
-				return fmt.Errorf("error checking %s for ready: %w", r.ID(), err)
-			} else if ready {
-				if printedDots {
-					fmt.Print(" ")
+	for {
+		now := time.Now()
+		var notReady, timedOut []string
+		for _, ws := range states {
+			status, err := resource.ReadyDetails(ctx, ws.r)
+			if err != nil {
+				ws.pt.MarkAsErrored()
+				failAll()
+				return fmt.Errorf("error checking %s for ready: %w", ws.r.ID(), err)
+			} else if status.Ready() {
+				if !ws.ready {
+					ws.ready = true
+					recordStartStep(ctx, TimingReady, ws.r.ID(), ws.startedAt)
+					ws.pt.UpdateMessage(fmt.Sprintf("%s is ready", ws.r.ID()))
+					ws.pt.MarkAsDone()
 				}
-				fmt.Println("OK")
-				break
+				continue
+			} else if ws.ready {
+				ws.pt.UpdateMessage(fmt.Sprintf("%s is no longer ready", ws.r.ID()))
+				ws.pt.MarkAsErrored()
+				failAll()
+				return fmt.Errorf("%s was ready but then became not ready, it may be crash looping: %s",
+					ws.r.ID(), status)
 			}
-			select {
-			case <-ctx.Done():
-				return context.Cause(ctx)
-			case <-ticker.C:
-				// retry
-				fmt.Print(".")
-				printedDots = true
+			ws.pt.UpdateMessage(fmt.Sprintf("Waiting on %s: %s", ws.r.ID(), status))
+			notReady = append(notReady, fmt.Sprintf("%s (%s)", ws.r.ID(), status))
+			if !ws.deadline.IsZero() && now.After(ws.deadline) {
+				timedOut = append(timedOut, fmt.Sprintf("%s (%s)", ws.r.ID(), status))
 			}
 		}
+		if len(notReady) == 0 {
+			return nil
+		} else if len(timedOut) != 0 {
+			failAll()
+			return fmt.Errorf("timed out waiting for %s to be ready", strings.Join(timedOut, ", "))
+		} else if !overallDeadline.IsZero() && now.After(overallDeadline) {
+			failAll()
+			return fmt.Errorf("timed out waiting for %s to be ready", strings.Join(notReady, ", "))
+		}
+		select {
+		case <-ctx.Done():
+			failAll()
+			return context.Cause(ctx)
+		case <-ticker.C:
+			// retry
+		case <-changed:
+			// re-check early
+		}
 	}
-	return nil
 }
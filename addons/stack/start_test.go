@@ -0,0 +1,136 @@
+package stack
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"fastcat.org/go/gdev/addons/stack/stacktest"
+	"fastcat.org/go/gdev/resource"
+	"fastcat.org/go/gdev/service"
+)
+
+type recordingResource struct {
+	id    string
+	mu    *sync.Mutex
+	calls *[]string
+}
+
+func (r recordingResource) ID() string { return r.id }
+
+func (r recordingResource) record(op string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	*r.calls = append(*r.calls, op+" "+r.id)
+}
+
+func (r recordingResource) Start(context.Context) error { r.record("start"); return nil }
+func (r recordingResource) Stop(context.Context) error  { r.record("stop"); return nil }
+
+func (r recordingResource) Ready(context.Context) (bool, error) { return true, nil }
+
+func TestStartStopServices_excluded(t *testing.T) {
+	stacktest.ResetServices()
+	t.Cleanup(stacktest.ResetServices)
+	var mu sync.Mutex
+	var calls []string
+	rr := func(id string) resource.Resource { return recordingResource{id, &mu, &calls} }
+	svc1 := service.New("svc1", service.WithResources(rr("a")))
+	svc2 := service.New("svc2", service.WithResources(rr("b")), service.WithDependsOn("svc1"))
+	AddService(svc1)
+	AddService(svc2)
+
+	ctx, err := resource.NewContext(service.NewContext(t.Context(),
+		service.WithServiceModes(map[string]service.Mode{"svc1": service.ModeExcluded}),
+		service.WithoutServiceWait(),
+	))
+	require.NoError(t, err)
+
+	require.NoError(t, StartServices(ctx, "stack", svc1, svc2))
+	assert.Equal(t, []string{"start b"}, calls)
+
+	calls = nil
+	require.NoError(t, StopServices(ctx, StackStopOptions{}, "stack", svc1, svc2))
+	assert.Equal(t, []string{"stop b"}, calls)
+
+	// excluded must not be turned into anti-resources like disabled is
+	ctx, err = resource.NewContext(service.NewContext(t.Context(),
+		service.WithServiceModes(map[string]service.Mode{"svc1": service.ModeDisabled}),
+		service.WithoutServiceWait(),
+	))
+	require.NoError(t, err)
+	calls = nil
+	require.NoError(t, StartServices(ctx, "stack", svc1, svc2))
+	assert.Equal(t, []string{"stop a", "start b"}, calls)
+}
+
+type failingResource struct {
+	recordingResource
+}
+
+func (r failingResource) Start(context.Context) error {
+	r.record("start")
+	return errors.New("boom")
+}
+
+func TestStartServices_rollback(t *testing.T) {
+	stacktest.ResetServices()
+	t.Cleanup(stacktest.ResetServices)
+	var mu sync.Mutex
+	var calls []string
+	rr := func(id string) resource.Resource { return recordingResource{id, &mu, &calls} }
+	svc1 := service.New("svc1", service.WithResources(rr("a"), rr("b")))
+	svc2 := service.New("svc2",
+		service.WithResources(failingResource{recordingResource{"c", &mu, &calls}}, rr("d")),
+		service.WithDependsOn("svc1"),
+	)
+	svc3 := service.New("svc3", service.WithResources(rr("e")), service.WithDependsOn("svc2"))
+	AddService(svc1)
+	AddService(svc2)
+	AddService(svc3)
+
+	for _, rollback := range []bool{false, true} {
+		t.Run(fmt.Sprintf("rollback=%v", rollback), func(t *testing.T) {
+			opts := []service.ContextOption{service.WithoutServiceWait()}
+			if rollback {
+				opts = append(opts, service.WithRollbackOnFailure())
+			}
+			ctx, err := resource.NewContext(service.NewContext(t.Context(), opts...))
+			require.NoError(t, err)
+			calls = nil
+			err = StartServices(ctx, "stack", svc1, svc2, svc3)
+			var sf *StartFailure
+			require.ErrorAs(t, err, &sf)
+			assert.ErrorContains(t, err, "failed to start c: boom")
+			assert.Equal(t, []string{"a", "b"}, sf.Started)
+			assert.Equal(t, []string{"c"}, sf.Failed)
+			assert.Equal(t, []string{"d", "e"}, sf.NotAttempted)
+			assert.Equal(t, rollback, sf.RolledBack)
+			if rollback {
+				assert.Equal(t, []string{"start a", "start b", "start c", "stop c", "stop b", "stop a"}, calls)
+				assert.Contains(t, sf.Summary(), "stopped again")
+			} else {
+				assert.Equal(t, []string{"start a", "start b", "start c"}, calls)
+				assert.Contains(t, sf.Summary(), "left running")
+			}
+		})
+	}
+}
+
+func TestStartCommand_negativeWaitTimeout(t *testing.T) {
+	for _, flag := range []string{"--wait-timeout=-1s", "--resource-wait-timeout=-1s"} {
+		t.Run(flag, func(t *testing.T) {
+			cmd := startCommand(false)
+			cmd.SetArgs([]string{flag})
+			cmd.SetOut(io.Discard)
+			cmd.SetErr(io.Discard)
+			assert.ErrorContains(t, cmd.ExecuteContext(t.Context()), "must not be negative")
+		})
+	}
+}
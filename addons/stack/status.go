@@ -0,0 +1,155 @@
+package stack
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/jedib0t/go-pretty/v6/table"
+
+	"fastcat.org/go/gdev/resource"
+	"fastcat.org/go/gdev/service"
+)
+
+// ServiceStatus describes the live state of a service in the stack.
+type ServiceStatus struct {
+	Name string `json:"name"`
+	// Kind is either "infrastructure" or "stack"
+	Kind      string           `json:"kind"`
+	Mode      service.Mode     `json:"mode"`
+	Resources []ResourceStatus `json:"resources"`
+	// Error is set if the service's resources could not be resolved.
+	Error string `json:"error,omitempty"`
+}
+
+// ResourceStatus describes the live state of a single resource.
+type ResourceStatus struct {
+	ID     string               `json:"id"`
+	Ready  resource.ReadyStatus `json:"ready"`
+	Images []string             `json:"images,omitempty"`
+	// Error is set if checking the resource failed.
+	Error string `json:"error,omitempty"`
+}
+
+// Status checks the state of the registered services. If names are given, only
+// those services are checked, else all services (including infrastructure) are.
+//
+// The resources checked are those that would be used by [Start] with the same
+// service options, but this only reads their state, it never starts or stops
+// anything.
+func Status(ctx context.Context, svcOpts []service.ContextOption, names ...string) ([]ServiceStatus, error) {
+	ctx = service.NewContext(ctx, svcOpts...)
+	ctx, err := resource.NewContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	type kindSvc struct {
+		kind string
+		svc  service.Service
+	}
+	var svcs []kindSvc
+	for _, svc := range AllInfrastructure() {
+		svcs = append(svcs, kindSvc{"infrastructure", svc})
+	}
+	for _, svc := range AllServices() {
+		svcs = append(svcs, kindSvc{"stack", svc})
+	}
+	if len(names) != 0 {
+		for _, n := range names {
+			if ServiceByName(n) == nil {
+				return nil, fmt.Errorf("unknown service %q", n)
+			}
+		}
+		svcs = slices.DeleteFunc(svcs, func(ks kindSvc) bool {
+			return !slices.Contains(names, ks.svc.Name())
+		})
+	}
+
+	ret := make([]ServiceStatus, len(svcs))
+	var wg sync.WaitGroup
+	for i, ks := range svcs {
+		ss := &ret[i]
+		ss.Name, ss.Kind = ks.svc.Name(), ks.kind
+		ss.Mode, _ = service.ServiceMode(ctx, ss.Name)
+		rs, err := startResources(ctx, ks.svc)
+		if err != nil {
+			ss.Error = err.Error()
+		}
+		ss.Resources = make([]ResourceStatus, len(rs))
+		for j, r := range rs {
+			wg.Go(func() { ss.Resources[j] = resourceStatus(ctx, r) })
+		}
+	}
+	wg.Wait()
+	return ret, nil
+}
+
+func resourceStatus(ctx context.Context, r resource.Resource) ResourceStatus {
+	rs := ResourceStatus{ID: r.ID()}
+	var err error
+	if rs.Ready, err = resource.ReadyDetails(ctx, r); err != nil {
+		rs.Error = err.Error()
+	}
+	if cr, ok := r.(resource.ContainerResource); ok {
+		if rs.Images, err = cr.ContainerImages(ctx); err != nil && rs.Error == "" {
+			rs.Error = err.Error()
+		}
+	}
+	return rs
+}
+
+// StatusTable renders the statuses as a table.
+func StatusTable(statuses []ServiceStatus, out io.Writer) {
+	tw := table.NewWriter()
+	tw.SetStyle(table.StyleColoredBlueWhiteOnBlack)
+	tw.SetOutputMirror(out)
+	tw.AppendHeader(table.Row{"Service", "Kind", "Mode", "Resource", "Ready", "Details", "Images"})
+	tw.AppendSeparator()
+	for _, ss := range statuses {
+		if ss.Error != "" {
+			tw.AppendRow(table.Row{ss.Name, ss.Kind, ss.Mode, "", "❌", ss.Error, ""})
+		}
+		for _, rs := range ss.Resources {
+			details := strings.TrimPrefix(rs.Ready.String(), string(rs.Ready.State))
+			details = strings.TrimPrefix(details, ": ")
+			if rs.Error != "" {
+				details = rs.Error
+			}
+			tw.AppendRow(table.Row{
+				ss.Name, ss.Kind, ss.Mode,
+				rs.ID, readyEmoji(rs.Ready.State), details,
+				strings.Join(rs.Images, "\n"),
+			})
+		}
+	}
+	tw.SetColumnConfigs([]table.ColumnConfig{
+		{Number: 1, AutoMerge: true},
+		{Number: 2, AutoMerge: true},
+		{Number: 3, AutoMerge: true},
+	})
+	tw.Render()
+}
+
+// StatusJSON renders the statuses as indented JSON.
+func StatusJSON(statuses []ServiceStatus, out io.Writer) error {
+	enc := json.NewEncoder(out)
+	enc.SetIndent("", "  ")
+	return enc.Encode(statuses)
+}
+
+func readyEmoji(state resource.ReadyState) string {
+	switch state {
+	case resource.ReadyStateReady:
+		return "👍"
+	case resource.ReadyStateNotReady:
+		return "⏳"
+	case resource.ReadyStateFailed:
+		return "❌"
+	default:
+		return "❔"
+	}
+}
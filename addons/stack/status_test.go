@@ -0,0 +1,76 @@
+package stack
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"fastcat.org/go/gdev/addons/stack/stacktest"
+	"fastcat.org/go/gdev/resource"
+	"fastcat.org/go/gdev/service"
+)
+
+func TestStatus(t *testing.T) {
+	stacktest.ResetServices()
+	t.Cleanup(stacktest.ResetServices)
+	waiter := func(name string, ready bool, err error) resource.Resource {
+		return resource.Waiter(name, func(context.Context) (bool, error) { return ready, err })
+	}
+	AddInfrastructure(service.New("infra", service.WithResources(waiter("db", true, nil))))
+	AddService(service.New("svc1", service.WithResources(waiter("a", false, nil))))
+	AddService(service.New("svc2",
+		service.WithResources(waiter("b", false, errors.New("boom"))),
+		service.WithModalResources(service.ModeLocal, waiter("c", true, nil)),
+	))
+
+	statuses, err := Status(t.Context(), nil)
+	require.NoError(t, err)
+	assert.Equal(t, []ServiceStatus{
+		{
+			Name: "infra", Kind: "infrastructure", Mode: service.ModeDefault,
+			Resources: []ResourceStatus{
+				{ID: "Wait/db", Ready: resource.ReadyStatus{State: resource.ReadyStateReady}},
+			},
+		},
+		{
+			Name: "svc1", Kind: "stack", Mode: service.ModeDefault,
+			Resources: []ResourceStatus{
+				{ID: "Wait/a", Ready: resource.ReadyStatus{State: resource.ReadyStateNotReady}},
+			},
+		},
+		{
+			Name: "svc2", Kind: "stack", Mode: service.ModeDefault,
+			Resources: []ResourceStatus{
+				{
+					ID:    "Wait/b",
+					Ready: resource.ReadyStatus{State: resource.ReadyStateFailed, Message: "boom"},
+					Error: "boom",
+				},
+				{
+					ID:    "anti/Wait/c",
+					Ready: resource.ReadyStatus{State: resource.ReadyStateNotReady, Message: "still running"},
+				},
+			},
+		},
+	}, statuses)
+
+	statuses, err = Status(t.Context(),
+		[]service.ContextOption{service.WithServiceModes(map[string]service.Mode{"svc2": service.ModeDisabled})},
+		"svc2",
+	)
+	require.NoError(t, err)
+	require.Len(t, statuses, 1)
+	assert.Equal(t, service.ModeDisabled, statuses[0].Mode)
+	assert.Equal(t, "anti/Wait/b", statuses[0].Resources[0].ID)
+
+	_, err = Status(t.Context(), nil, "nope")
+	assert.Error(t, err)
+
+	var buf bytes.Buffer
+	require.NoError(t, StatusJSON(statuses, &buf))
+	assert.Contains(t, buf.String(), `"mode": "disabled"`)
+}
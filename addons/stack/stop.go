@@ -5,6 +5,7 @@ import (
 	"errors"
 	"fmt"
 	"slices"
+	"strings"
 	"sync"
 	"time"
 
@@ -14,39 +15,60 @@ import (
 )
 
 type StackStopOptions struct {
+	// ServiceSelection limits stopping to the named services. If any are named,
+	// infrastructure in the selection is always stopped, and
+	// IncludeInfrastructure is ignored.
+	ServiceSelection
 	IncludeInfrastructure bool
 	Exclude               []string
 	Parallel              bool
 	// If set non-nil, the time each service takes to stop will be recorded in this map
 	Timing map[string]time.Duration
+	// ServiceModes are used to find services in [service.ModeExcluded], which
+	// will not be stopped.
+	ServiceModes map[string]service.Mode
+	// Wait, if set, waits after stopping each group of services until their
+	// resources report they have fully stopped (see [resource.Stopped]).
+	Wait bool
+	// WaitTimeout limits how long to wait when Wait is set. Zero means no limit.
+	WaitTimeout time.Duration
 }
 
+// DefaultStopWaitTimeout is a reasonable default for
+// [StackStopOptions.WaitTimeout].
+const DefaultStopWaitTimeout = 2 * time.Minute
+
 func StackStop(ctx context.Context, opts StackStopOptions) error {
 	ctx, stop := progress.StartWriter(ctx)
 	defer stop()
 
+	if opts.ServiceModes != nil {
+		ctx = service.NewContext(ctx, service.WithServiceModes(opts.ServiceModes))
+	}
 	ctx, err := resource.NewContext(ctx)
 	if err != nil {
 		return err
 	}
+	infra, svcs, err := opts.selected()
+	if err != nil {
+		return err
+	}
 	deleteFunc := func(s service.Service) bool {
 		return slices.Contains(opts.Exclude, s.Name())
 	}
 	// do services & infra separately in case parallel was requested. infra after
 	// services because we stop things in reverse of start order.
-	svcs := slices.DeleteFunc(AllServices(), deleteFunc)
+	svcs = slices.DeleteFunc(svcs, deleteFunc)
 	if err := StopServices(ctx, opts, "stack", svcs...); err != nil {
 		return err
 	}
-	if opts.IncludeInfrastructure {
-		svcs := slices.DeleteFunc(AllInfrastructure(), deleteFunc)
-		if err := StopServices(ctx, opts, "infrastructure", svcs...); err != nil {
+	if opts.IncludeInfrastructure || len(opts.Services) != 0 {
+		infra = slices.DeleteFunc(infra, deleteFunc)
+		if err := StopServices(ctx, opts, "infrastructure", infra...); err != nil {
 			return err
 		}
 	}
 
-	// TODO: mechanism for full stop wait?
-
 	return nil
 }
 
@@ -64,28 +86,61 @@ func Stop(
 	})
 }
 
+// StopServices stops the resources for the given services. Each service is
+// stopped before any of the services it depends on (see
+// [service.WithDependsOn]), and each service's resources are stopped in the
+// reverse of their start order. If opts.Parallel is set, services that do not
+// depend on each other, and the resources within each service, are stopped
+// concurrently. Services in [service.ModeExcluded] are skipped entirely.
 func StopServices(ctx context.Context, opts StackStopOptions, kind string, svcs ...service.Service) error {
-	pt := &progress.Tracker{
-		Message: fmt.Sprintf("Stopping %d services (%s)...", len(svcs), kind),
-		Total:   int64(len(svcs)),
-		Units:   progress.UnitsDefault,
+	svcs = withoutExcluded(ctx, "stopping", kind, svcs)
+	if len(svcs) == 0 {
+		return nil
 	}
-	progress.AddTracker(ctx, pt)
-
-	resources := make([]resource.Resource, 0, len(svcs))
+	svcResources := make(map[string][]resource.Resource, len(svcs))
 	var errs []error
 	for _, svc := range svcs {
 		r, err := svc.Resources(ctx)
 		if err != nil {
 			errs = append(errs, err)
 		}
-		resources = append(resources, r...)
+		svcResources[svc.Name()] = r
+	}
+	if err := stopServiceResources(ctx, opts, kind, svcs, svcResources); err != nil {
+		errs = append(errs, err)
+	}
+	return errors.Join(errs...)
+}
+
+// stopServiceResources does the work of [StopServices] for the given resources
+// of each service, which are listed in start order.
+func stopServiceResources(
+	ctx context.Context,
+	opts StackStopOptions,
+	kind string,
+	svcs []service.Service,
+	svcResources map[string][]resource.Resource,
+) error {
+	g, err := newServiceGraph(svcs)
+	if err != nil {
+		return err
 	}
-	// stop everything we can, in reverse order, don't return errors until the end
-	slices.Reverse(resources)
-	// buffer 1 so we can do the non-parallel stop without extra shenanigans
-	errCh := make(chan error, 1)
-	var wg sync.WaitGroup
+	pt := &progress.Tracker{
+		Message: fmt.Sprintf("Stopping %d services (%s)...", len(svcs), kind),
+		Units:   progress.UnitsDefault,
+	}
+	progress.AddTracker(ctx, pt)
+
+	total := 0
+	for _, svc := range svcs {
+		// stop in reverse order
+		r := slices.Clone(svcResources[svc.Name()])
+		slices.Reverse(r)
+		svcResources[svc.Name()] = r
+		total += len(r)
+	}
+	pt.UpdateTotal(int64(total))
+	var errs []error
 	recTiming := func(r resource.Resource, start time.Time) {}
 	if opts.Timing != nil {
 		var mu sync.Mutex
@@ -95,38 +150,99 @@ func StopServices(ctx context.Context, opts StackStopOptions, kind string, svcs
 			opts.Timing[r.ID()] = time.Since(start)
 		}
 	}
-	for _, r := range resources {
+	stopOne := func(ctx context.Context, r resource.Resource) error {
 		pt.UpdateMessage(fmt.Sprintf("Stopping %s", r.ID()))
-		wg.Go(func() {
-			start := time.Now()
-			if err := r.Stop(ctx); err != nil {
-				pt.MarkAsErrored()
-				errCh <- fmt.Errorf("failed to stop %s: %w", r.ID(), err)
-			}
-			recTiming(r, start)
-			pt.Increment(1)
-		})
-		if !opts.Parallel {
-			wg.Wait()
-			select {
-			case err := <-errCh:
-				errs = append(errs, err)
-			default:
+		start := time.Now()
+		defer pt.Increment(1)
+		defer recTiming(r, start)
+		if err := r.Stop(ctx); err != nil {
+			pt.MarkAsErrored()
+			return fmt.Errorf("failed to stop %s: %w", r.ID(), err)
+		}
+		return nil
+	}
+	// stop everything we can, don't return errors until the end
+	if err := g.walk(ctx, true, opts.Parallel, true, func(ctx context.Context, svc service.Service) error {
+		rs := svcResources[svc.Name()]
+		errs := make([]error, len(rs))
+		var wg sync.WaitGroup
+		for i, r := range rs {
+			if opts.Parallel {
+				wg.Go(func() { errs[i] = stopOne(ctx, r) })
+			} else {
+				errs[i] = stopOne(ctx, r)
 			}
 		}
+		wg.Wait()
+		return errors.Join(errs...)
+	}); err != nil {
+		errs = append(errs, err)
 	}
-	if opts.Parallel {
-		pt.UpdateMessage(fmt.Sprintf("Waiting for %d services (%s) to stop", len(svcs), kind))
-		go func() { defer close(errCh); wg.Wait() }()
-		for err := range errCh {
-			errs = append(errs, err)
+	if len(errs) != 0 {
+		return errors.Join(errs...)
+	}
+	pt.UpdateMessage(fmt.Sprintf("Stopped %d services (%s)", len(svcs), kind))
+	pt.MarkAsDone()
+	if opts.Wait {
+		var all []resource.Resource
+		for _, svc := range svcs {
+			all = append(all, svcResources[svc.Name()]...)
 		}
+		return waitStopped(ctx, kind, all, opts.WaitTimeout)
+	}
+	return nil
+}
+
+// waitStopped polls the resources until they have all fully stopped, or the
+// timeout is reached, in which case the resources that have not stopped are
+// reported in the error.
+func waitStopped(ctx context.Context, kind string, rs []resource.Resource, timeout time.Duration) error {
+	if len(rs) == 0 {
+		return nil
 	}
-	if len(errs) == 0 {
-		pt.UpdateMessage(fmt.Sprintf("Stopped %d services (%s)", len(svcs), kind))
+	pt := &progress.Tracker{
+		Message: fmt.Sprintf("Waiting for %d resources (%s) to stop", len(rs), kind),
+		Total:   int64(len(rs)),
+		Units:   progress.UnitsDefault,
+	}
+	progress.AddTracker(ctx, pt)
+	pending := slices.Clone(rs)
+	// errors checking may be transient, e.g. while a k8s object is being
+	// deleted, so they are only reported if we time out
+	checkErrs := map[string]error{}
+	policy := resource.NewWaitPolicy(250*time.Millisecond,
+		resource.WaitBackoff(1.5, 2*time.Second),
+		resource.WaitTimeout(timeout),
+	)
+	err := policy.Poll(ctx, func(ctx context.Context) (bool, error) {
+		pending = slices.DeleteFunc(pending, func(r resource.Resource) bool {
+			stopped, err := resource.Stopped(ctx, r)
+			if err != nil {
+				checkErrs[r.ID()] = err
+				return false
+			}
+			delete(checkErrs, r.ID())
+			return stopped
+		})
+		pt.SetValue(int64(len(rs) - len(pending)))
+		return len(pending) == 0, nil
+	})
+	if err == nil {
+		pt.UpdateMessage(fmt.Sprintf("Stopped %d resources (%s)", len(rs), kind))
 		pt.MarkAsDone()
 		return nil
 	}
-
-	return errors.Join(errs...)
+	pt.MarkAsErrored()
+	if !errors.Is(err, resource.ErrWaitTimeout) {
+		return err
+	}
+	stragglers := make([]string, 0, len(pending))
+	for _, r := range pending {
+		if err := checkErrs[r.ID()]; err != nil {
+			stragglers = append(stragglers, fmt.Sprintf("%s (%v)", r.ID(), err))
+		} else {
+			stragglers = append(stragglers, r.ID())
+		}
+	}
+	return fmt.Errorf("timed out after %v waiting for %s to stop", timeout, strings.Join(stragglers, ", "))
 }
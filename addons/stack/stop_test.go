@@ -0,0 +1,45 @@
+package stack
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"fastcat.org/go/gdev/resource"
+)
+
+// slowStopResource reports it is stopped after a number of checks.
+type slowStopResource struct {
+	recordingResource
+	checks atomic.Int32
+	after  int32
+	err    error
+}
+
+func (r *slowStopResource) Stopped(context.Context) (bool, error) {
+	if n := r.checks.Add(1); r.after >= 0 && n > r.after {
+		return true, nil
+	}
+	return false, r.err
+}
+
+func TestWaitStopped(t *testing.T) {
+	rr := func(id string, after int32, err error) *slowStopResource {
+		return &slowStopResource{recordingResource: recordingResource{id: id}, after: after, err: err}
+	}
+	a, b := rr("a", 2, nil), rr("b", 0, nil)
+	require.NoError(t, waitStopped(t.Context(), "stack", []resource.Resource{a, b}, time.Second))
+	assert.Equal(t, int32(3), a.checks.Load())
+	assert.Equal(t, int32(1), b.checks.Load())
+
+	// resources without a stopped check are assumed to be stopped
+	plain := recordingResource{id: "plain"}
+	stuck, failing := rr("stuck", -1, nil), rr("failing", -1, errors.New("boom"))
+	err := waitStopped(t.Context(), "stack", []resource.Resource{plain, stuck, failing}, 300*time.Millisecond)
+	assert.EqualError(t, err, "timed out after 300ms waiting for stuck, failing (boom) to stop")
+}
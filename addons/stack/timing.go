@@ -0,0 +1,149 @@
+package stack
+
+import (
+	"cmp"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/jedib0t/go-pretty/v6/table"
+)
+
+// TimingKind classifies the steps recorded in [StartTiming].
+type TimingKind string
+
+const (
+	// TimingHook is a stage of a pre-start hook.
+	TimingHook TimingKind = "hook"
+	// TimingBuild is a build run by a pre-start hook.
+	TimingBuild TimingKind = "build"
+	// TimingStart is the Start call for a resource.
+	TimingStart TimingKind = "start"
+	// TimingReady is the wait for a resource to be ready after it was started.
+	TimingReady TimingKind = "ready"
+)
+
+// TimingItem is how long one step of starting the stack took.
+type TimingItem struct {
+	Kind     TimingKind    `json:"kind"`
+	Name     string        `json:"name"`
+	Duration time.Duration `json:"durationNs"`
+}
+
+// StartTiming records how long each step of [Start] takes. Pass a non-nil
+// *StartTiming as an option to [Start] to fill it in.
+type StartTiming struct {
+	mu      sync.Mutex
+	Started time.Time
+	Total   time.Duration
+	Items   []TimingItem
+}
+
+func (t *StartTiming) record(kind TimingKind, name string, d time.Duration) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.Items = append(t.Items, TimingItem{kind, name, d})
+}
+
+// Slowest returns the n slowest recorded items, slowest first. If n <= 0, all
+// items are returned.
+func (t *StartTiming) Slowest(n int) []TimingItem {
+	t.mu.Lock()
+	items := slices.Clone(t.Items)
+	t.mu.Unlock()
+	slices.SortStableFunc(items, func(a, b TimingItem) int { return cmp.Compare(b.Duration, a.Duration) })
+	if n > 0 && len(items) > n {
+		items = items[:n]
+	}
+	return items
+}
+
+// SummaryTable writes a table of the n slowest items to out.
+func (t *StartTiming) SummaryTable(n int, out io.Writer) {
+	tw := table.NewWriter()
+	tw.SetStyle(table.StyleColoredBlueWhiteOnBlack)
+	tw.SetOutputMirror(out)
+	tw.AppendHeader(table.Row{"Kind", "Step", "Duration"})
+	tw.AppendSeparator()
+	for _, item := range t.Slowest(n) {
+		tw.AppendRow(table.Row{item.Kind, item.Name, item.Duration.Round(time.Millisecond)})
+	}
+	tw.AppendFooter(table.Row{"", "Total", t.Total.Round(time.Millisecond)})
+	tw.Render()
+}
+
+// TimingHistoryEntry is one line in a start timing history file.
+type TimingHistoryEntry struct {
+	Time     time.Time     `json:"time"`
+	Services []string      `json:"services,omitempty"`
+	Error    string        `json:"error,omitempty"`
+	Total    time.Duration `json:"totalNs"`
+	Items    []TimingItem  `json:"items"`
+}
+
+// AppendHistory appends the timing to a history file as a line of JSON, so that
+// startup times can be compared over time. The services are the ones that were
+// requested, if not the whole stack, and startErr is the result of [Start].
+func (t *StartTiming) AppendHistory(path string, services []string, startErr error) error {
+	t.mu.Lock()
+	entry := TimingHistoryEntry{
+		Time:     t.Started,
+		Services: services,
+		Total:    t.Total,
+		Items:    slices.Clone(t.Items),
+	}
+	t.mu.Unlock()
+	if startErr != nil {
+		entry.Error = startErr.Error()
+	}
+	line, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0o644)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(append(line, '\n')); err != nil {
+		_ = f.Close()
+		return fmt.Errorf("error writing timing history to %s: %w", path, err)
+	}
+	return f.Close()
+}
+
+type startTimingKey struct{}
+
+func withStartTiming(ctx context.Context, t *StartTiming) context.Context {
+	return context.WithValue(ctx, startTimingKey{}, t)
+}
+
+// TimeStartStep begins timing a step of starting the stack, returning a
+// function to call when the step is done. This is a no-op if the stack start
+// is not being timed. It is meant for pre-start hooks to report slow work such
+// as builds:
+//
+//	defer stack.TimeStartStep(ctx, stack.TimingBuild, repo)()
+func TimeStartStep(ctx context.Context, kind TimingKind, name string) func() {
+	t, _ := ctx.Value(startTimingKey{}).(*StartTiming)
+	if t == nil {
+		return func() {}
+	}
+	start := time.Now()
+	return func() { t.record(kind, name, time.Since(start)) }
+}
+
+// recordStartStep records a step that began at start and is finishing now.
+func recordStartStep(ctx context.Context, kind TimingKind, name string, start time.Time) {
+	if t, _ := ctx.Value(startTimingKey{}).(*StartTiming); t != nil {
+		t.record(kind, name, time.Since(start))
+	}
+}
@@ -0,0 +1,88 @@
+package stack
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"fastcat.org/go/gdev/addons/stack/stacktest"
+	"fastcat.org/go/gdev/resource"
+	"fastcat.org/go/gdev/service"
+)
+
+func TestStartServices_timing(t *testing.T) {
+	stacktest.ResetServices()
+	t.Cleanup(stacktest.ResetServices)
+	var mu sync.Mutex
+	var calls []string
+	svc := service.New("svc", service.WithResources(
+		recordingResource{"a", &mu, &calls},
+		recordingResource{"b", &mu, &calls},
+	))
+	AddService(svc)
+
+	timing := &StartTiming{}
+	ctx, err := resource.NewContext(service.NewContext(withStartTiming(t.Context(), timing)))
+	require.NoError(t, err)
+	require.NoError(t, StartServices(ctx, "stack", svc))
+
+	var got []string
+	for _, item := range timing.Items {
+		got = append(got, string(item.Kind)+" "+item.Name)
+	}
+	assert.ElementsMatch(t, []string{"start a", "start b", "ready a", "ready b"}, got)
+
+	// no-op without timing
+	TimeStartStep(t.Context(), TimingBuild, "x")()
+}
+
+func TestStartTiming_Slowest(t *testing.T) {
+	timing := &StartTiming{Items: []TimingItem{
+		{TimingStart, "a", time.Second},
+		{TimingReady, "a", 3 * time.Second},
+		{TimingBuild, "repo", 2 * time.Second},
+	}}
+	assert.Equal(t, []TimingItem{
+		{TimingReady, "a", 3 * time.Second},
+		{TimingBuild, "repo", 2 * time.Second},
+	}, timing.Slowest(2))
+	assert.Len(t, timing.Slowest(0), 3)
+
+	var out strings.Builder
+	timing.SummaryTable(2, &out)
+	assert.Contains(t, out.String(), "repo")
+	assert.NotContains(t, out.String(), "start")
+}
+
+func TestStartTiming_AppendHistory(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "sub", "history.jsonl")
+	timing := &StartTiming{
+		Started: time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC),
+		Total:   time.Second,
+		Items:   []TimingItem{{TimingStart, "a", time.Millisecond}},
+	}
+	require.NoError(t, timing.AppendHistory(path, nil, nil))
+	require.NoError(t, timing.AppendHistory(path, []string{"a"}, errors.New("boom")))
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	require.Len(t, lines, 2)
+	var entry TimingHistoryEntry
+	require.NoError(t, json.Unmarshal([]byte(lines[1]), &entry))
+	assert.Equal(t, TimingHistoryEntry{
+		Time:     timing.Started,
+		Services: []string{"a"},
+		Error:    "boom",
+		Total:    time.Second,
+		Items:    timing.Items,
+	}, entry)
+}
@@ -9,6 +9,7 @@ import (
 	"fastcat.org/go/gdev/addons"
 	"fastcat.org/go/gdev/instance"
 	"fastcat.org/go/gdev/internal"
+	"fastcat.org/go/gdev/progress"
 )
 
 func Root() *cobra.Command {
@@ -44,6 +45,17 @@ func Root() *cobra.Command {
 		SilenceErrors: true,
 		Version:       instance.Version(),
 	}
+	root.PersistentFlags().Var(progress.DefaultFormat(), "progress",
+		"how to show progress: auto, fancy (progress bars), plain (lines of text), or json (event per line)")
+	_ = root.RegisterFlagCompletionFunc("progress", func(
+		*cobra.Command, []string, string,
+	) ([]string, cobra.ShellCompDirective) {
+		names := make([]string, 0, len(progress.Formats))
+		for _, f := range progress.Formats {
+			names = append(names, f.String())
+		}
+		return names, cobra.ShellCompDirectiveNoFileComp
+	})
 	root.AddCommand(&cobra.Command{
 		Use:                   "version",
 		Short:                 "Detailed version information",
@@ -3,18 +3,57 @@ package sys
 import (
 	"context"
 	"os"
+	"time"
 )
 
 type Isolator interface {
+	// Isolate puts a process into a new group, constrained by the limits if they
+	// are not nil.
 	Isolate(
 		ctx context.Context,
 		name string,
 		process *os.Process,
+		limits *Limits,
 	) (group string, err error)
 	Cleanup(
 		ctx context.Context,
 		group string,
 	) error
+	// Join adds a process to an existing group created by Isolate.
+	Join(
+		ctx context.Context,
+		group string,
+		process *os.Process,
+	) error
+	// Usage reports the current resource usage of a group created by Isolate.
+	Usage(
+		ctx context.Context,
+		group string,
+	) (*Usage, error)
+}
+
+// Limits are resource constraints for an isolation group. Zero values mean no
+// limit.
+type Limits struct {
+	// MemoryMax is the memory limit in bytes, beyond which processes in the
+	// group are OOM killed.
+	MemoryMax int64
+	// CPUWeight is the relative share of CPU time, 1-10000, default 100.
+	CPUWeight uint64
+	// CPUQuota is the maximum CPU time, as a fraction of one CPU.
+	CPUQuota float64
+	// PidsMax is the maximum number of processes and threads.
+	PidsMax int64
+	// IOWeight is the relative share of IO bandwidth, 1-10000, default 100.
+	IOWeight uint64
+}
+
+// Usage is the resource usage of an isolation group. Values the isolator can't
+// determine are zero.
+type Usage struct {
+	MemoryBytes uint64
+	CPUTime     time.Duration
+	Pids        uint64
 }
 
 // GetIsolator is initialized in platform-specific files, and should generally
@@ -4,8 +4,10 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"math"
 	"os"
 	"path/filepath"
+	"strconv"
 	"strings"
 	"sync"
 	"sync/atomic"
@@ -73,6 +75,7 @@ func (s *systemdIsolator) Isolate(
 	ctx context.Context,
 	name string,
 	process *os.Process,
+	limits *Limits,
 ) (string, error) {
 	// systemd won't allow moving an existing pid into a .service
 	if !strings.HasSuffix(name, ".scope") {
@@ -84,23 +87,26 @@ func (s *systemdIsolator) Isolate(
 		return "", err
 	}
 	ch := make(chan string, 1)
+	props := []dbus.Property{
+		// TODO: description is contextual and needs to be passed in not derived
+		// dbus.PropDescription(fmt.Sprintf("%s pm service %s", instance.AppName(), name)),
+
+		// auto-harvest the transient unit once all its processes exit
+		{Name: "CollectMode", Value: godbus.MakeVariant("inactive-or-failed")},
+		// put the given PID into it now
+		dbus.PropPids(uint32(process.Pid)),
+		// accounting copied from containerd/cgroups/v3/cgroups2
+		{Name: "MemoryAccounting", Value: godbus.MakeVariant(true)},
+		{Name: "CPUAccounting", Value: godbus.MakeVariant(true)},
+		{Name: "IOAccounting", Value: godbus.MakeVariant(true)},
+		{Name: "TasksAccounting", Value: godbus.MakeVariant(true)},
+	}
+	props = append(props, systemdLimits(limits)...)
 	_, err = conn.StartTransientUnitContext(
 		ctx,
 		name,
 		"fail", // error if unit already exists
-		[]dbus.Property{
-			// TODO: description is contextual and needs to be passed in not derived
-			// dbus.PropDescription(fmt.Sprintf("%s pm service %s", instance.AppName(), name)),
-
-			// auto-harvest the transient unit once all its processes exit
-			{Name: "CollectMode", Value: godbus.MakeVariant("inactive-or-failed")},
-			// put the given PID into it now
-			dbus.PropPids(uint32(process.Pid)),
-			// accounting copied from containerd/cgroups/v3/cgroups2
-			{Name: "MemoryAccounting", Value: godbus.MakeVariant(true)},
-			{Name: "CPUAccounting", Value: godbus.MakeVariant(true)},
-			{Name: "IOAccounting", Value: godbus.MakeVariant(true)},
-		},
+		props,
 		ch,
 	)
 	if err != nil {
@@ -146,6 +152,63 @@ func (s *systemdIsolator) Cleanup(ctx context.Context, group string) error {
 	}
 }
 
+func (s *systemdIsolator) Join(ctx context.Context, group string, process *os.Process) error {
+	conn, err := s.getConn()
+	if err != nil {
+		return err
+	}
+	return conn.AttachProcessesToUnit(ctx, group, "", []uint32{uint32(process.Pid)})
+}
+
+func systemdLimits(limits *Limits) []dbus.Property {
+	if limits == nil {
+		return nil
+	}
+	var props []dbus.Property
+	add := func(name string, value uint64) {
+		props = append(props, dbus.Property{Name: name, Value: godbus.MakeVariant(value)})
+	}
+	if limits.MemoryMax > 0 {
+		add("MemoryMax", uint64(limits.MemoryMax))
+	}
+	if limits.CPUWeight > 0 {
+		add("CPUWeight", limits.CPUWeight)
+	}
+	if limits.CPUQuota > 0 {
+		add("CPUQuotaPerSecUSec", uint64(limits.CPUQuota*float64(time.Second/time.Microsecond)))
+	}
+	if limits.PidsMax > 0 {
+		add("TasksMax", uint64(limits.PidsMax))
+	}
+	if limits.IOWeight > 0 {
+		add("IOWeight", limits.IOWeight)
+	}
+	return props
+}
+
+func (s *systemdIsolator) Usage(ctx context.Context, group string) (*Usage, error) {
+	conn, err := s.getConn()
+	if err != nil {
+		return nil, err
+	}
+	props, err := conn.GetUnitTypePropertiesContext(ctx, group, "Scope")
+	if err != nil {
+		return nil, err
+	}
+	// systemd reports unknown values as max uint64
+	get := func(name string) uint64 {
+		if v, ok := props[name].(uint64); ok && v != math.MaxUint64 {
+			return v
+		}
+		return 0
+	}
+	return &Usage{
+		MemoryBytes: get("MemoryCurrent"),
+		CPUTime:     time.Duration(get("CPUUsageNSec")),
+		Pids:        get("TasksCurrent"),
+	}, nil
+}
+
 type cgroupsIsolator struct {
 	parentGroup string
 }
@@ -154,6 +217,7 @@ func (c *cgroupsIsolator) Isolate(
 	ctx context.Context,
 	name string,
 	process *os.Process,
+	limits *Limits,
 ) (string, error) {
 	// don't apply the same rules as systemd so we can fake a .service we would
 	// have started via it
@@ -169,11 +233,21 @@ func (c *cgroupsIsolator) Isolate(
 		cgroupsMountPath,
 		// TODO: hierarchy?
 		groupPath,
-		&cgroup2.Resources{},
+		cgroupsResources(limits),
 	)
 	if err != nil {
 		return groupPath, err
 	}
+	if limits != nil && limits.IOWeight > 0 {
+		// the cgroups library only knows how to set the bfq weight
+		if err := os.WriteFile(
+			filepath.Join(cgroupsMountPath, groupPath, "io.weight"),
+			[]byte("default "+strconv.FormatUint(limits.IOWeight, 10)),
+			0o644,
+		); err != nil {
+			return groupPath, err
+		}
+	}
 	if err := mgr.AddProc(uint64(process.Pid)); err != nil {
 		return groupPath, err
 	}
@@ -215,6 +289,64 @@ func (*cgroupsIsolator) Cleanup(ctx context.Context, groupPath string) error {
 	}
 }
 
+func (*cgroupsIsolator) Join(ctx context.Context, groupPath string, process *os.Process) error {
+	mgr, err := cgroup2.Load(groupPath)
+	if err != nil {
+		return err
+	}
+	return mgr.AddProc(uint64(process.Pid))
+}
+
+func cgroupsResources(limits *Limits) *cgroup2.Resources {
+	r := &cgroup2.Resources{}
+	if limits == nil {
+		return r
+	}
+	if limits.MemoryMax > 0 {
+		r.Memory = &cgroup2.Memory{Max: new(limits.MemoryMax)}
+	}
+	if limits.CPUWeight > 0 || limits.CPUQuota > 0 {
+		r.CPU = &cgroup2.CPU{}
+		if limits.CPUWeight > 0 {
+			r.CPU.Weight = new(limits.CPUWeight)
+		}
+		if limits.CPUQuota > 0 {
+			period := uint64(100 * time.Millisecond / time.Microsecond)
+			r.CPU.Max = cgroup2.NewCPUMax(new(int64(limits.CPUQuota*float64(period))), &period)
+		}
+	}
+	if limits.PidsMax > 0 {
+		r.Pids = &cgroup2.Pids{Max: limits.PidsMax}
+	}
+	if limits.IOWeight > 0 {
+		// enables the controller, the weight is set separately
+		r.IO = &cgroup2.IO{}
+	}
+	return r
+}
+
+func (*cgroupsIsolator) Usage(ctx context.Context, groupPath string) (*Usage, error) {
+	mgr, err := cgroup2.Load(groupPath)
+	if err != nil {
+		return nil, err
+	}
+	m, err := mgr.Stat()
+	if err != nil {
+		return nil, err
+	}
+	var u Usage
+	if m.Memory != nil {
+		u.MemoryBytes = m.Memory.Usage
+	}
+	if m.CPU != nil {
+		u.CPUTime = time.Duration(m.CPU.UsageUsec) * time.Microsecond
+	}
+	if m.Pids != nil {
+		u.Pids = m.Pids.Current
+	}
+	return &u, nil
+}
+
 func (c *cgroupsIsolator) getParentGroup() (string, error) {
 	if c.parentGroup == "" {
 		// default: put the new scope below whatever contains the current process
@@ -31,7 +31,7 @@ func Test_systemdIsolator_isolateProcess(t *testing.T) {
 	t.Run("kill via cgroup", func(t *testing.T) {
 		cmd := startSleep(t)
 
-		unit, err := i.Isolate(t.Context(), "test-sleep.scope", cmd.Process)
+		unit, err := i.Isolate(t.Context(), "test-sleep.scope", cmd.Process, nil)
 		require.NoError(t, err)
 		t.Logf("started unit %q", unit)
 		g, err := cgroup2.PidGroupPath(cmd.Process.Pid)
@@ -73,7 +73,7 @@ func Test_systemdIsolator_isolateProcess(t *testing.T) {
 	t.Run("kill via systemd", func(t *testing.T) {
 		cmd := startSleep(t)
 
-		unit, err := i.Isolate(t.Context(), "test-sleep.scope", cmd.Process)
+		unit, err := i.Isolate(t.Context(), "test-sleep.scope", cmd.Process, nil)
 		require.NoError(t, err)
 		t.Logf("started unit %q", unit)
 		require.NoError(t, i.Cleanup(t.Context(), unit))
@@ -108,7 +108,7 @@ func Test_cgroupsIsolator_isolateProcess(t *testing.T) {
 
 	t.Run("create and cleanup", func(t *testing.T) {
 		cmd := startSleep(t)
-		group, err := i.Isolate(t.Context(), "test-sleep.scope", cmd.Process)
+		group, err := i.Isolate(t.Context(), "test-sleep.scope", cmd.Process, nil)
 		require.NoError(t, err)
 		t.Logf("started process in cgroup %q", group)
 		g, err := cgroup2.PidGroupPath(cmd.Process.Pid)
@@ -121,7 +121,7 @@ func Test_cgroupsIsolator_isolateProcess(t *testing.T) {
 		// this test is to verify we can recover from an unclean shutdown that left
 		// the empty cgroup behind
 		cmd := startSleep(t)
-		group, err := i.Isolate(t.Context(), "test-double-sleep.service", cmd.Process)
+		group, err := i.Isolate(t.Context(), "test-double-sleep.service", cmd.Process, nil)
 		require.NoError(t, err)
 		t.Logf("started first process in cgroup %q", group)
 		require.NoError(t, cmd.Process.Kill())
@@ -129,7 +129,7 @@ func Test_cgroupsIsolator_isolateProcess(t *testing.T) {
 		require.NoError(t, err)
 
 		cmd = startSleep(t)
-		group2, err := i.Isolate(t.Context(), "test-double-sleep.service", cmd.Process)
+		group2, err := i.Isolate(t.Context(), "test-double-sleep.service", cmd.Process, nil)
 		require.NoError(t, err)
 		t.Logf("started second process in cgroup %q", group2)
 		assert.Equal(t, group, group2)
@@ -151,7 +151,7 @@ func Test_cgroupsIsolator_Cleanup_Noise(t *testing.T) {
 	log.L.Logger.SetOutput(&buf)
 
 	cmd := startSleep(t)
-	group, err := i.Isolate(t.Context(), "test-sleep.scope", cmd.Process)
+	group, err := i.Isolate(t.Context(), "test-sleep.scope", cmd.Process, nil)
 	require.NoError(t, err)
 
 	assert.NoError(t, i.Cleanup(t.Context(), group))
@@ -162,6 +162,49 @@ func Test_cgroupsIsolator_Cleanup_Noise(t *testing.T) {
 	assert.Empty(t, buf.String(), "double cleanup should not log")
 }
 
+func Test_cgroupsResources(t *testing.T) {
+	assert.Empty(t, cgroupsResources(nil).Values())
+	r := cgroupsResources(&Limits{
+		MemoryMax: 512 << 20,
+		CPUWeight: 50,
+		CPUQuota:  1.5,
+		PidsMax:   100,
+		IOWeight:  10,
+	})
+	assert.Equal(t, []string{"cpu", "memory", "pids", "io"}, r.EnabledControllers())
+	if assert.NotNil(t, r.Memory) {
+		assert.Equal(t, int64(512<<20), *r.Memory.Max)
+	}
+	if assert.NotNil(t, r.CPU) {
+		assert.Equal(t, uint64(50), *r.CPU.Weight)
+		assert.Equal(t, cgroup2.CPUMax("150000 100000"), r.CPU.Max)
+	}
+	if assert.NotNil(t, r.Pids) {
+		assert.Equal(t, int64(100), r.Pids.Max)
+	}
+}
+
+func Test_systemdLimits(t *testing.T) {
+	assert.Empty(t, systemdLimits(nil))
+	props := map[string]any{}
+	for _, p := range systemdLimits(&Limits{
+		MemoryMax: 512 << 20,
+		CPUWeight: 50,
+		CPUQuota:  1.5,
+		PidsMax:   100,
+		IOWeight:  10,
+	}) {
+		props[p.Name] = p.Value.Value()
+	}
+	assert.Equal(t, map[string]any{
+		"MemoryMax":          uint64(512 << 20),
+		"CPUWeight":          uint64(50),
+		"CPUQuotaPerSecUSec": uint64(1_500_000),
+		"TasksMax":           uint64(100),
+		"IOWeight":           uint64(10),
+	}, props)
+}
+
 func startSleep(t *testing.T) *exec.Cmd {
 	cmd := exec.CommandContext(t.Context(), "sleep", "1h")
 	require.NoError(t, cmd.Start())
@@ -0,0 +1,24 @@
+package sys
+
+// FileEvent describes a change to an entry in a directory watched by a
+// [Watcher].
+type FileEvent struct {
+	Path  string
+	IsDir bool
+	// Created is set if the entry was created or moved into the directory.
+	Created bool
+}
+
+// Watcher watches directories for changes to their entries. It is not
+// recursive: callers must add new subdirectories as they are created.
+type Watcher interface {
+	// Add starts watching the given directory.
+	Add(dir string) error
+	// Events delivers changes in the watched directories. It is closed when the
+	// watcher is closed.
+	Events() <-chan FileEvent
+	// Errors delivers failures reading events. It is closed when the watcher is
+	// closed.
+	Errors() <-chan error
+	Close() error
+}
@@ -0,0 +1,132 @@
+package sys
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sync"
+	"unsafe"
+
+	"golang.org/x/sys/unix"
+)
+
+const inotifyMask = unix.IN_CLOSE_WRITE |
+	unix.IN_CREATE |
+	unix.IN_DELETE |
+	unix.IN_MOVED_FROM |
+	unix.IN_MOVED_TO |
+	unix.IN_DELETE_SELF
+
+// NewWatcher creates a [Watcher] using inotify.
+func NewWatcher() (Watcher, error) {
+	fd, err := unix.InotifyInit1(unix.IN_CLOEXEC | unix.IN_NONBLOCK)
+	if err != nil {
+		return nil, fmt.Errorf("failed to init inotify: %w", err)
+	}
+	w := &inotifyWatcher{
+		fd: fd,
+		// non-blocking so that reads go through the runtime poller and Close will
+		// interrupt them
+		f:      os.NewFile(uintptr(fd), "inotify"),
+		dirs:   map[int]string{},
+		events: make(chan FileEvent),
+		errors: make(chan error, 1),
+		done:   make(chan struct{}),
+	}
+	go w.read()
+	return w, nil
+}
+
+type inotifyWatcher struct {
+	fd     int
+	f      *os.File
+	mu     sync.Mutex
+	dirs   map[int]string
+	events chan FileEvent
+	errors chan error
+	done   chan struct{}
+	once   sync.Once
+}
+
+// Add implements Watcher.
+func (w *inotifyWatcher) Add(dir string) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	wd, err := unix.InotifyAddWatch(w.fd, dir, inotifyMask|unix.IN_ONLYDIR)
+	if err != nil {
+		return &fs.PathError{Op: "inotify_add_watch", Path: dir, Err: err}
+	}
+	w.dirs[wd] = dir
+	return nil
+}
+
+// Events implements Watcher.
+func (w *inotifyWatcher) Events() <-chan FileEvent { return w.events }
+
+// Errors implements Watcher.
+func (w *inotifyWatcher) Errors() <-chan error { return w.errors }
+
+// Close implements Watcher.
+func (w *inotifyWatcher) Close() error {
+	var err error
+	w.once.Do(func() {
+		close(w.done)
+		err = w.f.Close()
+	})
+	return err
+}
+
+func (w *inotifyWatcher) read() {
+	defer close(w.errors)
+	defer close(w.events)
+	buf := make([]byte, 64*(unix.SizeofInotifyEvent+unix.NAME_MAX+1))
+	for {
+		n, err := w.f.Read(buf)
+		if err != nil {
+			if !errors.Is(err, os.ErrClosed) {
+				select {
+				case w.errors <- fmt.Errorf("failed reading inotify events: %w", err):
+				case <-w.done:
+				}
+			}
+			return
+		}
+		for off := 0; off+unix.SizeofInotifyEvent <= n; {
+			ev := (*unix.InotifyEvent)(unsafe.Pointer(&buf[off]))
+			nameBytes := buf[off+unix.SizeofInotifyEvent : off+unix.SizeofInotifyEvent+int(ev.Len)]
+			off += unix.SizeofInotifyEvent + int(ev.Len)
+			if ev.Mask&unix.IN_Q_OVERFLOW != 0 {
+				select {
+				case w.errors <- errors.New("inotify event queue overflowed, some changes were missed"):
+				case <-w.done:
+					return
+				}
+				continue
+			}
+			w.mu.Lock()
+			dir, ok := w.dirs[int(ev.Wd)]
+			if ev.Mask&unix.IN_IGNORED != 0 {
+				delete(w.dirs, int(ev.Wd))
+			}
+			w.mu.Unlock()
+			if !ok || ev.Mask&inotifyMask == 0 {
+				continue
+			}
+			fe := FileEvent{
+				Path:    dir,
+				IsDir:   ev.Mask&unix.IN_ISDIR != 0 || ev.Mask&unix.IN_DELETE_SELF != 0,
+				Created: ev.Mask&(unix.IN_CREATE|unix.IN_MOVED_TO) != 0,
+			}
+			if name := unix.ByteSliceToString(nameBytes); name != "" {
+				fe.Path = filepath.Join(dir, name)
+			}
+			select {
+			case w.events <- fe:
+			case <-w.done:
+				return
+			}
+		}
+	}
+}
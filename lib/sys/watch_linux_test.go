@@ -0,0 +1,43 @@
+package sys
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestInotifyWatcher(t *testing.T) {
+	dir := t.TempDir()
+	w, err := NewWatcher()
+	require.NoError(t, err)
+	t.Cleanup(func() { assert.NoError(t, w.Close()) })
+	require.NoError(t, w.Add(dir))
+
+	next := func() FileEvent {
+		t.Helper()
+		select {
+		case ev := <-w.Events():
+			return ev
+		case err := <-w.Errors():
+			require.NoError(t, err)
+		case <-time.After(5 * time.Second):
+			require.FailNow(t, "timed out waiting for event")
+		}
+		return FileEvent{}
+	}
+
+	require.NoError(t, os.Mkdir(filepath.Join(dir, "sub"), 0o755))
+	assert.Equal(t, FileEvent{Path: filepath.Join(dir, "sub"), IsDir: true, Created: true}, next())
+
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "f"), []byte("x"), 0o644))
+	assert.Equal(t, FileEvent{Path: filepath.Join(dir, "f"), Created: true}, next())
+	assert.Equal(t, FileEvent{Path: filepath.Join(dir, "f")}, next())
+
+	require.NoError(t, w.Close())
+	_, ok := <-w.Events()
+	assert.False(t, ok)
+}
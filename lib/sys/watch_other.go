@@ -0,0 +1,12 @@
+//go:build !linux
+
+package sys
+
+import (
+	"fmt"
+	"runtime"
+)
+
+func NewWatcher() (Watcher, error) {
+	return nil, fmt.Errorf("file watching not supported on %s", runtime.GOOS)
+}
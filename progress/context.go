@@ -2,57 +2,84 @@ package progress
 
 import (
 	"context"
-	"runtime"
+	"fmt"
+	"os"
 	"sync"
-
-	"github.com/jedib0t/go-pretty/v6/progress"
+	"sync/atomic"
+	"time"
 )
 
-type writerKey struct{}
+type sinkKey struct{}
+
+type reporter struct {
+	sink Sink
+	// trackers is used to assign tracker indexes
+	trackers atomic.Uint64
+}
 
-func WithWriter(ctx context.Context, w progress.Writer) context.Context {
-	return context.WithValue(ctx, writerKey{}, w)
+// WithSink attaches a sink to the context, for use by [AddTracker] and [Logf].
+func WithSink(ctx context.Context, sink Sink) context.Context {
+	return context.WithValue(ctx, sinkKey{}, &reporter{sink: sink})
 }
 
-func ContextWriter(ctx context.Context) progress.Writer {
-	w, _ := ctx.Value(writerKey{}).(progress.Writer)
-	return w
+func contextReporter(ctx context.Context) *reporter {
+	r, _ := ctx.Value(sinkKey{}).(*reporter)
+	return r
 }
 
+// ContextSink gets the sink attached to the context, if any.
+func ContextSink(ctx context.Context) Sink {
+	if r := contextReporter(ctx); r != nil {
+		return r.sink
+	}
+	return nil
+}
+
+// StartWriter attaches a new sink using the [DefaultFormat] to the context, if
+// it doesn't already have one. The returned stop function must be called when
+// progress reporting is done, it is safe to call more than once.
 func StartWriter(ctx context.Context) (_ context.Context, stop func()) {
-	if w := ContextWriter(ctx); w != nil {
+	if r := contextReporter(ctx); r != nil {
 		// don't create a duplicate one
 		return ctx, func() {}
 	}
+	sink := NewSink(*DefaultFormat())
+	return WithSink(ctx, sink), sync.OnceFunc(sink.Stop)
+}
 
-	// progress has an internal context, but doesn't support setting it to base
-	// off something other than context.Background()
-	pw := progress.NewWriter()
-	pw.SetStyle(progress.StyleBlocks)
-	pw.SetTrackerPosition(progress.PositionRight)
-	pw.SetSortBy(progress.SortByIndex)
-	var wg sync.WaitGroup
-	wg.Go(func() { pw.Render() })
-	// if the caller's work all finishes before render starts, then Stop() might
-	// not stop it, so we wait for it to confirm it's running before we return, so
-	// that callers don't have to worry about this.
-	for !pw.IsRenderInProgress() {
-		// this REALLY should not take long, don't even bother sleeping, just yield
-		// the scheduler so the other goroutine is sure to run ASAP.
-		runtime.Gosched()
+// AddTracker adds the tracker to the sink attached to the context. If there is
+// no sink, the tracker will still work, but its progress will not be reported.
+func AddTracker(ctx context.Context, t *Tracker) {
+	r := contextReporter(ctx)
+	if r == nil {
+		return
 	}
-	stop = func() {
-		pw.Stop()
-		wg.Wait()
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.Index == 0 {
+		t.Index = r.trackers.Add(1)
+	}
+	t.report = r.sink.Report
+	t.emit(EventStart)
+	switch {
+	case t.errored:
+		t.emit(EventError)
+	case t.done:
+		t.emit(EventDone)
 	}
-	return WithWriter(ctx, pw), stop
 }
 
-func AddTracker(ctx context.Context, t *progress.Tracker) {
-	if w := ContextWriter(ctx); w != nil {
-		if t.Index == 0 {
-			t.Index = uint64(w.Length() + 1)
-		}
-		w.AppendTracker(t)
+var standaloneJSON = NewJSONSink(os.Stdout)
+
+// Logf reports a free-form message. If the context has no sink, the message is
+// printed directly, in JSON format if that is the [DefaultFormat].
+func Logf(ctx context.Context, format string, args ...any) {
+	msg := fmt.Sprintf(format, args...)
+	if r := contextReporter(ctx); r != nil {
+		r.sink.Report(Event{Time: time.Now(), Type: EventLog, Message: msg})
+	} else if DefaultFormat().resolve() == FormatJSON {
+		standaloneJSON.Report(Event{Time: time.Now(), Type: EventLog, Message: msg})
+	} else {
+		fmt.Println(msg)
 	}
 }
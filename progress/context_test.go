@@ -1,6 +1,12 @@
 package progress
 
-import "testing"
+import (
+	"bytes"
+	"testing"
+
+	"github.com/jedib0t/go-pretty/v6/progress"
+	"github.com/stretchr/testify/assert"
+)
 
 func TestStartWriter(t *testing.T) {
 	ctx, stop := StartWriter(t.Context())
@@ -10,3 +16,16 @@ func TestStartWriter(t *testing.T) {
 	stop()
 	t.Log("stopped")
 }
+
+func TestDeprecatedWriter(t *testing.T) {
+	pw := progress.NewWriter()
+	ctx := WithWriter(t.Context(), pw)
+	assert.Same(t, pw, ContextWriter(ctx))
+	AddTracker(ctx, &Tracker{Message: "new"})
+	AddPrettyTracker(ctx, &PrettyTracker{Message: "old"})
+	assert.Equal(t, 2, pw.Length())
+
+	ctx = WithSink(t.Context(), NewPlainSink(&bytes.Buffer{}))
+	assert.Nil(t, ContextWriter(ctx))
+	AddPrettyTracker(ctx, &PrettyTracker{Message: "old"})
+}
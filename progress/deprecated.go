@@ -0,0 +1,47 @@
+package progress
+
+import (
+	"context"
+
+	"github.com/jedib0t/go-pretty/v6/progress"
+)
+
+// PrettyTracker is the go-pretty tracker type that [Tracker] used to be an
+// alias for.
+//
+// Deprecated: use [Tracker], which works with every [Format].
+type PrettyTracker = progress.Tracker
+
+// WithWriter attaches a sink that renders to the given go-pretty writer. The
+// caller remains responsible for rendering and stopping the writer.
+//
+// Deprecated: use [WithSink].
+func WithWriter(ctx context.Context, w progress.Writer) context.Context {
+	return WithSink(ctx, newFancySink(w))
+}
+
+// ContextWriter gets the go-pretty writer rendering progress for the context.
+// It returns nil if there is no sink, or if the sink does not use
+// [FormatFancy].
+//
+// Deprecated: use [ContextSink] and [AddTracker].
+func ContextWriter(ctx context.Context) progress.Writer {
+	if s, ok := ContextSink(ctx).(*fancySink); ok {
+		return s.pw
+	}
+	return nil
+}
+
+// AddPrettyTracker adds a go-pretty tracker to the writer from
+// [ContextWriter], if there is one. Its progress is not reported in other
+// formats.
+//
+// Deprecated: use [Tracker] and [AddTracker].
+func AddPrettyTracker(ctx context.Context, t *PrettyTracker) {
+	if w := ContextWriter(ctx); w != nil {
+		if t.Index == 0 {
+			t.Index = uint64(w.Length() + 1)
+		}
+		w.AppendTracker(t)
+	}
+}
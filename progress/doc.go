@@ -1,4 +1,5 @@
-// Package progress provides thin wrappers and helpers around the go-pretty
-// progress package to simplify aggregate progress reporting across multiple
-// activities.
+// Package progress provides aggregate progress reporting across multiple
+// activities, rendered by a pluggable [Sink]: animated progress bars for
+// interactive terminals (using the go-pretty progress package), plain lines for
+// logs and CI, or a stream of JSON events for tools.
 package progress
@@ -4,7 +4,7 @@ import "github.com/jedib0t/go-pretty/v6/progress"
 
 // re-export types we want to expose as-is
 
-type Tracker = progress.Tracker
+type Units = progress.Units
 
 const (
 	PositionLeft  = progress.PositionLeft
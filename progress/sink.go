@@ -0,0 +1,255 @@
+package progress
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"runtime"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/jedib0t/go-pretty/v6/progress"
+	"golang.org/x/term"
+)
+
+// Sink renders progress events. Implementations must be safe for concurrent
+// use.
+type Sink interface {
+	Report(Event)
+	// Stop flushes any pending output. No events will be reported after this.
+	Stop()
+}
+
+type EventType string
+
+const (
+	// EventStart is reported when a tracker is added.
+	EventStart EventType = "start"
+	// EventMessage is reported when a tracker's message changes.
+	EventMessage EventType = "message"
+	// EventProgress is reported when a tracker's value or total changes
+	// significantly.
+	EventProgress EventType = "progress"
+	// EventDone is reported when a tracker completes.
+	EventDone EventType = "done"
+	// EventError is reported when a tracker fails.
+	EventError EventType = "error"
+	// EventLog is a free-form message not associated with a tracker, see
+	// [Logf].
+	EventLog EventType = "log"
+)
+
+// Event describes a change in progress. Tracker events include the full
+// current state of the tracker.
+type Event struct {
+	Time time.Time `json:"time"`
+	Type EventType `json:"type"`
+	// Tracker is the tracker's Index, or zero for log events.
+	Tracker uint64 `json:"tracker,omitempty"`
+	Message string `json:"message"`
+	Value   int64  `json:"value,omitempty"`
+	Total   int64  `json:"total,omitempty"`
+	Units   Units  `json:"-"`
+}
+
+// Format selects a [Sink] implementation.
+type Format string
+
+const (
+	// FormatAuto uses FormatFancy if stdout is an interactive terminal, else
+	// FormatPlain.
+	FormatAuto Format = "auto"
+	// FormatFancy renders animated progress bars.
+	FormatFancy Format = "fancy"
+	// FormatPlain prints a line for each significant change.
+	FormatPlain Format = "plain"
+	// FormatJSON prints each [Event] as a line of JSON.
+	FormatJSON Format = "json"
+)
+
+var Formats = []Format{FormatAuto, FormatFancy, FormatPlain, FormatJSON}
+
+// String implements pflag.Value.
+func (f Format) String() string { return string(f) }
+
+// Set implements pflag.Value.
+func (f *Format) Set(s string) error {
+	if !slices.Contains(Formats, Format(s)) {
+		return fmt.Errorf("invalid progress format %q", s)
+	}
+	*f = Format(s)
+	return nil
+}
+
+// Type implements pflag.Value.
+func (f *Format) Type() string { return "format" }
+
+var defaultFormat = FormatAuto
+
+// DefaultFormat returns the format used by [StartWriter]. The returned pointer
+// may be used as a flag value to change it.
+func DefaultFormat() *Format {
+	return &defaultFormat
+}
+
+// resolve converts FormatAuto into a concrete format.
+func (f Format) resolve() Format {
+	if f != FormatAuto {
+		return f
+	}
+	if term.IsTerminal(int(os.Stdout.Fd())) && os.Getenv("TERM") != "dumb" {
+		return FormatFancy
+	}
+	return FormatPlain
+}
+
+// NewSink creates a sink for the given format, writing to stdout.
+func NewSink(f Format) Sink {
+	switch f.resolve() {
+	case FormatFancy:
+		return NewFancySink()
+	case FormatJSON:
+		return NewJSONSink(os.Stdout)
+	default:
+		return NewPlainSink(os.Stdout)
+	}
+}
+
+type fancySink struct {
+	pw       progress.Writer
+	wg       sync.WaitGroup
+	mu       sync.Mutex
+	trackers map[uint64]*progress.Tracker
+}
+
+// NewFancySink creates a sink that renders animated progress bars on stdout.
+func NewFancySink() Sink {
+	// progress has an internal context, but doesn't support setting it to base
+	// off something other than context.Background()
+	pw := progress.NewWriter()
+	pw.SetStyle(progress.StyleBlocks)
+	pw.SetTrackerPosition(progress.PositionRight)
+	pw.SetSortBy(progress.SortByIndex)
+	s := newFancySink(pw)
+	s.wg.Go(func() { pw.Render() })
+	// if the caller's work all finishes before render starts, then Stop() might
+	// not stop it, so we wait for it to confirm it's running before we return, so
+	// that callers don't have to worry about this.
+	for !pw.IsRenderInProgress() {
+		// this REALLY should not take long, don't even bother sleeping, just yield
+		// the scheduler so the other goroutine is sure to run ASAP.
+		runtime.Gosched()
+	}
+	return s
+}
+
+func newFancySink(pw progress.Writer) *fancySink {
+	return &fancySink{pw: pw, trackers: map[uint64]*progress.Tracker{}}
+}
+
+// Report implements Sink.
+func (s *fancySink) Report(ev Event) {
+	if ev.Type == EventLog {
+		s.pw.Log("%s", ev.Message)
+		return
+	}
+	s.mu.Lock()
+	pt := s.trackers[ev.Tracker]
+	if pt == nil {
+		pt = &progress.Tracker{Message: ev.Message, Total: ev.Total, Units: ev.Units, Index: ev.Tracker}
+		s.trackers[ev.Tracker] = pt
+		s.pw.AppendTracker(pt)
+	}
+	s.mu.Unlock()
+	switch ev.Type {
+	case EventMessage:
+		pt.UpdateMessage(ev.Message)
+	case EventProgress:
+		pt.UpdateTotal(ev.Total)
+		pt.SetValue(ev.Value)
+	case EventDone:
+		pt.UpdateMessage(ev.Message)
+		pt.MarkAsDone()
+	case EventError:
+		pt.UpdateMessage(ev.Message)
+		pt.MarkAsErrored()
+	}
+}
+
+// Stop implements Sink.
+func (s *fancySink) Stop() {
+	s.pw.Stop()
+	s.wg.Wait()
+}
+
+type plainSink struct {
+	mu sync.Mutex
+	w  io.Writer
+	// last reported tenth of progress for each tracker
+	tenths map[uint64]int64
+}
+
+// NewPlainSink creates a sink that writes a line of text to w for each
+// significant change.
+func NewPlainSink(w io.Writer) Sink {
+	return &plainSink{w: w, tenths: map[uint64]int64{}}
+}
+
+// Report implements Sink.
+func (s *plainSink) Report(ev Event) {
+	var line string
+	switch ev.Type {
+	case EventStart, EventMessage, EventLog:
+		line = ev.Message
+	case EventProgress:
+		if ev.Total <= 0 {
+			line = fmt.Sprintf("%s: %s", ev.Message, ev.Units.Sprint(ev.Value))
+			break
+		}
+		tenth := ev.Value * 10 / ev.Total
+		s.mu.Lock()
+		last := s.tenths[ev.Tracker]
+		s.tenths[ev.Tracker] = tenth
+		s.mu.Unlock()
+		if tenth == last {
+			return
+		}
+		line = fmt.Sprintf("%s: %d%% (%s of %s)",
+			ev.Message, tenth*10, ev.Units.Sprint(ev.Value), ev.Units.Sprint(ev.Total))
+	case EventDone:
+		line = ev.Message + ": done"
+	case EventError:
+		line = ev.Message + ": failed"
+	default:
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	_, _ = fmt.Fprintln(s.w, strings.TrimRight(line, "\n"))
+}
+
+// Stop implements Sink.
+func (s *plainSink) Stop() {}
+
+type jsonSink struct {
+	mu  sync.Mutex
+	enc *json.Encoder
+}
+
+// NewJSONSink creates a sink that writes each [Event] to w as a line of JSON.
+func NewJSONSink(w io.Writer) Sink {
+	return &jsonSink{enc: json.NewEncoder(w)}
+}
+
+// Report implements Sink.
+func (s *jsonSink) Report(ev Event) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	_ = s.enc.Encode(ev)
+}
+
+// Stop implements Sink.
+func (s *jsonSink) Stop() {}
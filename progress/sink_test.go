@@ -0,0 +1,77 @@
+package progress
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPlainSink(t *testing.T) {
+	var buf bytes.Buffer
+	ctx := WithSink(t.Context(), NewPlainSink(&buf))
+	pt := &Tracker{Message: "Downloading", Total: 100, Units: UnitsDefault}
+	AddTracker(ctx, pt)
+	for range 100 {
+		pt.Increment(1)
+	}
+	pt.MarkAsDone()
+	Logf(ctx, "hello %s", "world")
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	require.Len(t, lines, 13)
+	assert.Equal(t, "Downloading", lines[0])
+	assert.Equal(t, "Downloading: 10% (10 of 100)", lines[1])
+	assert.Equal(t, "Downloading: 100% (100 of 100)", lines[10])
+	assert.Equal(t, "Downloading: done", lines[11])
+	assert.Equal(t, "hello world", lines[12])
+}
+
+func TestJSONSink(t *testing.T) {
+	var buf bytes.Buffer
+	ctx := WithSink(t.Context(), NewJSONSink(&buf))
+	pt := &Tracker{Message: "Waiting", Units: UnitsDefault}
+	AddTracker(ctx, pt)
+	pt.UpdateMessage("Waiting: not ready")
+	pt.MarkAsErrored()
+	// late additions report their current state
+	done := &Tracker{Message: "Skipped", Units: UnitsDefault}
+	done.MarkAsDone()
+	AddTracker(ctx, done)
+
+	var events []Event
+	dec := json.NewDecoder(&buf)
+	for dec.More() {
+		var ev Event
+		require.NoError(t, dec.Decode(&ev))
+		assert.False(t, ev.Time.IsZero())
+		events = append(events, ev)
+	}
+	type summary struct {
+		Type    EventType
+		Tracker uint64
+		Message string
+	}
+	var got []summary
+	for _, ev := range events {
+		got = append(got, summary{ev.Type, ev.Tracker, ev.Message})
+	}
+	assert.Equal(t, []summary{
+		{EventStart, 1, "Waiting"},
+		{EventMessage, 1, "Waiting: not ready"},
+		{EventError, 1, "Waiting: not ready"},
+		{EventStart, 2, "Skipped"},
+		{EventDone, 2, "Skipped"},
+	}, got)
+}
+
+func TestFormat_Set(t *testing.T) {
+	var f Format
+	require.NoError(t, f.Set("json"))
+	assert.Equal(t, FormatJSON, f)
+	assert.Error(t, f.Set("fancy-pants"))
+	assert.Equal(t, FormatJSON, f)
+	assert.Equal(t, FormatFancy, FormatFancy.resolve())
+}
@@ -0,0 +1,153 @@
+package progress
+
+import (
+	"sync"
+	"time"
+)
+
+// Tracker tracks the progress of a single activity. Changes are reported to
+// the sink it was added to with [AddTracker], if any.
+type Tracker struct {
+	Message string
+	// Total is the expected final value, or zero if it is not known.
+	Total int64
+	Units Units
+	// Index orders trackers for display. If zero, [AddTracker] will assign one.
+	Index uint64
+
+	mu      sync.Mutex
+	report  func(Event)
+	value   int64
+	done    bool
+	errored bool
+	// used to avoid flooding the sink with tiny progress increments
+	lastPercent  int64
+	lastProgress time.Time
+}
+
+// progressInterval is how often progress is reported for trackers without a
+// known total.
+const progressInterval = time.Second
+
+func (t *Tracker) event(typ EventType) Event {
+	return Event{
+		Time:    time.Now(),
+		Type:    typ,
+		Tracker: t.Index,
+		Message: t.Message,
+		Value:   t.value,
+		Total:   t.Total,
+		Units:   t.Units,
+	}
+}
+
+// emit reports an event, must be called with the lock held
+func (t *Tracker) emit(typ EventType) {
+	if t.report != nil {
+		t.report(t.event(typ))
+	}
+}
+
+func (t *Tracker) percent() int64 {
+	if t.Total <= 0 {
+		return 0
+	}
+	return t.value * 100 / t.Total
+}
+
+func (t *Tracker) setValue(value int64) {
+	if t.done {
+		return
+	}
+	t.value = value
+	if t.Total > 0 {
+		if p := t.percent(); p != t.lastPercent {
+			t.lastPercent = p
+			t.emit(EventProgress)
+		}
+	} else if now := time.Now(); now.Sub(t.lastProgress) >= progressInterval {
+		t.lastProgress = now
+		t.emit(EventProgress)
+	}
+}
+
+// Increment adds value to the progress.
+func (t *Tracker) Increment(value int64) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.setValue(t.value + value)
+}
+
+// SetValue sets the progress value.
+func (t *Tracker) SetValue(value int64) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.setValue(value)
+}
+
+// Value gets the current progress value.
+func (t *Tracker) Value() int64 {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.value
+}
+
+// UpdateMessage changes the message describing the activity.
+func (t *Tracker) UpdateMessage(msg string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if msg == t.Message {
+		return
+	}
+	t.Message = msg
+	t.emit(EventMessage)
+}
+
+// UpdateTotal changes the expected final value.
+func (t *Tracker) UpdateTotal(total int64) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if total == t.Total {
+		return
+	}
+	t.Total = total
+	t.lastPercent = t.percent()
+	t.emit(EventProgress)
+}
+
+// MarkAsDone marks the activity as having completed successfully.
+func (t *Tracker) MarkAsDone() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.done {
+		return
+	}
+	t.done = true
+	t.emit(EventDone)
+}
+
+// MarkAsErrored marks the activity as having failed. This may be called after
+// [Tracker.MarkAsDone], if something that was complete breaks.
+func (t *Tracker) MarkAsErrored() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.errored {
+		return
+	}
+	t.done, t.errored = true, true
+	t.emit(EventError)
+}
+
+// IsDone reports whether the activity has finished, successfully or not.
+func (t *Tracker) IsDone() bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.done
+}
+
+// IsErrored reports whether the activity has failed.
+func (t *Tracker) IsErrored() bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.errored
+}
@@ -0,0 +1,56 @@
+package resource
+
+import (
+	"context"
+	"strings"
+)
+
+// PlanAction summarizes what starting a resource would do.
+type PlanAction string
+
+const (
+	// PlanCreate means the resource does not exist and would be created.
+	PlanCreate PlanAction = "create"
+	// PlanUpdate means the resource exists but its definition would change.
+	PlanUpdate PlanAction = "update"
+	// PlanRestart means the resource would be restarted without changing its
+	// definition.
+	PlanRestart PlanAction = "restart"
+	// PlanNone means the resource is already as desired and would be left alone.
+	PlanNone PlanAction = "none"
+	// PlanStop means the resource would be stopped, e.g. for a disabled service.
+	PlanStop PlanAction = "stop"
+	// PlanUnknown means the resource cannot tell what starting it would do.
+	PlanUnknown PlanAction = "unknown"
+)
+
+// Plan describes what starting a resource would do.
+type Plan struct {
+	Action PlanAction `json:"action"`
+	// Changes optionally lists what would change, e.g. which fields differ.
+	Changes []string `json:"changes,omitempty"`
+}
+
+// String provides a brief human readable summary of the plan.
+func (p Plan) String() string {
+	if len(p.Changes) == 0 {
+		return string(p.Action)
+	}
+	return string(p.Action) + ": " + strings.Join(p.Changes, ", ")
+}
+
+// Planner is an optional interface for resources that can report what Start
+// would do, without changing anything.
+type Planner interface {
+	Resource
+	Plan(context.Context) (Plan, error)
+}
+
+// PlanStart gets the plan for starting the resource. If it does not implement
+// [Planner], the plan is [PlanUnknown].
+func PlanStart(ctx context.Context, r Resource) (Plan, error) {
+	if p, ok := r.(Planner); ok {
+		return p.Plan(ctx)
+	}
+	return Plan{Action: PlanUnknown}, nil
+}
@@ -0,0 +1,98 @@
+package resource
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// ReadyState summarizes the readiness of a resource or one of its parts.
+type ReadyState string
+
+const (
+	// ReadyStateReady means the resource is ready for use.
+	ReadyStateReady ReadyState = "ready"
+	// ReadyStateNotReady means the resource is not ready yet, but is expected to
+	// become ready.
+	ReadyStateNotReady ReadyState = "not-ready"
+	// ReadyStateFailed means the resource is not ready, and is not expected to
+	// become ready without intervention.
+	ReadyStateFailed ReadyState = "failed"
+	// ReadyStateUnknown means the readiness could not be determined.
+	ReadyStateUnknown ReadyState = "unknown"
+)
+
+// ReadyCondition is one part of the readiness of a resource, such as a health
+// check or a container within a pod.
+type ReadyCondition struct {
+	Name    string     `json:"name"`
+	State   ReadyState `json:"state"`
+	Message string     `json:"message,omitempty"`
+}
+
+// ReadyStatus is a structured description of why a resource is or is not
+// ready.
+type ReadyStatus struct {
+	State      ReadyState       `json:"state"`
+	Message    string           `json:"message,omitempty"`
+	Conditions []ReadyCondition `json:"conditions,omitempty"`
+}
+
+// Ready reports whether the status indicates the resource is ready.
+func (s ReadyStatus) Ready() bool {
+	return s.State == ReadyStateReady
+}
+
+// String provides a brief human readable summary of the status, including any
+// conditions that are not ready.
+func (s ReadyStatus) String() string {
+	var sb strings.Builder
+	sb.WriteString(string(s.State))
+	if s.Message != "" {
+		sb.WriteString(": ")
+		sb.WriteString(s.Message)
+	}
+	for _, c := range s.Conditions {
+		if c.State == ReadyStateReady {
+			continue
+		}
+		fmt.Fprintf(&sb, "; %s %s", c.Name, c.State)
+		if c.Message != "" {
+			sb.WriteString(": ")
+			sb.WriteString(c.Message)
+		}
+	}
+	return sb.String()
+}
+
+// ReadyDetailer is an optional interface for resources that can explain why
+// they are or are not ready.
+//
+// The ready state and error returned from ReadyDetails should be consistent
+// with what Ready would return.
+type ReadyDetailer interface {
+	Resource
+	ReadyDetails(context.Context) (ReadyStatus, error)
+}
+
+// ReadyDetails gets the structured ready status of the resource. If it does not
+// implement [ReadyDetailer], a basic status is synthesized from its Ready
+// method.
+func ReadyDetails(ctx context.Context, r Resource) (ReadyStatus, error) {
+	if rd, ok := r.(ReadyDetailer); ok {
+		return rd.ReadyDetails(ctx)
+	}
+	ready, err := r.Ready(ctx)
+	return ReadyStatusFromBool(ready, err), err
+}
+
+// ReadyStatusFromBool creates a basic ready status from the results of a
+// Resource's Ready method.
+func ReadyStatusFromBool(ready bool, err error) ReadyStatus {
+	if err != nil {
+		return ReadyStatus{State: ReadyStateFailed, Message: err.Error()}
+	} else if ready {
+		return ReadyStatus{State: ReadyStateReady}
+	}
+	return ReadyStatus{State: ReadyStateNotReady}
+}
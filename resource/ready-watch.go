@@ -0,0 +1,41 @@
+package resource
+
+import "context"
+
+// ReadyWatcher is an optional interface for resources that can tell waiters
+// when their readiness may have changed, so they don't have to poll for it.
+type ReadyWatcher interface {
+	Resource
+	// WatchReady sends on the returned channel whenever the resource's readiness
+	// may have changed, until ctx is done. The channel is closed if watching
+	// stops early, e.g. because it isn't supported, and waiters should fall back
+	// on polling.
+	WatchReady(ctx context.Context) <-chan struct{}
+}
+
+// WatchReady merges the notifications from all the resources that implement
+// [ReadyWatcher]. It returns nil if none of them do. The returned channel is
+// never closed.
+func WatchReady(ctx context.Context, resources ...Resource) <-chan struct{} {
+	var out chan struct{}
+	for _, r := range resources {
+		rw, ok := r.(ReadyWatcher)
+		if !ok {
+			continue
+		}
+		if out == nil {
+			out = make(chan struct{}, 1)
+		}
+		ch := rw.WatchReady(ctx)
+		go func() {
+			for range ch {
+				select {
+				case out <- struct{}{}:
+				default:
+					// a notification is already pending
+				}
+			}
+		}()
+	}
+	return out
+}
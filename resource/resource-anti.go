@@ -39,3 +39,31 @@ func (a *anti) Ready(ctx context.Context) (bool, error) {
 	inner, _ := a.r.Ready(ctx)
 	return !inner, nil
 }
+
+// ReadyDetails implements ReadyDetailer.
+func (a *anti) ReadyDetails(ctx context.Context) (ReadyStatus, error) {
+	// we expect errors checking status of services we stopped
+	inner, _ := ReadyDetails(ctx, a.r)
+	if inner.Ready() {
+		return ReadyStatus{
+			State:      ReadyStateNotReady,
+			Message:    "still running",
+			Conditions: inner.Conditions,
+		}, nil
+	}
+	return ReadyStatus{State: ReadyStateReady, Message: "stopped"}, nil
+}
+
+// Plan implements Planner.
+func (a *anti) Plan(ctx context.Context) (Plan, error) {
+	// we expect errors checking status of services we stopped
+	if inner, _ := a.r.Ready(ctx); inner {
+		return Plan{Action: PlanStop}, nil
+	}
+	return Plan{Action: PlanNone}, nil
+}
+
+// Stopped implements StoppedChecker.
+func (a *anti) Stopped(ctx context.Context) (bool, error) {
+	return Stopped(ctx, a.r)
+}
@@ -2,26 +2,40 @@ package resource
 
 import (
 	"context"
+	"fmt"
 	"time"
 )
 
 type waitResource struct {
-	name  string
-	ready func(context.Context) (bool, error)
+	name    string
+	ready   func(context.Context) (bool, error)
+	details func(context.Context) (ReadyStatus, error)
+	policy  WaitPolicy
 }
 
 // Waiter creates a resource that blocks during start until the provided ready
 // function passes. That function will also provide the implementation of Ready.
 // Stop is a no-op.
 //
-// TODO: allow customizing the poll interval
-func Waiter(name string, ready func(context.Context) (bool, error)) *waitResource {
+// By default it polls every 250ms with no timeout, options can customize this.
+// If the timeout is reached, the error will include the last ready status,
+// unless [WaitLastError] overrides that.
+func Waiter(name string, ready func(context.Context) (bool, error), opts ...WaitOption) *waitResource {
 	return &waitResource{
-		name:  name,
-		ready: ready,
+		name:   name,
+		ready:  ready,
+		policy: NewWaitPolicy(250*time.Millisecond, opts...),
 	}
 }
 
+// WithReadyDetails provides a function to explain why the waiter is or is not
+// ready, implementing [ReadyDetailer]. It should be consistent with the ready
+// function.
+func (r *waitResource) WithReadyDetails(details func(context.Context) (ReadyStatus, error)) *waitResource {
+	r.details = details
+	return r
+}
+
 // ID implements Resource.
 func (r *waitResource) ID() string {
 	return "Wait/" + r.name
@@ -32,24 +46,33 @@ func (r *waitResource) Ready(ctx context.Context) (bool, error) {
 	return r.ready(ctx)
 }
 
+// ReadyDetails implements ReadyDetailer.
+func (r *waitResource) ReadyDetails(ctx context.Context) (ReadyStatus, error) {
+	if r.details == nil {
+		ready, err := r.ready(ctx)
+		return ReadyStatusFromBool(ready, err), err
+	}
+	return r.details(ctx)
+}
+
 // Start implements Resource.
 func (r *waitResource) Start(ctx context.Context) error {
-	retryTicker := time.NewTicker(250 * time.Millisecond)
-	defer retryTicker.Stop()
-	for {
-		if ready, err := r.Ready(ctx); err != nil {
-			return err
-		} else if ready {
-			return nil
-		}
-
-		select {
-		case <-ctx.Done():
-			return ctx.Err()
-		case <-retryTicker.C:
-			// continue & re-check
+	policy := r.policy
+	if policy.LastError == nil {
+		policy.LastError = func(ctx context.Context) string {
+			status, _ := r.ReadyDetails(ctx)
+			return status.String()
 		}
 	}
+	if err := policy.Poll(ctx, r.Ready); err != nil {
+		return fmt.Errorf("waiting for %s: %w", r.name, err)
+	}
+	return nil
+}
+
+// Plan implements Planner. Waiters never change anything.
+func (r *waitResource) Plan(context.Context) (Plan, error) {
+	return Plan{Action: PlanNone}, nil
 }
 
 // Stop implements Resource.
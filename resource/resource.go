@@ -6,7 +6,16 @@ type Resource interface {
 	ID() string
 	Start(context.Context) error
 	Stop(context.Context) error
-	Ready(context.Context) (bool, error) // TODO: provide not-ready details
+	// Ready checks if the resource is ready for use. Resources can provide more
+	// details on why they are not ready by implementing [ReadyDetailer].
+	Ready(context.Context) (bool, error)
+}
+
+// Restarter is an optional interface for resources that run code built from
+// local source, and so need to be restarted to pick up a new build.
+type Restarter interface {
+	Resource
+	Restart(context.Context) error
 }
 
 type ContainerResource interface {
@@ -0,0 +1,21 @@
+package resource
+
+import "context"
+
+// StoppedChecker is an optional interface for resources that may still be
+// shutting down after their Stop method returns, such as k8s workloads whose
+// pods are terminated in the background.
+type StoppedChecker interface {
+	Resource
+	Stopped(context.Context) (bool, error)
+}
+
+// Stopped checks whether the resource has fully stopped, after Stop has been
+// called. Resources that do not implement [StoppedChecker] are assumed to have
+// stopped once their Stop method returns.
+func Stopped(ctx context.Context, r Resource) (bool, error) {
+	if sc, ok := r.(StoppedChecker); ok {
+		return sc.Stopped(ctx)
+	}
+	return true, nil
+}
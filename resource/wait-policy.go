@@ -0,0 +1,130 @@
+package resource
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ErrWaitTimeout is returned (wrapped) by [WaitPolicy.Poll] when the timeout is
+// reached before the resource is ready.
+var ErrWaitTimeout = errors.New("timed out waiting for ready")
+
+// WaitPolicy controls how a resource polls while waiting to be ready.
+type WaitPolicy struct {
+	// Interval is the delay before the first re-check.
+	Interval time.Duration
+	// Backoff, if greater than 1, multiplies the interval after each check that
+	// is not ready.
+	Backoff float64
+	// MaxInterval, if set, caps the interval when backing off.
+	MaxInterval time.Duration
+	// Timeout, if set, limits the total time spent waiting.
+	Timeout time.Duration
+	// LastError, if set, is called when the timeout is reached, to describe why
+	// the resource is still not ready in the returned error.
+	LastError func(context.Context) string
+	// Wake, if set, triggers an immediate re-check when it receives, e.g. from
+	// [ReadyWatcher.WatchReady]. Polling continues as a fallback.
+	Wake <-chan struct{}
+}
+
+type WaitOption func(*WaitPolicy)
+
+// NewWaitPolicy creates a policy that polls at a fixed interval with no
+// timeout, modified by the given options.
+func NewWaitPolicy(interval time.Duration, opts ...WaitOption) WaitPolicy {
+	p := WaitPolicy{Interval: interval}
+	for _, o := range opts {
+		o(&p)
+	}
+	return p
+}
+
+// WaitInterval sets the (initial) poll interval.
+func WaitInterval(interval time.Duration) WaitOption {
+	if interval <= 0 {
+		panic(fmt.Errorf("wait interval must be positive, got %v", interval))
+	}
+	return func(p *WaitPolicy) { p.Interval = interval }
+}
+
+// WaitBackoff makes the poll interval grow exponentially by factor after each
+// check, up to maxInterval (if non-zero).
+func WaitBackoff(factor float64, maxInterval time.Duration) WaitOption {
+	if factor < 1 || maxInterval < 0 {
+		panic(fmt.Errorf("invalid wait backoff %v up to %v", factor, maxInterval))
+	}
+	return func(p *WaitPolicy) { p.Backoff, p.MaxInterval = factor, maxInterval }
+}
+
+// WaitTimeout limits the total time spent waiting. Zero means no limit.
+func WaitTimeout(timeout time.Duration) WaitOption {
+	if timeout < 0 {
+		panic(fmt.Errorf("wait timeout must not be negative, got %v", timeout))
+	}
+	return func(p *WaitPolicy) { p.Timeout = timeout }
+}
+
+// WaitLastError provides a function to describe why the resource is still not
+// ready when the timeout is reached.
+func WaitLastError(lastError func(context.Context) string) WaitOption {
+	return func(p *WaitPolicy) { p.LastError = lastError }
+}
+
+// WaitWake re-checks whenever wake receives, in addition to polling.
+func WaitWake(wake <-chan struct{}) WaitOption {
+	return func(p *WaitPolicy) { p.Wake = wake }
+}
+
+// Poll calls check until it returns true or an error, waiting between calls as
+// per the policy.
+func (p WaitPolicy) Poll(ctx context.Context, check func(context.Context) (bool, error)) error {
+	var deadline time.Time
+	if p.Timeout > 0 {
+		deadline = time.Now().Add(p.Timeout)
+	}
+	interval := p.Interval
+	wake := p.Wake
+	timer := time.NewTimer(interval)
+	defer timer.Stop()
+	for {
+		if ready, err := check(ctx); err != nil {
+			return err
+		} else if ready {
+			return nil
+		}
+
+		if !deadline.IsZero() && !time.Now().Before(deadline) {
+			if p.LastError != nil {
+				if msg := p.LastError(ctx); msg != "" {
+					return fmt.Errorf("%w after %v: %s", ErrWaitTimeout, p.Timeout, msg)
+				}
+			}
+			return fmt.Errorf("%w after %v", ErrWaitTimeout, p.Timeout)
+		}
+		wait := interval
+		if !deadline.IsZero() {
+			wait = min(wait, time.Until(deadline))
+		}
+		timer.Reset(wait)
+		select {
+		case <-ctx.Done():
+			return context.Cause(ctx)
+		case <-timer.C:
+			// only back off when polling, wake ups are not failed polls
+			if p.Backoff > 1 {
+				interval = time.Duration(float64(interval) * p.Backoff)
+				if p.MaxInterval > 0 {
+					interval = min(interval, p.MaxInterval)
+				}
+			}
+		case _, ok := <-wake:
+			if !ok {
+				// stop selecting on the closed channel, just poll
+				wake = nil
+			}
+		}
+	}
+}
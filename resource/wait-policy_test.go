@@ -0,0 +1,87 @@
+package resource
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWaitPolicy_Poll(t *testing.T) {
+	t.Run("backoff", func(t *testing.T) {
+		p := NewWaitPolicy(time.Millisecond, WaitBackoff(2, 4*time.Millisecond))
+		var times []time.Time
+		require.NoError(t, p.Poll(t.Context(), func(context.Context) (bool, error) {
+			times = append(times, time.Now())
+			return len(times) == 5, nil
+		}))
+		require.Len(t, times, 5)
+		// intervals should be at least 1, 2, 4, 4ms
+		for i, want := range []time.Duration{1, 2, 4, 4} {
+			assert.GreaterOrEqual(t, times[i+1].Sub(times[i]), want*time.Millisecond, i)
+		}
+	})
+	t.Run("error", func(t *testing.T) {
+		boom := errors.New("boom")
+		err := NewWaitPolicy(time.Millisecond).Poll(t.Context(), func(context.Context) (bool, error) {
+			return false, boom
+		})
+		assert.ErrorIs(t, err, boom)
+	})
+	t.Run("timeout", func(t *testing.T) {
+		p := NewWaitPolicy(time.Millisecond,
+			WaitTimeout(10*time.Millisecond),
+			WaitLastError(func(context.Context) string { return "still broken" }),
+		)
+		err := p.Poll(t.Context(), func(context.Context) (bool, error) { return false, nil })
+		assert.ErrorIs(t, err, ErrWaitTimeout)
+		assert.ErrorContains(t, err, "still broken")
+	})
+	t.Run("wake", func(t *testing.T) {
+		wake := make(chan struct{}, 1)
+		p := NewWaitPolicy(time.Hour, WaitWake(wake))
+		checks := 0
+		require.NoError(t, p.Poll(t.Context(), func(context.Context) (bool, error) {
+			checks++
+			if checks == 1 {
+				wake <- struct{}{}
+			}
+			return checks == 2, nil
+		}))
+		// a closed wake channel falls back on polling
+		close(wake)
+		p = NewWaitPolicy(time.Millisecond, WaitWake(wake))
+		checks = 0
+		require.NoError(t, p.Poll(t.Context(), func(context.Context) (bool, error) {
+			checks++
+			return checks == 3, nil
+		}))
+	})
+	t.Run("wake does not back off", func(t *testing.T) {
+		wake := make(chan struct{}, 1)
+		p := NewWaitPolicy(10*time.Millisecond, WaitWake(wake), WaitBackoff(100, time.Hour))
+		ctx, cancel := context.WithTimeout(t.Context(), 5*time.Second)
+		defer cancel()
+		checks := 0
+		// the poll after the wake ups still uses the initial interval
+		require.NoError(t, p.Poll(ctx, func(context.Context) (bool, error) {
+			checks++
+			if checks <= 5 {
+				wake <- struct{}{}
+			}
+			return checks == 7, nil
+		}))
+	})
+	t.Run("waiter timeout includes status", func(t *testing.T) {
+		w := Waiter("x", func(context.Context) (bool, error) { return false, nil }, WaitTimeout(time.Millisecond)).
+			WithReadyDetails(func(context.Context) (ReadyStatus, error) {
+				return ReadyStatus{State: ReadyStateNotReady, Message: "0/1 replicas"}, nil
+			})
+		err := w.Start(t.Context())
+		assert.ErrorIs(t, err, ErrWaitTimeout)
+		assert.ErrorContains(t, err, "0/1 replicas")
+	})
+}
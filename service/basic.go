@@ -4,6 +4,8 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"maps"
+	"slices"
 	"strings"
 	"unicode"
 
@@ -14,9 +16,17 @@ type basicService struct {
 	name      string
 	resources []func(context.Context) ([]resource.Resource, error)
 	hasModal  map[Mode]bool
+	dependsOn []string
+	env       []func(context.Context) (map[string]string, error)
+	debug     func(context.Context) (DebugLaunch, error)
 }
 
-var _ Service = (*basicService)(nil)
+var (
+	_ Service                 = (*basicService)(nil)
+	_ ServiceWithDependencies = (*basicService)(nil)
+	_ EnvProvider             = (*basicService)(nil)
+	_ DebugLauncher           = (*basicService)(nil)
+)
 
 // Name implements Service.
 func (s *basicService) Name() string {
@@ -38,7 +48,41 @@ func (s *basicService) Resources(ctx context.Context) ([]resource.Resource, erro
 }
 
 func (s *basicService) HasModal(mode Mode) bool {
-	return mode != ModeDisabled && s.hasModal[mode]
+	return mode != ModeDisabled && mode != ModeExcluded && s.hasModal[mode]
+}
+
+// DependsOn implements ServiceWithDependencies.
+func (s *basicService) DependsOn() []string {
+	return s.dependsOn
+}
+
+// Env implements EnvProvider.
+func (s *basicService) Env(ctx context.Context) (map[string]string, error) {
+	var ret map[string]string
+	var errs []error
+	for _, f := range s.env {
+		if env, err := f(ctx); err != nil {
+			errs = append(errs, err)
+		} else if len(env) != 0 {
+			if ret == nil {
+				ret = make(map[string]string, len(env))
+			}
+			maps.Copy(ret, env)
+		}
+	}
+	return ret, errors.Join(errs...)
+}
+
+// DebugLaunch implements DebugLauncher.
+func (s *basicService) DebugLaunch(ctx context.Context) (*DebugLaunch, error) {
+	if s.debug == nil {
+		return nil, nil
+	}
+	launch, err := s.debug(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &launch, nil
 }
 
 func New(
@@ -100,7 +144,7 @@ func WithModalResourceFuncs(
 	mode Mode,
 	funcs ...func(context.Context) ([]resource.Resource, error),
 ) BasicOpt {
-	if !mode.Valid() || mode == ModeDisabled {
+	if !mode.Valid() || mode == ModeDisabled || mode == ModeExcluded {
 		panic(fmt.Errorf("invalid mode %s for modal resources", mode))
 	}
 	return func(svc Service, bs *basicService) Service {
@@ -127,3 +171,43 @@ func WithModalResourceFuncs(
 		return svc
 	}
 }
+
+// WithDependsOn declares that this service depends on the named other services.
+// The stack will start those services before this one, and stop this one
+// before them.
+func WithDependsOn(names ...string) BasicOpt {
+	for _, n := range names {
+		if n == "" || strings.ContainsFunc(n, unicode.IsSpace) {
+			panic(fmt.Errorf("invalid dependency service name %q", n))
+		}
+	}
+	return func(svc Service, bs *basicService) Service {
+		for _, n := range names {
+			if n == bs.name {
+				panic(fmt.Errorf("service %s cannot depend on itself", n))
+			}
+			if !slices.Contains(bs.dependsOn, n) {
+				bs.dependsOn = append(bs.dependsOn, n)
+			}
+		}
+		return svc
+	}
+}
+
+// WithEnv adds environment variables the service publishes for connecting to
+// it, see [EnvProvider]. Later values override earlier ones with the same name.
+func WithEnv(env map[string]string) BasicOpt {
+	for k := range env {
+		checkEnvName(k)
+	}
+	env = maps.Clone(env)
+	return WithEnvFunc(func(context.Context) (map[string]string, error) { return env, nil })
+}
+
+// WithEnvFunc is like [WithEnv], but computes the variables on demand.
+func WithEnvFunc(fn func(context.Context) (map[string]string, error)) BasicOpt {
+	return func(svc Service, bs *basicService) Service {
+		bs.env = append(bs.env, fn)
+		return svc
+	}
+}
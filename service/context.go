@@ -3,6 +3,7 @@ package service
 import (
 	"context"
 	"fmt"
+	"time"
 
 	"fastcat.org/go/gdev/internal"
 )
@@ -11,6 +12,9 @@ type Context struct {
 	context.Context
 	serviceModes  map[string]Mode
 	noServiceWait bool
+	waitTimeouts  WaitTimeouts
+	env           *envSource
+	rollback      bool
 }
 
 func NewContext(
@@ -52,9 +56,42 @@ func WithoutServiceWait() ContextOption {
 	}
 }
 
+// WithRollbackOnFailure requests that if starting services fails, the
+// resources already started for them be stopped again, instead of leaving the
+// stack half started.
+func WithRollbackOnFailure() ContextOption {
+	return func(ctx *Context) {
+		ctx.rollback = true
+	}
+}
+
+// WaitTimeouts limits the final wait for non-infrastructure services to be
+// ready. Zero values mean no limit.
+type WaitTimeouts struct {
+	// Overall limits the total time spent waiting for all resources.
+	Overall time.Duration
+	// PerResource limits how long any one resource may take to become ready
+	// after it was started.
+	PerResource time.Duration
+}
+
+// WithServiceWaitTimeouts sets limits on the final wait for non-infrastructure
+// services to be ready.
+func WithServiceWaitTimeouts(timeouts WaitTimeouts) ContextOption {
+	if timeouts.Overall < 0 || timeouts.PerResource < 0 {
+		panic(fmt.Errorf("invalid negative wait timeouts %+v", timeouts))
+	}
+	return func(ctx *Context) {
+		ctx.waitTimeouts = timeouts
+	}
+}
+
 type (
 	modesKey         struct{}
 	noServiceWaitKey struct{}
+	waitTimeoutsKey  struct{}
+	envKey           struct{}
+	rollbackKey      struct{}
 )
 
 func (ctx *Context) Value(key any) any {
@@ -62,6 +99,12 @@ func (ctx *Context) Value(key any) any {
 		return ctx.serviceModes
 	} else if _, ok := key.(noServiceWaitKey); ok {
 		return ctx.noServiceWait
+	} else if _, ok := key.(waitTimeoutsKey); ok {
+		return ctx.waitTimeouts
+	} else if _, ok := key.(envKey); ok {
+		return ctx.env
+	} else if _, ok := key.(rollbackKey); ok {
+		return ctx.rollback
 	}
 	return ctx.Context.Value(key)
 }
@@ -78,3 +121,14 @@ func NoServiceWait(ctx context.Context) bool {
 	noServiceWait, ok := ctx.Value(noServiceWaitKey{}).(bool)
 	return ok && noServiceWait
 }
+
+// RollbackOnFailure checks if [WithRollbackOnFailure] was used.
+func RollbackOnFailure(ctx context.Context) bool {
+	rollback, ok := ctx.Value(rollbackKey{}).(bool)
+	return ok && rollback
+}
+
+func ServiceWaitTimeouts(ctx context.Context) WaitTimeouts {
+	timeouts, _ := ctx.Value(waitTimeoutsKey{}).(WaitTimeouts)
+	return timeouts
+}
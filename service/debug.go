@@ -0,0 +1,64 @@
+package service
+
+import (
+	"context"
+	"maps"
+	"slices"
+)
+
+// Debugger names the kind of debugger used to launch a service.
+type Debugger string
+
+const (
+	// DebuggerGo launches a Go main package with delve.
+	DebuggerGo Debugger = "go"
+	// DebuggerNode launches a Node.js script with the inspector enabled.
+	DebuggerNode Debugger = "node"
+)
+
+// DebugLaunch describes how to run a service under a debugger. The fields
+// mirror those used to run a service normally, such as in a pm Exec.
+type DebugLaunch struct {
+	Debugger Debugger
+	// Program is the Go package or Node script to run.
+	Program string
+	Args    []string
+	Env     map[string]string
+	// Cwd is the working directory, if not the default.
+	Cwd string
+}
+
+// DebugLauncher is an optional interface for services that may be able to run
+// under a debugger, see [ModeDebug].
+type DebugLauncher interface {
+	Service
+	// DebugLaunch describes how to run the service under a debugger, or returns
+	// nil if it doesn't support that.
+	DebugLaunch(context.Context) (*DebugLaunch, error)
+}
+
+// GetDebugLaunch gets how to run svc under a debugger, or nil if it doesn't
+// support that.
+func GetDebugLaunch(ctx context.Context, svc Service) (*DebugLaunch, error) {
+	if dl, ok := svc.(DebugLauncher); ok {
+		return dl.DebugLaunch(ctx)
+	}
+	return nil, nil
+}
+
+// WithDebugLaunch sets how to run the service under a debugger, see
+// [DebugLauncher].
+func WithDebugLaunch(launch DebugLaunch) BasicOpt {
+	launch.Args = slices.Clone(launch.Args)
+	launch.Env = maps.Clone(launch.Env)
+	return WithDebugLaunchFunc(func(context.Context) (DebugLaunch, error) { return launch, nil })
+}
+
+// WithDebugLaunchFunc is like [WithDebugLaunch], but computes the launch on
+// demand.
+func WithDebugLaunchFunc(fn func(context.Context) (DebugLaunch, error)) BasicOpt {
+	return func(svc Service, bs *basicService) Service {
+		bs.debug = fn
+		return svc
+	}
+}
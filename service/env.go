@@ -0,0 +1,94 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"unicode"
+)
+
+// EnvProvider is an optional interface for services that publish environment
+// variables for connecting to them, such as host, port, and credentials.
+type EnvProvider interface {
+	Service
+	Env(context.Context) (map[string]string, error)
+}
+
+// Env gets the environment variables svc publishes, or nil if it does not
+// implement [EnvProvider].
+func Env(ctx context.Context, svc Service) (map[string]string, error) {
+	if ep, ok := svc.(EnvProvider); ok {
+		return ep.Env(ctx)
+	}
+	return nil, nil
+}
+
+func checkEnvName(name string) {
+	if name == "" || strings.ContainsFunc(name, func(r rune) bool {
+		return r == '=' || unicode.IsSpace(r)
+	}) {
+		panic(fmt.Errorf("invalid environment variable name %q", name))
+	}
+}
+
+// WithEnvSource sets the source for the environment variables a service may
+// consume from the services it depends on, for use by its resources. The source
+// is called at most once per service, when [ProvidedEnv] is first called for
+// it.
+func WithEnvSource(source func(ctx context.Context, svc Service) (map[string]string, error)) ContextOption {
+	return func(ctx *Context) {
+		ctx.env = &envSource{
+			// the source must not depend on the context of the first caller, so we
+			// give it the context it was registered with
+			ctx:    ctx,
+			source: source,
+			cache:  map[string]func() (map[string]string, error){},
+		}
+	}
+}
+
+type envSource struct {
+	ctx    context.Context
+	source func(context.Context, Service) (map[string]string, error)
+	mu     sync.Mutex
+	cache  map[string]func() (map[string]string, error)
+}
+
+func (s *envSource) get(svc Service) (map[string]string, error) {
+	s.mu.Lock()
+	get, ok := s.cache[svc.Name()]
+	if !ok {
+		get = sync.OnceValues(func() (map[string]string, error) { return s.source(s.ctx, svc) })
+		s.cache[svc.Name()] = get
+	}
+	s.mu.Unlock()
+	return get()
+}
+
+type currentServiceKey struct{}
+
+// WithCurrentService marks the context as being used to operate on the
+// resources of svc, which selects the environment [ProvidedEnv] returns.
+func WithCurrentService(ctx context.Context, svc Service) context.Context {
+	return context.WithValue(ctx, currentServiceKey{}, svc)
+}
+
+// CurrentService gets the service set with [WithCurrentService], or nil if
+// there is none.
+func CurrentService(ctx context.Context) Service {
+	svc, _ := ctx.Value(currentServiceKey{}).(Service)
+	return svc
+}
+
+// ProvidedEnv gets the environment variables provided to the current service
+// (see [WithCurrentService]) by the source set with [WithEnvSource]. It returns
+// nil if there is no source or no current service.
+func ProvidedEnv(ctx context.Context) (map[string]string, error) {
+	source, _ := ctx.Value(envKey{}).(*envSource)
+	svc := CurrentService(ctx)
+	if source == nil || svc == nil {
+		return nil, nil
+	}
+	return source.get(svc)
+}
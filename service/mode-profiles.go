@@ -0,0 +1,176 @@
+package service
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+
+	"fastcat.org/go/gdev/internal"
+	"fastcat.org/go/gdev/lib/config"
+)
+
+func init() {
+	config.AddKey(modeProfilesKey{})
+}
+
+var defaultModeProfiles = map[string]map[string]Mode{}
+
+// AddDefaultModeProfile registers an app-defined mode profile, which will be
+// available to users unless they save their own profile with the same name.
+//
+// This must be called during app initialization, before customizations are
+// locked.
+func AddDefaultModeProfile(name string, modes map[string]Mode) {
+	internal.CheckCanCustomize()
+	if err := checkProfileName(name); err != nil {
+		panic(err)
+	}
+	if _, ok := defaultModeProfiles[name]; ok {
+		panic(fmt.Errorf("default mode profile %q already registered", name))
+	}
+	for svc, mode := range modes {
+		if svc == "" {
+			panic(fmt.Errorf("empty service name in mode profile %q", name))
+		} else if !mode.Valid() {
+			panic(fmt.Errorf("invalid mode %d for service %q in mode profile %q", mode, svc, name))
+		}
+	}
+	defaultModeProfiles[name] = withoutDefaultModes(modes)
+}
+
+func checkProfileName(name string) error {
+	if name == "" || strings.ContainsFunc(name, func(r rune) bool { return r == '/' || r == ' ' }) {
+		return fmt.Errorf("invalid mode profile name %q", name)
+	}
+	return nil
+}
+
+type modeProfilesKey struct{}
+
+// Name implements config.ConfigKey.
+func (modeProfilesKey) Name() string {
+	return "service-mode-profiles"
+}
+
+// IsDefault implements config.ConfigKey.
+func (modeProfilesKey) IsDefault(value map[string]map[string]Mode) bool {
+	return len(value) == 0
+}
+
+// New implements config.ConfigKey.
+func (modeProfilesKey) New() map[string]map[string]Mode {
+	return map[string]map[string]Mode{}
+}
+
+// NewFrom implements config.ConfigKey.
+func (modeProfilesKey) NewFrom(value any) (map[string]map[string]Mode, error) {
+	m, ok := value.(map[string]any)
+	if !ok {
+		return nil, fmt.Errorf("expected map[string]any for service-mode-profiles, got %T", value)
+	}
+	profiles := make(map[string]map[string]Mode, len(m))
+	for name, v := range m {
+		modes, err := serviceModesKey{}.NewFrom(v)
+		if err != nil {
+			return nil, fmt.Errorf("in service-mode-profiles[%q]: %w", name, err)
+		}
+		profiles[name] = modes
+	}
+	return profiles, nil
+}
+
+// ModeProfileNames lists the available mode profiles, both user-saved and
+// app-defined, in sorted order.
+func ModeProfileNames() []string {
+	names := slices.Collect(maps.Keys(config.Get(modeProfilesKey{})))
+	for name := range defaultModeProfiles {
+		if !slices.Contains(names, name) {
+			names = append(names, name)
+		}
+	}
+	slices.Sort(names)
+	return names
+}
+
+// ModeProfile gets a copy of the named mode profile. A user-saved profile takes
+// precedence over an app-defined one with the same name.
+func ModeProfile(name string) (map[string]Mode, bool) {
+	if modes, ok := config.Get(modeProfilesKey{})[name]; ok {
+		return maps.Clone(modes), true
+	} else if modes, ok := defaultModeProfiles[name]; ok {
+		return maps.Clone(modes), true
+	}
+	return nil, false
+}
+
+// IsDefaultModeProfile reports whether the named profile is app-defined and
+// has not been overridden by a user-saved profile.
+func IsDefaultModeProfile(name string) bool {
+	if _, ok := config.Get(modeProfilesKey{})[name]; ok {
+		return false
+	}
+	_, ok := defaultModeProfiles[name]
+	return ok
+}
+
+// SaveModeProfile saves the given modes as a user profile, replacing any
+// existing profile with the same name.
+func SaveModeProfile(name string, modes map[string]Mode) error {
+	if err := checkProfileName(name); err != nil {
+		return err
+	}
+	profiles := config.Get(modeProfilesKey{})
+	profiles[name] = withoutDefaultModes(modes)
+	config.SetDirty()
+	return nil
+}
+
+// DeleteModeProfile deletes a user-saved profile. App-defined profiles cannot
+// be deleted, though if a user profile was overriding one, it will become
+// visible again.
+func DeleteModeProfile(name string) error {
+	if err := checkProfileName(name); err != nil {
+		return err
+	}
+	profiles := config.Get(modeProfilesKey{})
+	if _, ok := profiles[name]; !ok {
+		if _, ok := defaultModeProfiles[name]; ok {
+			return fmt.Errorf("profile %s is built-in and cannot be deleted", name)
+		}
+		return fmt.Errorf("unknown mode profile %q", name)
+	}
+	delete(profiles, name)
+	config.SetDirty()
+	return nil
+}
+
+// UseModeProfile replaces the configured service modes with those from the
+// named profile.
+func UseModeProfile(name string) error {
+	if err := checkProfileName(name); err != nil {
+		return err
+	}
+	modes, ok := ModeProfile(name)
+	if !ok {
+		return fmt.Errorf("unknown mode profile %q", name)
+	}
+	sm := config.Get(serviceModesKey{})
+	if maps.Equal(sm, modes) {
+		return nil
+	}
+	clear(sm)
+	maps.Copy(sm, modes)
+	config.SetDirty()
+	return nil
+}
+
+func withoutDefaultModes(modes map[string]Mode) map[string]Mode {
+	ret := make(map[string]Mode, len(modes))
+	for k, v := range modes {
+		if v != ModeDefault {
+			ret[k] = v
+		}
+	}
+	return ret
+}
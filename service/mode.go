@@ -12,9 +12,10 @@ const (
 	ModeLocal                // local
 	ModeDebug                // debug
 	ModeDisabled             // disabled
-
-	// TODO: consider an excluded mode that makes us just pretend the service
-	// isn't registered, we neither stop nor start any of its services.
+	// ModeExcluded makes the stack pretend the service isn't registered, it will
+	// neither start nor stop any of its resources. This is useful when running
+	// the service manually, e.g. from an IDE.
+	ModeExcluded // excluded
 )
 
 //go:generate go tool stringer -type=Mode -linecomment
@@ -35,6 +36,13 @@ func ValidModeNames() []string {
 	return names
 }
 
+// RequiresModal reports whether a service must explicitly support this mode
+// (see [Service.HasModal]) to be used in it. Some modes are implicitly
+// supported by all services.
+func (m Mode) RequiresModal() bool {
+	return m != ModeDebug && m != ModeDisabled && m != ModeExcluded
+}
+
 func (m Mode) Valid() bool {
 	return m >= 0 && m < Mode(len(_Mode_index)-1)
 }
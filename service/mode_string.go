@@ -12,11 +12,12 @@ func _() {
 	_ = x[ModeLocal-1]
 	_ = x[ModeDebug-2]
 	_ = x[ModeDisabled-3]
+	_ = x[ModeExcluded-4]
 }
 
-const _Mode_name = "defaultlocaldebugdisabled"
+const _Mode_name = "defaultlocaldebugdisabledexcluded"
 
-var _Mode_index = [...]uint8{0, 7, 12, 17, 25}
+var _Mode_index = [...]uint8{0, 7, 12, 17, 25, 33}
 
 func (i Mode) String() string {
 	idx := int(i) - 0
@@ -23,3 +23,20 @@ type ServiceWithSource interface {
 	RemoteSource(context.Context) (vcs, repo string, err error)
 	UsesSourceInMode(mode Mode) bool
 }
+
+// ServiceWithDependencies is implemented by services that declare other
+// services they depend on, see [WithDependsOn].
+type ServiceWithDependencies interface {
+	Service
+	// DependsOn returns the names of the services this one depends on.
+	DependsOn() []string
+}
+
+// Dependencies returns the names of the services svc depends on, or nil if it
+// does not declare any.
+func Dependencies(svc Service) []string {
+	if sd, ok := svc.(ServiceWithDependencies); ok {
+		return sd.DependsOn()
+	}
+	return nil
+}
@@ -8,7 +8,12 @@ type serviceWithSource struct {
 	remoteSource func(context.Context) (vcs, repo string, err error)
 }
 
-var _ ServiceWithSource = (*serviceWithSource)(nil)
+var (
+	_ ServiceWithSource       = (*serviceWithSource)(nil)
+	_ ServiceWithDependencies = (*serviceWithSource)(nil)
+	_ EnvProvider             = (*serviceWithSource)(nil)
+	_ DebugLauncher           = (*serviceWithSource)(nil)
+)
 
 func WithSource(
 	localRoot, localSubDir string,
@@ -50,3 +55,19 @@ func (s *serviceWithSource) UsesSourceInMode(mode Mode) bool {
 	// mode uses docker/etc artifacts instead of any source code.
 	return mode == ModeLocal
 }
+
+// DependsOn implements ServiceWithDependencies, forwarding to the wrapped
+// service.
+func (s *serviceWithSource) DependsOn() []string {
+	return Dependencies(s.Service)
+}
+
+// Env implements EnvProvider, forwarding to the wrapped service.
+func (s *serviceWithSource) Env(ctx context.Context) (map[string]string, error) {
+	return Env(ctx, s.Service)
+}
+
+// DebugLaunch implements DebugLauncher, forwarding to the wrapped service.
+func (s *serviceWithSource) DebugLaunch(ctx context.Context) (*DebugLaunch, error) {
+	return GetDebugLaunch(ctx, s.Service)
+}